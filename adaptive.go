@@ -0,0 +1,214 @@
+package run
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultHistorySize is the number of durations a History keeps per
+// component and phase.
+const DefaultHistorySize = 100
+
+// History records the durations of the start and stop calls of components,
+// by component name, across runs of a group and, when marshaled to JSON and
+// back, across processes. The zero value is not usable; use NewHistory.
+type History struct {
+	mu        sync.Mutex
+	size      int
+	durations map[string]map[Phase][]time.Duration // oldest first
+}
+
+// NewHistory returns an empty History keeping the last size durations per
+// component and phase, or DefaultHistorySize if size is not positive.
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	return &History{size: size, durations: make(map[string]map[Phase][]time.Duration)}
+}
+
+// Durations returns the recorded durations of component's calls in phase,
+// oldest first.
+func (h *History) Durations(component string, phase Phase) []time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return slices.Clone(h.durations[component][phase])
+}
+
+// Percentile returns the q-quantile, with q between 0 and 1, of the
+// recorded durations of component's calls in phase, and how many durations
+// it is based on.
+func (h *History) Percentile(component string, phase Phase, q float64) (time.Duration, int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return percentile(h.durations[component][phase], q)
+}
+
+// add records that component's call in phase took d.
+func (h *History) add(component string, phase Phase, d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	phases := h.durations[component]
+	if phases == nil {
+		phases = make(map[Phase][]time.Duration)
+		h.durations[component] = phases
+	}
+	ds := append(phases[phase], d)
+	if len(ds) > h.size {
+		ds = slices.Delete(ds, 0, len(ds)-h.size)
+	}
+	phases[phase] = ds
+}
+
+// replace replaces the recorded durations with those of other.
+func (h *History) replace(other *History) {
+	other.mu.Lock()
+	size, durations := other.size, other.durations
+	other.mu.Unlock()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.size, h.durations = size, durations
+}
+
+// historyJSON is the JSON form of a History.
+type historyJSON struct {
+	Size      int                                  `json:"size"`
+	Durations map[string]map[Phase][]time.Duration `json:"durations"`
+}
+
+// MarshalJSON encodes the history, with durations in nanoseconds.
+func (h *History) MarshalJSON() ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return json.Marshal(historyJSON{Size: h.size, Durations: h.durations})
+}
+
+// UnmarshalJSON replaces the history with one encoded by MarshalJSON.
+func (h *History) UnmarshalJSON(data []byte) error {
+	var v historyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Size <= 0 {
+		v.Size = DefaultHistorySize
+	}
+	if v.Durations == nil {
+		v.Durations = make(map[string]map[Phase][]time.Duration)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.size, h.durations = v.Size, v.Durations
+	return nil
+}
+
+// percentile returns the q-quantile of ds and len(ds).
+func percentile(ds []time.Duration, q float64) (time.Duration, int) {
+	if len(ds) == 0 {
+		return 0, 0
+	}
+	sorted := slices.Clone(ds)
+	slices.Sort(sorted)
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)], len(sorted)
+}
+
+// AdaptivePolicy derives each component's stop timeout from the history of
+// its stop durations. The zero value uses the 99th percentile plus one
+// second once five stops were recorded.
+type AdaptivePolicy struct {
+	// Percentile is the quantile of the recorded stop durations, between 0
+	// and 1, that the timeout is based on. Zero means 0.99.
+	Percentile float64
+
+	// Margin is added to the percentile. Zero means one second.
+	Margin time.Duration
+
+	// Min and Max bound the timeout. Zero means no bound; the group's stop
+	// timeout applies in any case.
+	Min, Max time.Duration
+
+	// MinSamples is the number of recorded stops below which the component
+	// is bounded by the group's stop timeout only. Zero means five.
+	MinSamples int
+
+	// OnRegression, if not nil, is called when a stop took longer than the
+	// percentile of the stops before it, such as to alert on a component
+	// that got slower to stop after a deploy.
+	OnRegression func(component string, took, percentile time.Duration)
+}
+
+// adaptive is the TimeoutPolicy set with WithAdaptiveTimeouts.
+type adaptive struct {
+	h *History
+	p AdaptivePolicy
+}
+
+// WithAdaptiveTimeouts returns an Option that records the durations of
+// start and stop calls in h and bounds each component's stop call by a
+// timeout learned from its earlier stops, as configured by p, instead of
+// one static timeout that is too tight for some components and too loose
+// for others. Start calls are bounded by the start timeout only. Persist h
+// to keep learning across processes.
+//
+// It replaces a policy set with WithTimeoutPolicy.
+func WithAdaptiveTimeouts(h *History, p AdaptivePolicy) Option {
+	if p.Percentile <= 0 {
+		p.Percentile = 0.99
+	}
+	if p.Margin == 0 {
+		p.Margin = time.Second
+	}
+	if p.MinSamples <= 0 {
+		p.MinSamples = 5
+	}
+	a := &adaptive{h: h, p: p}
+	return optionFunc(func(o *options) {
+		o.timeoutPolicy = a
+		o.adaptive = a
+	})
+}
+
+// StartTimeoutFor returns zero.
+func (a *adaptive) StartTimeoutFor(ComponentInfo) time.Duration {
+	return 0
+}
+
+// StopTimeoutFor returns the learned stop timeout of c.
+func (a *adaptive) StopTimeoutFor(c ComponentInfo) time.Duration {
+	d, n := a.h.Percentile(c.Name, PhaseStop, a.p.Percentile)
+	if n < a.p.MinSamples {
+		return 0
+	}
+	d += a.p.Margin
+	if a.p.Min > 0 {
+		d = max(d, a.p.Min)
+	}
+	if a.p.Max > 0 {
+		d = min(d, a.p.Max)
+	}
+	return d
+}
+
+// observe records that c's call in phase took d and reports a regression.
+func (a *adaptive) observe(c *component, phase Phase, d time.Duration) {
+	if phase != PhaseStart && phase != PhaseStop {
+		return
+	}
+	if phase == PhaseStop && a.p.OnRegression != nil {
+		if q, n := a.h.Percentile(c.name, phase, a.p.Percentile); n >= a.p.MinSamples && d > q {
+			a.p.OnRegression(c.name, d, q)
+		}
+	}
+	a.h.add(c.name, phase, d)
+}
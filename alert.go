@@ -0,0 +1,76 @@
+package run
+
+import "fmt"
+
+// DefaultAlertThreshold is the number of consecutive runs with the same
+// failure after which WithAlerts alerts by default.
+const DefaultAlertThreshold = 2
+
+// AlertKind categorizes an Alert.
+type AlertKind string
+
+// Alert kinds.
+const (
+	// AlertStartFailing reports a component whose start failed on
+	// consecutive boots.
+	AlertStartFailing AlertKind = "start_failing"
+
+	// AlertStopTimingOut reports a component whose stop timed out on
+	// consecutive runs, such as on every deploy.
+	AlertStopTimingOut AlertKind = "stop_timing_out"
+)
+
+// Alert reports a lifecycle failure that repeats across runs of the group.
+type Alert struct {
+	Kind      AlertKind // category of the failure
+	Component string    // component name
+	Runs      int       // number of consecutive runs with the failure, including this one
+}
+
+// String formats the alert as `component "db" start failing on 3
+// consecutive runs`.
+func (a Alert) String() string {
+	what := string(a.Kind)
+	switch a.Kind {
+	case AlertStartFailing:
+		what = "start failing"
+	case AlertStopTimingOut:
+		what = "stop timing out"
+	}
+	return fmt.Sprintf("component %q %s on %d consecutive runs", a.Component, what, a.Runs)
+}
+
+// WithAlerts returns an Option that detects failures repeating across runs
+// in the statistics kept with WithStats, and calls fn with an Alert for
+// every component that failed the same way on threshold or more
+// consecutive runs, or DefaultAlertThreshold if threshold is not positive.
+// Alerts are raised when Wait returns, before the statistics are saved, and
+// again on every further run with the failure. Without WithStats, fn is
+// never called.
+func WithAlerts(fn func(Alert), threshold int) Option {
+	if threshold <= 0 {
+		threshold = DefaultAlertThreshold
+	}
+	return optionFunc(func(o *options) {
+		o.alert = fn
+		o.alertThreshold = threshold
+	})
+}
+
+// alerts returns the alerts raised by stats, in the order of components.
+func (g *Group) alerts(stats Stats) []Alert {
+	if g.opts.alert == nil {
+		return nil
+	}
+	var alerts []Alert
+	for _, c := range g.registry.list() {
+		cs := stats.Components[c.name]
+		if cs.StartFailures >= g.opts.alertThreshold {
+			alerts = append(alerts, Alert{Kind: AlertStartFailing, Component: c.name, Runs: cs.StartFailures})
+		}
+		if cs.StopTimeouts >= g.opts.alertThreshold {
+			alerts = append(alerts, Alert{Kind: AlertStopTimingOut, Component: c.name, Runs: cs.StopTimeouts})
+		}
+	}
+	return alerts
+}
@@ -0,0 +1,63 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrUnauthorized is returned by control surfaces, wrapping the cause, when
+// an Authorizer denies a command.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Names of the commands control surfaces pass to an Authorizer.
+const (
+	CommandStatus  = "status"  // read the group's health and component states
+	CommandStop    = "stop"    // stop the components of the command, or the whole group without any
+	CommandRestart = "restart" // restart the components of the command
+	CommandReload  = "reload"  // reload the group
+)
+
+// Command is an operation requested through a control surface, such as
+// Group.DashboardHandler, the control package's unix socket or the
+// grpccontrol package's gRPC API.
+type Command struct {
+	Name       string        // one of the Command constants
+	Components []string      // components the command targets, none for the whole group
+	Request    *http.Request // the HTTP request, for HTTP surfaces only
+}
+
+// Authorizer decides whether a command may run. Control surfaces call it
+// before every command with a context describing the caller: the request
+// context for HTTP, a context carrying the peer's credentials for the
+// control package, and the incoming call's context, with its metadata and
+// peer, for gRPC.
+type Authorizer interface {
+	// Authorize returns nil to allow cmd, or an error to deny it.
+	Authorize(ctx context.Context, cmd Command) error
+}
+
+// AuthorizerFunc is an Authorizer implemented by a function.
+type AuthorizerFunc func(ctx context.Context, cmd Command) error
+
+// Authorize calls f.
+func (f AuthorizerFunc) Authorize(ctx context.Context, cmd Command) error {
+	return f(ctx, cmd)
+}
+
+// Authorize runs a, if any, for cmd and returns an error wrapping
+// ErrUnauthorized and the cause when it denies the command. Control
+// surfaces outside this package use it so they report denials alike.
+func Authorize(ctx context.Context, a Authorizer, cmd Command) error {
+	if a == nil {
+		return nil
+	}
+	if err := a.Authorize(ctx, cmd); err != nil {
+		if errors.Is(err, ErrUnauthorized) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+// Package batch runs a set of jobs with bounded concurrency as the work of a
+// job-mode run.Group, such as a nightly export or a migration, and
+// summarizes the outcome of every job.
+package batch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/not-for-prod/run"
+)
+
+// DefaultConcurrency is the default number of jobs running at the same time.
+const DefaultConcurrency = 4
+
+// Result is the outcome of a single job.
+type Result struct {
+	Name     string        // job name
+	Started  bool          // false if the job was canceled before it started
+	Duration time.Duration // time the job took
+	Err      error         // error the job returned, or why it did not start
+}
+
+// Summary is the outcome of a batch, with results in the order the jobs
+// were added.
+type Summary struct {
+	Results   []Result
+	Succeeded int // jobs that returned nil
+	Failed    int // jobs that returned an error
+	Canceled  int // jobs that did not start
+}
+
+// Error is returned by Run, and through Wait by a runner registered with
+// Register, when a job failed or did not run. It carries the whole summary.
+type Error struct {
+	Summary Summary
+}
+
+// Error reports the number of failed and canceled jobs and the first
+// failure.
+func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d of %d jobs failed", e.Summary.Failed, len(e.Summary.Results))
+	if e.Summary.Canceled > 0 {
+		fmt.Fprintf(&b, ", %d canceled", e.Summary.Canceled)
+	}
+	for _, r := range e.Summary.Results {
+		if r.Started && r.Err != nil {
+			fmt.Fprintf(&b, ": job %q: %v", r.Name, r.Err)
+			break
+		}
+	}
+	return b.String()
+}
+
+// Unwrap returns the errors of the failed jobs.
+func (e *Error) Unwrap() []error {
+	var errs []error
+	for _, r := range e.Summary.Results {
+		if r.Started && r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+	return errs
+}
+
+// Option configures a Runner.
+type Option func(*Runner)
+
+// WithConcurrency returns an Option that sets how many jobs run at the same
+// time. Values less than one are treated as one.
+//
+// Default is DefaultConcurrency.
+func WithConcurrency(n int) Option {
+	return func(r *Runner) {
+		r.concurrency = max(n, 1)
+	}
+}
+
+// WithFailFast returns an Option that cancels the running jobs and skips the
+// remaining ones as soon as a job fails.
+//
+// Default is to run every job.
+func WithFailFast() Option {
+	return func(r *Runner) {
+		r.failFast = true
+	}
+}
+
+// job is a named function added with Add.
+type job struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// Runner runs jobs with bounded concurrency.
+type Runner struct {
+	concurrency int
+	failFast    bool
+
+	mu      sync.Mutex
+	jobs    []job
+	summary Summary
+}
+
+// New returns a Runner configured by opts.
+func New(opts ...Option) *Runner {
+	r := &Runner{concurrency: DefaultConcurrency}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Add adds a job.
+func (r *Runner) Add(name string, fn func(ctx context.Context) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jobs = append(r.jobs, job{name: name, run: fn})
+}
+
+// Run runs every job, at most the configured number at a time, and returns
+// the summary, together with an Error if any job failed or did not start.
+// Jobs not started when ctx is done are canceled.
+func (r *Runner) Run(ctx context.Context) (Summary, error) {
+	r.mu.Lock()
+	jobs := r.jobs
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]Result, len(jobs))
+	sem := make(chan struct{}, r.concurrency)
+	var wg sync.WaitGroup
+	for i, j := range jobs {
+		results[i].Name = j.name
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			began := time.Now()
+			err := j.run(ctx)
+			results[i] = Result{Name: j.name, Started: true, Duration: time.Since(began), Err: err}
+			if err != nil && r.failFast {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	s := Summary{Results: results}
+	for _, res := range results {
+		switch {
+		case !res.Started:
+			s.Canceled++
+		case res.Err != nil:
+			s.Failed++
+		default:
+			s.Succeeded++
+		}
+	}
+
+	r.mu.Lock()
+	r.summary = s
+	r.mu.Unlock()
+
+	if s.Failed > 0 || s.Canceled > 0 {
+		return s, &Error{Summary: s}
+	}
+	return s, nil
+}
+
+// Summary returns the summary of the last Run, or the zero Summary before
+// the first one.
+func (r *Runner) Summary() Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.summary
+}
+
+// Register adds a component to g that runs the jobs once every other
+// component has started, and returns its handle. When the batch succeeds, the group is
+// stopped and Wait returns nil; when a job fails, Wait returns an error
+// wrapping an Error with the summary. Stopping the group early cancels the
+// jobs. Further component options, such as Named, may be passed in opts.
+func (r *Runner) Register(g *run.Group, opts ...run.ComponentOption) *run.Handle {
+	opts = append([]run.ComponentOption{run.Named("batch"), run.Priority(math.MinInt)}, opts...)
+	return g.Go(func(ctx context.Context) error {
+		if _, err := r.Run(ctx); err != nil && ctx.Err() == nil {
+			return err
+		}
+		go g.Stop(context.Background())
+		<-ctx.Done()
+		return nil
+	}, opts...)
+}
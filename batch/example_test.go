@@ -0,0 +1,58 @@
+package batch_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/batch"
+)
+
+func ExampleRunner_Register() {
+	r := batch.New(batch.WithConcurrency(2))
+	for _, table := range []string{"orders", "invoices", "refunds"} {
+		r.Add("export "+table, func(ctx context.Context) error {
+			if table == "refunds" {
+				return errors.New("permission denied")
+			}
+			return nil
+		})
+	}
+
+	g := run.NewGroup()
+	g.Add(func() error { return nil }, func(ctx context.Context) error {
+		fmt.Println("db closed")
+		return nil
+	}, run.Named("db"))
+	r.Register(g)
+
+	err := g.Wait(context.Background())
+	var berr *batch.Error
+	if errors.As(err, &berr) {
+		s := berr.Summary
+		fmt.Println("succeeded:", s.Succeeded, "failed:", s.Failed, "canceled:", s.Canceled)
+	}
+	fmt.Println(err)
+	// Output:
+	// db closed
+	// succeeded: 2 failed: 1 canceled: 0
+	// 1 of 3 jobs failed: job "export refunds": permission denied
+}
+
+func ExampleRunner_Run() {
+	r := batch.New(batch.WithConcurrency(1), batch.WithFailFast())
+	r.Add("migrate schema", func(ctx context.Context) error {
+		return errors.New("lock timeout")
+	})
+	r.Add("backfill", func(ctx context.Context) error {
+		return nil
+	})
+
+	s, err := r.Run(context.Background())
+	fmt.Println(s.Failed, s.Canceled)
+	fmt.Println(err)
+	// Output:
+	// 1 1
+	// 1 of 2 jobs failed, 1 canceled: job "migrate schema": lock timeout
+}
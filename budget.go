@@ -0,0 +1,123 @@
+package run
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBudgetFractions are the fractions of the start and stop timeouts
+// at which WithBudgetAlerts calls its callback by default.
+var DefaultBudgetFractions = []float64{0.5, 0.8, 1}
+
+// BudgetAlert reports that the start or stop phase has used up a fraction of
+// its timeout.
+type BudgetAlert struct {
+	Phase     Phase         // PhaseStart or PhaseStop
+	Fraction  float64       // fraction of the budget used up, such as 0.8
+	Budget    time.Duration // start or stop timeout
+	Remaining time.Duration // budget left, zero once it is used up
+	Pending   []string      // components whose start or stop call is still running, in registration order
+}
+
+// String formats the alert as "stop phase at 80% of 15s, 3s left, waiting
+// on: kafka-consumer, db-pool".
+func (a BudgetAlert) String() string {
+	s := fmt.Sprintf("%s phase at %.0f%% of %s, %s left", a.Phase, a.Fraction*100, a.Budget, a.Remaining)
+	if len(a.Pending) > 0 {
+		s += ", waiting on: " + strings.Join(a.Pending, ", ")
+	}
+	return s
+}
+
+// WithBudgetAlerts returns an Option that calls fn when the start or stop
+// phase is still running as it crosses the given fractions of its timeout,
+// or DefaultBudgetFractions if none are given, with the components still
+// pending and the budget left, so escalating warnings can be emitted before
+// the phase fails with a timeout.
+//
+// Calls run on a separate goroutine, so the callback should return quickly.
+func WithBudgetAlerts(fn func(BudgetAlert), fractions ...float64) Option {
+	if len(fractions) == 0 {
+		fractions = DefaultBudgetFractions
+	}
+	return optionFunc(func(o *options) {
+		o.budgetAlert = fn
+		o.budgetFractions = fractions
+	})
+}
+
+// watchBudget calls the budget alert callback as the phase that began now
+// with budget crosses the configured fractions, until the returned function
+// is called.
+func (g *Group) watchBudget(phase Phase, budget time.Duration, inFlight State) (stop func()) {
+	fn := g.opts.budgetAlert
+	if fn == nil || budget <= 0 {
+		return func() {}
+	}
+
+	// Each alert arms the timer of the next one, so no goroutine waits
+	// between them.
+	began := time.Now()
+	var (
+		mu      sync.Mutex
+		timer   *time.Timer
+		stopped bool
+	)
+	var arm func(i int)
+	arm = func(i int) {
+		if i == len(g.opts.budgetFractions) {
+			return
+		}
+		fraction := g.opts.budgetFractions[i]
+		at := time.Duration(float64(budget) * fraction)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if stopped {
+			return
+		}
+		timer = time.AfterFunc(at-time.Since(began), func() {
+			mu.Lock()
+			done := stopped
+			mu.Unlock()
+			if done {
+				return
+			}
+			fn(BudgetAlert{
+				Phase:     phase,
+				Fraction:  fraction,
+				Budget:    budget,
+				Remaining: max(budget-time.Since(began), 0),
+				Pending:   g.pending(inFlight),
+			})
+			arm(i + 1)
+		})
+	}
+	arm(0)
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = true
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// pending returns the names of the components in state inFlight, in
+// registration order.
+func (g *Group) pending(inFlight State) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var names []string
+	for _, c := range g.registry.list() {
+		if c.loadState() == inFlight {
+			names = append(names, c.name)
+		}
+	}
+	return names
+}
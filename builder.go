@@ -0,0 +1,96 @@
+package run
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// ComponentBuilder builds a Component step by step, for components with
+// many settings:
+//
+//	g.AddComponent(run.NewComponent("kafka").
+//		Start(connect).
+//		Stop(disconnect).
+//		DependsOn("config").
+//		Retry(3).
+//		Build())
+type ComponentBuilder struct {
+	c Component
+}
+
+// NewComponent returns a builder of a component with the given name.
+func NewComponent(name string) *ComponentBuilder {
+	return &ComponentBuilder{c: Component{Name: name}}
+}
+
+// Start sets the component's start function.
+func (b *ComponentBuilder) Start(start Start) *ComponentBuilder {
+	b.c.Start = start
+	return b
+}
+
+// Stop sets the component's stop function.
+func (b *ComponentBuilder) Stop(stop Stop) *ComponentBuilder {
+	b.c.Stop = stop
+	return b
+}
+
+// Timeout bounds each start and stop call of the component by d.
+func (b *ComponentBuilder) Timeout(d time.Duration) *ComponentBuilder {
+	b.c.Timeout = d
+	return b
+}
+
+// Tags adds roles the component belongs to.
+func (b *ComponentBuilder) Tags(tags ...string) *ComponentBuilder {
+	b.c.Tags = append(b.c.Tags, tags...)
+	return b
+}
+
+// Label attaches a key/value label to the component.
+func (b *ComponentBuilder) Label(key, value string) *ComponentBuilder {
+	if b.c.Labels == nil {
+		b.c.Labels = make(map[string]string)
+	}
+	b.c.Labels[key] = value
+	return b
+}
+
+// DependsOn adds components that must start before, and stop after, this
+// one.
+func (b *ComponentBuilder) DependsOn(names ...string) *ComponentBuilder {
+	b.c.DependsOn = append(b.c.DependsOn, names...)
+	return b
+}
+
+// Critical sets whether the group depends on the component. Components are
+// critical by default.
+func (b *ComponentBuilder) Critical(critical bool) *ComponentBuilder {
+	b.c.NonCritical = !critical
+	return b
+}
+
+// Retry repeats a failing start call up to n times before the component
+// fails.
+func (b *ComponentBuilder) Retry(n int) *ComponentBuilder {
+	b.c.Retry = n
+	return b
+}
+
+// With adds component options for settings the builder has no method for.
+func (b *ComponentBuilder) With(opts ...ComponentOption) *ComponentBuilder {
+	b.c.Options = append(b.c.Options, opts...)
+	return b
+}
+
+// Build returns the described component. The builder may be used further,
+// such as to build variants of the component, without affecting it.
+func (b *ComponentBuilder) Build() Component {
+	c := b.c
+	c.Tags = slices.Clone(c.Tags)
+	c.Labels = maps.Clone(c.Labels)
+	c.DependsOn = slices.Clone(c.DependsOn)
+	c.Options = slices.Clone(c.Options)
+	return c
+}
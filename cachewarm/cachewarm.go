@@ -0,0 +1,163 @@
+// Package cachewarm primes caches during the warmup phase of a run.Group:
+// loaders registered with Add run with bounded concurrency after every
+// component has started and before the group reports ready.
+package cachewarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/not-for-prod/run"
+)
+
+// DefaultConcurrency is the default number of loaders running at the same
+// time.
+const DefaultConcurrency = 4
+
+// Policy decides what a failed loader does to the group.
+type Policy int
+
+const (
+	// FailOnError fails the warmup, and with it the group's start, when a
+	// loader fails.
+	FailOnError Policy = iota
+
+	// DegradeOnError marks the group degraded when a loader fails and lets
+	// it become ready with a cold cache.
+	DegradeOnError
+)
+
+// Result is the outcome of a single loader.
+type Result struct {
+	Name     string        // loader name
+	Duration time.Duration // time the loader took
+	Err      error         // error the loader returned
+}
+
+// Option configures a Warmer.
+type Option func(*Warmer)
+
+// WithConcurrency returns an Option that sets how many loaders run at the
+// same time. Values less than one are treated as one.
+//
+// Default is DefaultConcurrency.
+func WithConcurrency(n int) Option {
+	return func(w *Warmer) {
+		w.concurrency = max(n, 1)
+	}
+}
+
+// WithPolicy returns an Option that sets what a failed loader does to the
+// group.
+//
+// Default is FailOnError.
+func WithPolicy(p Policy) Option {
+	return func(w *Warmer) {
+		w.policy = p
+	}
+}
+
+// loader is a named function registered with Add.
+type loader struct {
+	name string
+	load func(ctx context.Context) error
+}
+
+// Warmer runs cache loaders in parallel. It implements run.Warmer.
+type Warmer struct {
+	concurrency int
+	policy      Policy
+
+	mu      sync.Mutex
+	loaders []loader
+	results []Result
+}
+
+var _ run.Warmer = (*Warmer)(nil)
+
+// New returns a Warmer configured by opts.
+func New(opts ...Option) *Warmer {
+	w := &Warmer{concurrency: DefaultConcurrency}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Add registers a loader, such as one filling the cache of a single table.
+func (w *Warmer) Add(name string, load func(ctx context.Context) error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.loaders = append(w.loaders, loader{name: name, load: load})
+}
+
+// Warmup runs every loader, at most the configured number at a time, and
+// returns the errors of those that failed joined, each prefixed with the
+// loader name. With DegradeOnError the error is marked with run.Degraded.
+// Loaders not yet started when ctx is done are skipped with ctx's error.
+func (w *Warmer) Warmup(ctx context.Context) error {
+	w.mu.Lock()
+	loaders := w.loaders
+	w.mu.Unlock()
+
+	results := make([]Result, len(loaders))
+	sem := make(chan struct{}, w.concurrency)
+	var wg sync.WaitGroup
+	for i, l := range loaders {
+		results[i].Name = l.name
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			began := time.Now()
+			err := l.load(ctx)
+			results[i].Duration, results[i].Err = time.Since(began), err
+		}()
+	}
+	wg.Wait()
+
+	w.mu.Lock()
+	w.results = results
+	w.mu.Unlock()
+
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("loader %q: %w", r.Name, r.Err))
+		}
+	}
+	err := errors.Join(errs...)
+	if w.policy == DegradeOnError {
+		return run.Degraded(err)
+	}
+	return err
+}
+
+// Report returns the result of every loader of the last warmup, in
+// registration order, for example to log how long each cache took to load.
+// It returns nil before the first warmup.
+func (w *Warmer) Report() []Result {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.results
+}
+
+// Register adds a component to g that runs w in the warmup phase, and
+// returns its handle. Further component options, such as Named or DependsOn
+// for the stores the loaders read from, may be passed in opts.
+func (w *Warmer) Register(g *run.Group, opts ...run.ComponentOption) *run.Handle {
+	opts = append([]run.ComponentOption{run.Named("cachewarm"), run.Warms(w)}, opts...)
+	return g.Add(func() error { return nil }, func(context.Context) error { return nil }, opts...)
+}
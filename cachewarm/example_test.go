@@ -0,0 +1,42 @@
+package cachewarm_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/cachewarm"
+)
+
+func ExampleWarmer() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w := cachewarm.New(cachewarm.WithConcurrency(2), cachewarm.WithPolicy(cachewarm.DegradeOnError))
+	w.Add("products", func(ctx context.Context) error {
+		return nil
+	})
+	w.Add("prices", func(ctx context.Context) error {
+		return errors.New("pricing service unavailable")
+	})
+
+	g := run.NewGroup()
+	w.Register(g)
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("wait error:", err)
+	}
+	fmt.Println("degraded:", g.Degraded())
+	for _, r := range w.Report() {
+		fmt.Println(r.Name, r.Err)
+	}
+	// Output:
+	// degraded: true
+	// products <nil>
+	// prices pricing service unavailable
+}
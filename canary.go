@@ -0,0 +1,42 @@
+package run
+
+// Canary returns a ComponentOption that starts the component canary-first
+// with the other components of class, such as a large fleet of per-tenant
+// components: in each start wave, the first component of the class in
+// registration order starts and runs its check registered with Verify
+// before the rest of the class starts. A systemic misconfiguration then
+// fails a single start, and the group shuts down before booting the whole
+// fleet. Components without a class start alongside the canaries.
+func Canary(class string) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.canary = class
+	})
+}
+
+// canaries splits every wave holding more than one component of a canary
+// class in two: one wave with the first component of each class and the
+// components without a class, followed by one with the rest.
+func canaries(waves [][]*component) [][]*component {
+	var split [][]*component
+	for _, wave := range waves {
+		var first, rest []*component
+		seen := make(map[string]bool)
+		for _, c := range wave {
+			switch {
+			case c.canary == "":
+				first = append(first, c)
+			case seen[c.canary]:
+				rest = append(rest, c)
+			default:
+				seen[c.canary] = true
+				first = append(first, c)
+			}
+		}
+		if len(rest) == 0 {
+			split = append(split, wave)
+			continue
+		}
+		split = append(split, first, rest)
+	}
+	return split
+}
@@ -0,0 +1,66 @@
+// Package cloudrun adapts a run.Group to Cloud Run and similar serverless
+// container platforms, which send SIGTERM and allow only a short grace period
+// before killing the instance.
+package cloudrun
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/not-for-prod/run"
+)
+
+// GracePeriod is the time Cloud Run allows between SIGTERM and SIGKILL.
+const GracePeriod = 10 * time.Second
+
+// StopTimeout is the stop timeout used by Options. It leaves part of the
+// grace period for flushing logs and for the process to exit.
+const StopTimeout = 8 * time.Second
+
+// ForceStopTimeout is the force stop timeout used by Options. Together with
+// StopTimeout it stays within the grace period.
+const ForceStopTimeout = time.Second
+
+// DefaultPort is the port used when $PORT is not set.
+const DefaultPort = "8080"
+
+// Options returns the group options for the platform: stop and force stop
+// timeouts that fit the grace period. Readiness already flips as soon as
+// SIGTERM arrives, so no drain delay is added. Options given later to
+// run.NewGroup override these.
+func Options() []run.Option {
+	return []run.Option{
+		run.WithStopTimeout(StopTimeout),
+		run.WithForceStopTimeout(ForceStopTimeout),
+	}
+}
+
+// NewGroup returns a group configured with Options followed by opts.
+func NewGroup(opts ...run.Option) *run.Group {
+	return run.NewGroup(append(Options(), opts...)...)
+}
+
+// Addr returns the address to serve on, ":" followed by $PORT, or by
+// DefaultPort when it is not set.
+func Addr() string {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = DefaultPort
+	}
+	return ":" + port
+}
+
+// Listen listens for TCP connections on Addr.
+func Listen() (net.Listener, error) {
+	return net.Listen("tcp", Addr())
+}
+
+// Context returns a context, to be passed to Group.Wait, that is canceled on
+// SIGTERM or SIGINT, together with a function releasing the signal handlers.
+func Context(ctx context.Context) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+}
@@ -0,0 +1,35 @@
+package cloudrun_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/cloudrun"
+)
+
+func ExampleAddr() {
+	os.Setenv("PORT", "9090")
+	defer os.Unsetenv("PORT")
+
+	fmt.Println(cloudrun.Addr())
+	// Output:
+	// :9090
+}
+
+func ExampleNewGroup() {
+	srv := &http.Server{Addr: cloudrun.Addr()}
+
+	g := cloudrun.NewGroup()
+	g.Add(func() error {
+		go srv.ListenAndServe()
+		return nil
+	}, srv.Shutdown, run.Named("http"))
+
+	ctx, stop := cloudrun.Context(context.Background())
+	defer stop()
+
+	_ = g.Wait(ctx)
+}
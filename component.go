@@ -0,0 +1,222 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// component is a single registered start/stop pair together with its
+// per-component settings.
+type component struct {
+	name  string                          // unique name used in errors and dependency declarations
+	seq   uint64                          // registration number, starting at one
+	start func(ctx context.Context) error // called with the start phase context
+	stop  Stop
+
+	forceStop  Stop                            // called when stop did not return nil in time, nil for none
+	forceAfter time.Duration                   // calls forceStop while stop is still running after this long, zero for never
+	verify     func(ctx context.Context) error // checks the component after start returned nil, nil for none
+	probe      func(ctx context.Context) error // checks the component periodically while it runs, nil for none
+
+	provides  reflect.Type       // type published by Provide, nil for plain components
+	value     func() (any, bool) // returns the published value once the provider succeeded
+	requires  []dependency       // values that must be published before start
+	dependsOn []string           // names of components that must start before this one
+	disabled  bool               // skipped together with everything depending on it
+	optional  bool               // failures degrade the group instead of aborting it
+	restart   *RestartPolicy     // restarts a run function that returned, nil for none
+	priority  int                // higher priorities start earlier and stop later
+	stopClass string             // stop class declared with WithStopClasses, empty for the default class
+	canary    string             // canary class, empty for none
+	labels    map[string]string  // arbitrary metadata such as owning team or tier
+	tags      []string           // roles the component belongs to, matched by selectors
+	rotator   Rotator            // switches to rotated secrets, nil for none
+	reloader  Reloader           // applies new configuration, nil for none
+	pauser    Pauser             // pauses and resumes the component, nil to stop and start it
+	drainer   Drainer            // stops accepting work before any component stops, nil for none
+	warmer    Warmer             // prepares the component before the group reports ready, nil for none
+
+	startTimeout time.Duration // bounds each start call, zero for the TimeoutPolicy
+	stopTimeout  time.Duration // bounds each stop call, zero for the TimeoutPolicy
+	retries      int           // repeats a failing start call this often before the component fails
+
+	attempted bool         // set once start has been invoked, guarded by Group.mu
+	forced    bool         // set once forceStop has been called, guarded by Group.mu
+	stopping  bool         // set once stop has been claimed, guarded by Group.mu
+	err       error        // first start or stop error, guarded by Group.mu
+	stopErr   error        // result of the stop call, guarded by Group.mu
+	spans     []span       // completed calls for WriteTrace, guarded by Group.mu
+	state     atomic.Int32 // current State
+	since     atomic.Int64 // time the current State was entered, in Unix nanoseconds
+	restarts  atomic.Int32 // restarts by RestartPolicy or rolling restarts
+	recorder  *Recorder    // receives state changes, nil when disabled
+	stateLog  *stateLog    // logs state changes, nil when disabled
+	pauseMu   sync.Mutex   // serializes Group.Pause and Group.Resume
+	life      lifetime     // context returned by ComponentContext
+
+	started  chan struct{} // closed once start returned nil
+	done     chan struct{} // closed once the component will not run anymore
+	doneOnce sync.Once
+
+	startDone chan struct{} // closed once the start call returned or timed out, nil before it, guarded by Group.mu
+	endStart  func()        // closes startDone once
+}
+
+// closeDone closes the component's done channel if it is still open.
+func (c *component) closeDone() {
+	c.doneOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// dependency identifies a value a component requires from another component.
+type dependency struct {
+	typ  reflect.Type // type of the required value
+	name string       // name of the providing component, empty for any provider of typ
+}
+
+// String returns the dependency in the form used by error messages.
+func (d dependency) String() string {
+	if d.name == "" {
+		return d.typ.String()
+	}
+	return fmt.Sprintf("%s %q", d.typ, d.name)
+}
+
+// ComponentOption is a functional option that configures a single component
+// registered with Add or Provide.
+type ComponentOption interface {
+	apply(*component)
+}
+
+// componentOptionFunc is a helper type to implement the ComponentOption
+// interface with functions.
+type componentOptionFunc func(*component)
+
+// apply executes the function to modify the component.
+func (f componentOptionFunc) apply(c *component) {
+	f(c)
+}
+
+// Named returns a ComponentOption that sets the component's name. Names
+// appear in errors and identify providers for RequiresNamed. They must be
+// unique within a group.
+//
+// Unnamed components are called "component-<n>", where n is the registration
+// index; components registered with Provide default to the provided type.
+func Named(name string) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.name = name
+	})
+}
+
+// Requires returns a ComponentOption declaring that the component needs the
+// value of type T published by another component through Provide. The group
+// starts the provider before the component and stops it after. Exactly one
+// component must provide T.
+func Requires[T any]() ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.requires = append(c.requires, dependency{typ: reflect.TypeFor[T]()})
+	})
+}
+
+// RequiresNamed is like Requires but selects the provider of T by its
+// component name, for groups that provide several values of the same type.
+func RequiresNamed[T any](name string) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.requires = append(c.requires, dependency{typ: reflect.TypeFor[T](), name: name})
+	})
+}
+
+// DependsOn returns a ComponentOption declaring that the component must start
+// after, and stop before, the named components.
+func DependsOn(names ...string) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.dependsOn = append(c.dependsOn, names...)
+	})
+}
+
+// Enabled returns a ComponentOption that enables or disables the component.
+// A disabled component is neither started nor stopped, and neither are the
+// components that depend on it. Components are enabled by default.
+func Enabled(enabled bool) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.disabled = !enabled
+	})
+}
+
+// Critical returns a ComponentOption that sets whether the group depends on
+// the component. Failures of a non-critical component, such as a metrics
+// exporter, are classified SeverityDegraded: the component fails and the
+// group is marked degraded while critical components keep serving.
+// Components are critical by default.
+func Critical(critical bool) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.optional = !critical
+	})
+}
+
+// Priority returns a ComponentOption that sets the component's start
+// priority. Components with a higher priority start before, and stop after,
+// all components with a lower priority; components of equal priority start
+// concurrently, subject to their dependencies. This is a lightweight way to
+// express "databases before servers" without declaring dependencies.
+//
+// A component may only depend on components of equal or higher priority;
+// otherwise Wait fails with ErrPriorityInversion. The default priority is 0.
+func Priority(p int) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.priority = p
+	})
+}
+
+// StopClass returns a ComponentOption that assigns the component to one of
+// the stop classes declared with WithStopClasses. Classes are stopped one
+// after another, independently of the start order.
+func StopClass(class string) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.stopClass = class
+	})
+}
+
+// Label returns a ComponentOption that attaches a key/value label to the
+// component, such as team, tier or criticality. Labels are carried in
+// ComponentInfo, ComponentError and Status, and added to logs and metrics, so
+// tooling can route alerts and dashboards by them.
+func Label(key, value string) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		if c.labels == nil {
+			c.labels = make(map[string]string)
+		}
+		c.labels[key] = value
+	})
+}
+
+// Tags returns a ComponentOption that tags the component with roles such as
+// "api" or "worker", so that one wiring function can serve several roles
+// selected with WithSelector and Tagged.
+func Tags(tags ...string) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.tags = append(c.tags, tags...)
+	})
+}
+
+// Reloads returns a ComponentOption that registers r to be called by
+// Group.Reload while the component is running.
+func Reloads(r Reloader) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.reloader = r
+	})
+}
+
+// Rotates returns a ComponentOption that registers r to be called by
+// Group.Rotate while the component is running.
+func Rotates(r Rotator) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.rotator = r
+	})
+}
@@ -0,0 +1,190 @@
+// Package configwatch reloads configuration when files change on disk,
+// including ConfigMaps and Secrets mounted into containers, which Kubernetes
+// updates by swapping a symlink rather than writing the files in place.
+package configwatch
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/not-for-prod/run"
+)
+
+// DefaultDebounce is the default quiet period after the last change before
+// the reload callback runs.
+const DefaultDebounce = 500 * time.Millisecond
+
+// Option configures a watcher added with Watch.
+type Option func(*watcher)
+
+// WithName returns an Option that names the watcher component.
+//
+// Default is "configwatch".
+func WithName(name string) Option {
+	return func(w *watcher) {
+		w.name = name
+	}
+}
+
+// WithDebounce returns an Option that sets how long the watched files must
+// stay unchanged before the reload callback runs, so that a burst of writes
+// results in a single reload.
+//
+// Default is DefaultDebounce.
+func WithDebounce(d time.Duration) Option {
+	return func(w *watcher) {
+		w.debounce = d
+	}
+}
+
+// WithErrorHandler returns an Option that passes errors returned by the
+// reload callback and by the underlying file watcher to fn.
+//
+// Default is to discard them.
+func WithErrorHandler(fn func(error)) Option {
+	return func(w *watcher) {
+		w.onError = fn
+	}
+}
+
+// watcher calls reload when one of its paths changes.
+type watcher struct {
+	paths    []string
+	reload   func(ctx context.Context) error
+	name     string
+	debounce time.Duration
+	onError  func(error)
+
+	fsw    *fsnotify.Watcher
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Watch adds a component to g that calls reload whenever one of paths is
+// written, created, replaced or removed, and returns its handle. Changes are
+// debounced, and reload never runs concurrently with itself. The context
+// passed to reload is canceled when the component stops. Pass g.Reload to
+// fan the change out to every component's run.Reloader.
+//
+// The parent directories of paths are watched rather than the files, so
+// changes survive editors that replace files and the atomic symlink swaps
+// used for mounted ConfigMaps.
+func Watch(g *run.Group, reload func(ctx context.Context) error, paths []string, opts ...Option) *run.Handle {
+	w := &watcher{
+		paths:    paths,
+		reload:   reload,
+		name:     "configwatch",
+		debounce: DefaultDebounce,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return g.Add(w.start, w.stop, run.Named(w.name))
+}
+
+// start watches the parent directories of the paths and launches the event
+// loop.
+func (w *watcher) start() error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	watched := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, path := range w.paths {
+		path = filepath.Clean(path)
+		watched[path] = true
+		dirs[filepath.Dir(path)] = true
+	}
+	for dir := range dirs {
+		if err := fsw.Add(dir); err != nil {
+			fsw.Close()
+			return err
+		}
+	}
+
+	var ctx context.Context
+	ctx, w.cancel = context.WithCancel(context.Background())
+	w.fsw = fsw
+	w.wg.Add(1)
+	go w.run(ctx, watched)
+	return nil
+}
+
+// stop ends the event loop, waiting for a running reload to return.
+func (w *watcher) stop(ctx context.Context) error {
+	w.cancel()
+	err := w.fsw.Close()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run debounces relevant events and calls reload.
+func (w *watcher) run(ctx context.Context, watched map[string]bool) {
+	defer w.wg.Done()
+
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if w.relevant(event, watched) {
+				timer.Reset(w.debounce)
+			}
+
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			w.report(err)
+
+		case <-timer.C:
+			if err := w.reload(ctx); err != nil && ctx.Err() == nil {
+				w.report(err)
+			}
+		}
+	}
+}
+
+// relevant reports whether event may have changed one of the watched files:
+// an event on the file itself, or on the "..data" symlink through which
+// Kubernetes swaps the contents of a mounted volume.
+func (w *watcher) relevant(event fsnotify.Event, watched map[string]bool) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	name := filepath.Clean(event.Name)
+	return watched[name] || filepath.Base(name) == "..data"
+}
+
+// report passes err to the error handler, if any.
+func (w *watcher) report(err error) {
+	if w.onError != nil {
+		w.onError(err)
+	}
+}
@@ -0,0 +1,54 @@
+package configwatch_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/configwatch"
+)
+
+func ExampleWatch() {
+	dir, err := os.MkdirTemp("", "configwatch")
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("level: info\n"), 0o600); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g := run.NewGroup()
+	configwatch.Watch(g, func(ctx context.Context) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("reloaded: %s", data)
+		cancel()
+		return nil
+	}, []string{path}, configwatch.WithDebounce(10*time.Millisecond))
+
+	// Change the file once the watcher is running.
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			_ = os.WriteFile(path, []byte("level: debug\n"), 0o600)
+		}
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// reloaded: level: debug
+}
@@ -0,0 +1,12 @@
+module github.com/not-for-prod/run/configwatch
+
+go 1.24.3
+
+require (
+	github.com/fsnotify/fsnotify v1.10.1
+	github.com/not-for-prod/run v0.0.0-00010101000000-000000000000
+)
+
+require golang.org/x/sys v0.33.0 // indirect
+
+replace github.com/not-for-prod/run => ../
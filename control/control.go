@@ -0,0 +1,382 @@
+// Package control lets operators drive a running run.Group from the host
+// through a unix domain socket, without exposing an HTTP port: a control
+// component serves a line-based command protocol, and Send is a tiny client
+// for it.
+//
+// A client writes one command per connection, such as
+//
+//	restart worker-1 worker-2
+//
+// and the server answers with "ok" or "error: <message>" on the first line,
+// followed by the command's output, and closes the connection. The commands
+// are status, stop [component]..., restart <component>... and reload.
+package control
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/not-for-prod/run"
+)
+
+// DefaultTimeout is the default duration a single command may take.
+const DefaultTimeout = time.Minute
+
+var (
+	// ErrCommand is returned by Send, wrapping the server's message, when a
+	// command failed.
+	ErrCommand = errors.New("command failed")
+
+	// ErrInUse is returned by Server.Start when the socket path is taken by
+	// something other than a stale socket, such as a regular file or the
+	// socket of a server that is still listening.
+	ErrInUse = errors.New("socket path in use")
+)
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithTimeout returns an Option that bounds every command.
+//
+// Default is DefaultTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.timeout = d
+	}
+}
+
+// WithPermissions returns an Option that sets the file mode of the socket,
+// which decides who on the host may connect.
+//
+// Default is 0600, the owner of the process only.
+func WithPermissions(perm os.FileMode) Option {
+	return func(s *Server) {
+		s.perm = perm
+	}
+}
+
+// WithAuthorizer returns an Option that lets a decide whether each command
+// may run. Its context carries the credentials of the sending process, see
+// PeerFromContext; denied commands fail with a message naming
+// run.ErrUnauthorized.
+//
+// Default is to allow every command from anyone the socket's permissions
+// let connect.
+func WithAuthorizer(a run.Authorizer) Option {
+	return func(s *Server) {
+		s.auth = a
+	}
+}
+
+// Server serves control commands for a group on a unix domain socket.
+type Server struct {
+	path    string
+	timeout time.Duration
+	perm    os.FileMode
+	auth    run.Authorizer
+
+	g        *run.Group
+	ln       net.Listener
+	sock     os.FileInfo    // the socket file created by Start, removed by Stop
+	serving  sync.WaitGroup // the accept loop and commands in flight
+	mu       sync.Mutex
+	commands map[string]func(ctx context.Context, args []string) (string, error)
+}
+
+// New returns a Server listening on the unix socket at path once it is
+// registered and started.
+func New(path string, opts ...Option) *Server {
+	s := &Server{path: path, timeout: DefaultTimeout, perm: 0o600}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Register adds the server to g as a component named "control" that
+// controls g, and returns its handle. opts can override the name and add
+// further component options.
+func (s *Server) Register(g *run.Group, opts ...run.ComponentOption) *run.Handle {
+	s.g = g
+	s.commands = map[string]func(ctx context.Context, args []string) (string, error){
+		run.CommandStatus:  s.status,
+		run.CommandStop:    s.stop,
+		run.CommandRestart: s.restart,
+		run.CommandReload:  s.reload,
+	}
+	opts = append([]run.ComponentOption{run.Named("control")}, opts...)
+	return g.Add(s.Start, s.Stop, opts...)
+}
+
+// Start listens on the socket, replacing a stale socket file left behind by
+// an earlier process, and serves commands in the background. Anything else
+// at the path, such as a regular file or the socket of a server that still
+// listens, is left alone and Start returns an error wrapping ErrInUse. The
+// socket only appears at the path once its permissions are set, so nobody
+// else can connect in between.
+func (s *Server) Start() error {
+	if err := removeStale(s.path); err != nil {
+		return err
+	}
+	ln, sock, err := listen(s.path, s.perm)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.ln, s.sock = ln, sock
+	s.mu.Unlock()
+
+	s.serving.Add(1)
+	go s.serve(ln)
+	return nil
+}
+
+// Stop closes the socket and waits for commands in flight, or for ctx.
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	ln, sock := s.ln, s.sock
+	s.mu.Unlock()
+	if ln == nil {
+		return nil
+	}
+	err := ln.Close()
+	// Leave a socket another server created at the path in the meantime.
+	if fi, statErr := os.Lstat(s.path); statErr == nil && os.SameFile(fi, sock) {
+		err = errors.Join(err, os.Remove(s.path))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.serving.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// removeStale removes the socket at path if no server listens on it. It
+// returns an error wrapping ErrInUse for anything else at path.
+func removeStale(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode().Type() != os.ModeSocket {
+		return fmt.Errorf("%s: %w: not a socket", path, ErrInUse)
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("%s: %w: a server is listening", path, ErrInUse)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return os.Remove(path)
+}
+
+// listen binds a unix socket with mode perm at path, which must not exist.
+// The socket is bound in a private directory next to path and linked into
+// place once its mode is set, so it is never reachable with the looser mode
+// the umask would give it.
+func listen(path string, perm os.FileMode) (net.Listener, os.FileInfo, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".control-")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "sock")
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmp, Net: "unix"})
+	if err != nil {
+		return nil, nil, err
+	}
+	// Stop removes the socket under its final name.
+	ln.SetUnlinkOnClose(false)
+
+	err = os.Chmod(tmp, perm)
+	if err == nil {
+		err = os.Link(tmp, path)
+	}
+	if err != nil {
+		ln.Close()
+		if errors.Is(err, os.ErrExist) {
+			err = fmt.Errorf("%s: %w", path, ErrInUse)
+		}
+		return nil, nil, err
+	}
+	fi, err := os.Lstat(path)
+	if err != nil {
+		ln.Close()
+		os.Remove(path)
+		return nil, nil, err
+	}
+	return ln, fi, nil
+}
+
+// serve accepts connections until ln is closed.
+func (s *Server) serve(ln net.Listener) {
+	defer s.serving.Done()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		s.serving.Add(1)
+		go func() {
+			defer s.serving.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+// handle runs the command sent on conn and writes the reply.
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(s.timeout))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil && line == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	if peer, ok := peerOf(conn); ok {
+		ctx = context.WithValue(ctx, peerKey{}, peer)
+	}
+	out, err := s.run(ctx, strings.Fields(line))
+
+	_ = conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	if err != nil {
+		fmt.Fprintf(conn, "error: %s\n", strings.ReplaceAll(err.Error(), "\n", "; "))
+		return
+	}
+	fmt.Fprintf(conn, "ok\n%s", out)
+}
+
+// run runs a command given as its fields.
+func (s *Server) run(ctx context.Context, fields []string) (string, error) {
+	if len(fields) == 0 {
+		return "", errors.New("empty command")
+	}
+	cmd, ok := s.commands[fields[0]]
+	if !ok {
+		return "", fmt.Errorf("unknown command %q", fields[0])
+	}
+	if err := run.Authorize(ctx, s.auth, run.Command{Name: fields[0], Components: fields[1:]}); err != nil {
+		return "", err
+	}
+	return cmd(ctx, fields[1:])
+}
+
+// status reports the group's health and the state of every component.
+func (s *Server) status(context.Context, []string) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "health\t%s\n", s.g.Health())
+	for _, c := range s.g.Status() {
+		fmt.Fprintf(&b, "%s\t%s", c.Name, c.State)
+		if c.Err != nil {
+			fmt.Fprintf(&b, "\t%s", strings.ReplaceAll(c.Err.Error(), "\n", "; "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// stop stops the named components, leaving the rest of the group running,
+// or without names shuts the whole group down after replying, passing the
+// values of ctx, such as the peer, on to the stop calls.
+func (s *Server) stop(ctx context.Context, names []string) (string, error) {
+	if len(names) > 0 {
+		if err := s.known("stop", names); err != nil {
+			return "", err
+		}
+		return "", s.g.StopSubset(ctx, run.Names(names...))
+	}
+	go func() {
+		_ = s.g.Stop(context.WithoutCancel(ctx))
+	}()
+	return "", nil
+}
+
+// restart restarts the named components one at a time.
+func (s *Server) restart(ctx context.Context, names []string) (string, error) {
+	if len(names) == 0 {
+		return "", errors.New("restart: no components given")
+	}
+	if err := s.known("restart", names); err != nil {
+		return "", err
+	}
+	return "", s.g.RollingRestart(ctx, run.Names(names...), run.RollingPolicy{})
+}
+
+// known returns an error wrapping run.ErrUnknownComponent for the first of
+// names that is not a component of the group.
+func (s *Server) known(command string, names []string) error {
+	known := make(map[string]bool)
+	for _, c := range s.g.Status() {
+		known[c.Name] = true
+	}
+	for _, name := range names {
+		if !known[name] {
+			return fmt.Errorf("%s %q: %w", command, name, run.ErrUnknownComponent)
+		}
+	}
+	return nil
+}
+
+// reload reloads the group.
+func (s *Server) reload(ctx context.Context, _ []string) (string, error) {
+	return "", s.g.Reload(ctx)
+}
+
+// Send sends command to the control server listening on the unix socket at
+// path and returns its output. A failed command returns an error wrapping
+// ErrCommand.
+func Send(ctx context.Context, path, command string) (string, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := io.WriteString(conn, strings.TrimSpace(command)+"\n"); err != nil {
+		return "", err
+	}
+	reply, err := io.ReadAll(conn)
+	if err != nil {
+		return "", err
+	}
+	status, out, _ := strings.Cut(string(reply), "\n")
+	if msg, ok := strings.CutPrefix(status, "error: "); ok {
+		return "", fmt.Errorf("%w: %s", ErrCommand, msg)
+	}
+	if status != "ok" {
+		return "", fmt.Errorf("%w: unexpected reply %q", ErrCommand, status)
+	}
+	return out, nil
+}
@@ -0,0 +1,128 @@
+package control_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/control"
+)
+
+func ExampleServer_Register() {
+	dir, _ := os.MkdirTemp("", "control")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "control.sock")
+
+	g := run.NewGroup()
+	g.Add(func() error { return nil }, func(context.Context) error { return nil }, run.Named("worker"))
+	control.New(path).Register(g)
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		go func() {
+			ctx := context.Background()
+			out, _ := control.Send(ctx, path, "status")
+			fmt.Print(out)
+			_, err := control.Send(ctx, path, "restart ghost")
+			fmt.Println(err)
+			_, _ = control.Send(ctx, path, "stop")
+		}()
+	})
+
+	if err := g.Wait(context.Background()); err != nil {
+		fmt.Println("wait error:", err)
+	}
+	// Output:
+	// health	ok
+	// worker	running
+	// control	running
+	// command failed: restart "ghost": unknown component
+}
+
+func ExampleWithAuthorizer() {
+	dir, _ := os.MkdirTemp("", "control")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "control.sock")
+
+	// Operators may stop the web component, and nothing else.
+	onlyWeb := run.AuthorizerFunc(func(ctx context.Context, cmd run.Command) error {
+		if cmd.Name == run.CommandStatus || cmd.Name == run.CommandStop && slices.Equal(cmd.Components, []string{"web"}) {
+			return nil
+		}
+		return errors.New("not allowed")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g := run.NewGroup()
+	noop := func(context.Context) error { return nil }
+	g.Add(func() error { return nil }, noop, run.Named("web"))
+	g.Add(func() error { return nil }, noop, run.Named("worker"))
+	control.New(path, control.WithAuthorizer(onlyWeb)).Register(g)
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		go func() {
+			defer cancel()
+			_, err := control.Send(ctx, path, "stop web")
+			fmt.Println("stop web:", err)
+			_, err = control.Send(ctx, path, "stop")
+			fmt.Println("stop:", err)
+			out, _ := control.Send(ctx, path, "status")
+			fmt.Print(out)
+		}()
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("wait error:", err)
+	}
+	// Output:
+	// stop web: <nil>
+	// stop: command failed: unauthorized: not allowed
+	// health	ok
+	// web	stopped
+	// worker	running
+	// control	running
+}
+
+func ExampleServer_Start() {
+	dir, _ := os.MkdirTemp("", "control")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "control.sock")
+	ctx := context.Background()
+
+	// A file that is not a socket is never replaced.
+	_ = os.WriteFile(path, []byte("data"), 0o644)
+	fmt.Println(errors.Is(control.New(path).Start(), control.ErrInUse))
+	_ = os.Remove(path)
+
+	// A stale socket left behind by a crashed process is.
+	stale, _ := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+
+	s := control.New(path)
+	fmt.Println(s.Start())
+	fi, _ := os.Stat(path)
+	fmt.Println(fi.Mode().Perm())
+
+	// The socket of a server that still listens is not.
+	fmt.Println(errors.Is(control.New(path).Start(), control.ErrInUse))
+
+	fmt.Println(s.Stop(ctx))
+	_, err := os.Stat(path)
+	fmt.Println(errors.Is(err, os.ErrNotExist))
+	// Output:
+	// true
+	// <nil>
+	// -rw-------
+	// true
+	// <nil>
+	// true
+}
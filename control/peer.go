@@ -0,0 +1,21 @@
+package control
+
+import "context"
+
+// Peer identifies the process on the other end of a control connection.
+type Peer struct {
+	PID int // process ID
+	UID int // user ID
+	GID int // group ID
+}
+
+// peerKey is the context key of the connection's Peer.
+type peerKey struct{}
+
+// PeerFromContext returns the credentials of the process that sent the
+// command, from the context an Authorizer receives. It reports false where
+// the platform does not provide peer credentials.
+func PeerFromContext(ctx context.Context) (Peer, bool) {
+	p, ok := ctx.Value(peerKey{}).(Peer)
+	return p, ok
+}
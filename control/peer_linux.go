@@ -0,0 +1,28 @@
+//go:build linux
+
+package control
+
+import (
+	"net"
+	"syscall"
+)
+
+// peerOf returns the credentials of the process connected on conn.
+func peerOf(conn net.Conn) (Peer, bool) {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return Peer{}, false
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return Peer{}, false
+	}
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil || credErr != nil {
+		return Peer{}, false
+	}
+	return Peer{PID: int(cred.Pid), UID: int(cred.Uid), GID: int(cred.Gid)}, true
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package control
+
+import "net"
+
+// peerOf reports that peer credentials are not supported on this platform.
+func peerOf(net.Conn) (Peer, bool) {
+	return Peer{}, false
+}
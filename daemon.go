@@ -0,0 +1,135 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrExited is returned when the run function of a component added with Go
+// returns nil while the group is still running.
+var ErrExited = errors.New("run function exited")
+
+// daemon runs a long-running function for the lifetime of a component.
+type daemon struct {
+	g   *Group
+	c   *component
+	run func(ctx context.Context) error
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{} // closed when run returned
+	err    error         // error run returned after being canceled
+}
+
+// Go adds a run-style component whose fn blocks for as long as the component
+// runs, such as a queue consumer or an HTTP server's Serve loop, and returns
+// its handle. fn is called in its own goroutine when the component starts,
+// which succeeds immediately; stopping the component cancels fn's context and
+// waits for it to return. An error fn returns after being canceled is the
+// component's stop error, except context.Canceled.
+//
+// If fn returns while the group is running, the component fails with its
+// error, or with ErrExited if it returned nil. The failure is classified
+// like a start error: a critical component shuts the whole group down and
+// Wait returns the error, while a component registered with Critical(false)
+// only marks the group degraded and the rest keeps serving. Use the Restart
+// component option to restart fn instead.
+func (g *Group) Go(fn func(ctx context.Context) error, opts ...ComponentOption) *Handle {
+	c := &component{}
+	d := &daemon{g: g, c: c, run: fn}
+	c.start = d.start
+	c.stop = d.stop
+	return g.add(c, opts)
+}
+
+// start launches the run function.
+func (d *daemon) start(ctx context.Context) error {
+	// The run context outlives the start call but keeps its values, such as
+	// the component identity.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+
+	d.mu.Lock()
+	d.cancel, d.done, d.err = cancel, done, nil
+	d.mu.Unlock()
+
+	go d.loop(ctx, done)
+	return nil
+}
+
+// loop calls the run function, restarting it according to the component's
+// RestartPolicy, and reports an unexpected return.
+func (d *daemon) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	var r restarts
+	for {
+		began := time.Now()
+		err := d.run(ctx)
+		if ctx.Err() != nil {
+			if errors.Is(err, context.Canceled) {
+				err = nil
+			}
+			d.mu.Lock()
+			d.err = err
+			d.mu.Unlock()
+			return
+		}
+
+		restarted, err := d.g.restart(ctx, d.c, &r, began, err)
+		if restarted {
+			continue
+		}
+		if ctx.Err() != nil {
+			// Stopped while waiting to restart.
+			return
+		}
+		if err == nil {
+			err = ErrExited
+		}
+		d.g.runExited(d.c, began, err)
+		return
+	}
+}
+
+// stop cancels the run function and waits for it to return.
+func (d *daemon) stop(ctx context.Context) error {
+	d.mu.Lock()
+	cancel, done := d.cancel, d.done
+	d.mu.Unlock()
+
+	cancel()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
+
+// runExited handles the unexpected return of c's run function, which began at
+// began, according to the severity of err.
+func (g *Group) runExited(c *component, began time.Time, err error) {
+	err = g.classify(c, PhaseRun, g.transform(c, err))
+	if !c.transition(StateRunning, StateFailed) {
+		// The component is already being stopped.
+		return
+	}
+	g.mu.Lock()
+	c.err = c.wrap(PhaseRun, err)
+	g.mu.Unlock()
+
+	severity := SeverityOf(err)
+	if severity == SeverityDegraded {
+		g.degrade()
+	}
+	g.record(c, PhaseRun, began, err)
+	if severity == SeverityFatal {
+		g.exitGroup(c.wrap(PhaseRun, err))
+	}
+}
@@ -0,0 +1,185 @@
+package run
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DashboardOptions configures the handler returned by
+// Group.DashboardHandler.
+type DashboardOptions struct {
+	// Title is the page title. Empty means "Components".
+	Title string
+
+	// Actions enables the restart and stop buttons, which post to the
+	// handler itself. Only enable them on an admin listener that
+	// authenticates its users, or with an Authorizer. Posts must carry the
+	// token embedded in the page and must not come from another origin, so
+	// other sites cannot forge them through a user's browser.
+	Actions bool
+
+	// Authorizer, if set, decides whether a request may view the page, as
+	// CommandStatus, and use its buttons, as CommandRestart or CommandStop
+	// of the component. Denied requests get 403 Forbidden.
+	Authorizer Authorizer
+}
+
+// dashboardRow is a component as shown on the dashboard.
+type dashboardRow struct {
+	ComponentStatus
+	Uptime string
+	Error  string
+}
+
+// dashboardPage is the data the dashboard template renders.
+type dashboardPage struct {
+	Title   string
+	Health  Health
+	Rows    []dashboardRow
+	Actions bool
+	Token   string // token the buttons post back
+}
+
+// dashboardTemplate renders the dashboard.
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border-bottom: 1px solid #ddd; padding: 0.4em 0.8em; text-align: left; }
+.running { color: #080; }
+.failed, .timed { color: #c00; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Health: <strong>{{.Health}}</strong></p>
+<table>
+<tr><th>Component</th><th>State</th><th>Uptime</th><th>Restarts</th><th>Last error</th>{{if .Actions}}<th></th>{{end}}</tr>
+{{- range .Rows}}
+<tr>
+<td>{{.Name}}</td>
+<td class="{{.State}}">{{.State}}</td>
+<td>{{.Uptime}}</td>
+<td>{{.Restarts}}</td>
+<td>{{.Error}}</td>
+{{- if $.Actions}}
+<td>
+<form method="post"><input type="hidden" name="token" value="{{$.Token}}"><input type="hidden" name="component" value="{{.Name}}">
+<button name="action" value="restart">Restart</button>
+<button name="action" value="stop">Stop</button>
+</form>
+</td>
+{{- end}}
+</tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// DashboardHandler returns an http.Handler serving a human-readable HTML
+// page for incidents: the group's health and a table of its components with
+// their states, uptimes, restart counts and last errors. With o.Actions, a
+// POST with the form values component and action, "restart" or "stop",
+// restarts the component like Group.RollingRestart or stops it like
+// Group.StopSubset, and redirects back to the page. The POST must also carry
+// the form value token from the page; the token is random per handler.
+func (g *Group) DashboardHandler(o DashboardOptions) http.Handler {
+	if o.Title == "" {
+		o.Title = "Components"
+	}
+	token := rand.Text()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			g.dashboardAction(w, r, o, token)
+			return
+		}
+		if err := Authorize(r.Context(), o.Authorizer, Command{Name: CommandStatus, Request: r}); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		page := dashboardPage{Title: o.Title, Health: g.Health(), Actions: o.Actions}
+		if o.Actions {
+			page.Token = token
+		}
+		now := time.Now()
+		for _, s := range g.Status() {
+			row := dashboardRow{ComponentStatus: s}
+			if s.State == StateRunning && !s.Since.IsZero() {
+				row.Uptime = now.Sub(s.Since).Round(time.Second).String()
+			}
+			if s.Err != nil {
+				row.Error = s.Err.Error()
+			}
+			page.Rows = append(page.Rows, row)
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = dashboardTemplate.Execute(w, page)
+	})
+}
+
+// dashboardAction handles a restart or stop button.
+func (g *Group) dashboardAction(w http.ResponseWriter, r *http.Request, o DashboardOptions, token string) {
+	if !o.Actions {
+		http.Error(w, "actions disabled", http.StatusForbidden)
+		return
+	}
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request", http.StatusForbidden)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.PostFormValue("token")), []byte(token)) != 1 {
+		http.Error(w, "invalid token", http.StatusForbidden)
+		return
+	}
+
+	name, action := r.FormValue("component"), r.FormValue("action")
+	if action != CommandRestart && action != CommandStop {
+		http.Error(w, "unknown action", http.StatusBadRequest)
+		return
+	}
+	cmd := Command{Name: action, Components: []string{name}, Request: r}
+	if err := Authorize(r.Context(), o.Authorizer, cmd); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	var err error
+	if action == CommandRestart {
+		err = g.RollingRestart(r.Context(), Names(name), RollingPolicy{})
+	} else {
+		err = g.StopSubset(r.Context(), Names(name))
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, r.URL.String(), http.StatusSeeOther)
+}
+
+// sameOrigin reports whether r was not sent by a browser on behalf of
+// another site, judged by the Sec-Fetch-Site header of modern browsers or
+// else by the Origin header. Requests with neither, such as from scripts,
+// pass.
+func sameOrigin(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site == "same-origin" || site == "none"
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
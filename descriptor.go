@@ -0,0 +1,149 @@
+package run
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// startRetry is the backoff between the start attempts of a component
+// registered with RetryStart.
+var startRetry = RestartPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: 5 * time.Second}
+
+// Component describes a component with its most common settings in one
+// place, as an alternative to Add with a long list of component options.
+// The zero values of its fields keep the defaults of Add.
+type Component struct {
+	// Name is the component's name, as set by Named.
+	Name string
+
+	// Start initializes the component. Nil starts it without doing anything.
+	Start Start
+
+	// Stop shuts the component down. Nil stops it without doing anything.
+	Stop Stop
+
+	// Timeout bounds each start call, including its retries, and each stop
+	// call, within the group's start and stop timeouts. Zero leaves the
+	// component to the TimeoutPolicy set with WithTimeoutPolicy.
+	Timeout time.Duration
+
+	// Tags are the roles the component belongs to, as set by Tags.
+	Tags []string
+
+	// Labels are attached to the component as if set by Label.
+	Labels map[string]string
+
+	// DependsOn names the components that must start before, and stop
+	// after, this one.
+	DependsOn []string
+
+	// NonCritical degrades the group instead of shutting it down when the
+	// component fails, like Critical(false).
+	NonCritical bool
+
+	// Retry is how many times a start call that returned an error is
+	// repeated before the component fails, as set by RetryStart. Zero fails
+	// on the first error.
+	Retry int
+
+	// Options configure anything else, and are applied after the fields.
+	Options []ComponentOption
+}
+
+// AddComponent registers the component described by c and returns its
+// handle, like Add with the equivalent component options.
+func (g *Group) AddComponent(c Component) *Handle {
+	return g.add(c.component(), c.options())
+}
+
+// AddAll registers the described components in order, as one registration,
+// and returns their handles in the same order. It suits wiring code that
+// builds its components from configuration, such as one per tenant, and
+// accepts a slice with AddAll(components...).
+func (g *Group) AddAll(components ...Component) []*Handle {
+	cs := make([]*component, len(components))
+	for i, c := range components {
+		cs[i] = c.component()
+		g.prepare(cs[i], c.options())
+	}
+
+	seq := g.registry.reserve(len(cs))
+	handles := make([]*Handle, len(cs))
+	for i, c := range cs {
+		handles[i] = g.insert(c, seq+uint64(i))
+	}
+	return handles
+}
+
+// component returns the unconfigured component with c's start and stop
+// functions.
+func (c Component) component() *component {
+	start, stop := c.Start, c.Stop
+	if start == nil {
+		start = func() error { return nil }
+	}
+	if stop == nil {
+		stop = func(context.Context) error { return nil }
+	}
+	return &component{
+		start: func(context.Context) error { return start() },
+		stop:  stop,
+	}
+}
+
+// options returns the component options equivalent to c.
+func (c Component) options() []ComponentOption {
+	opts := make([]ComponentOption, 0, len(c.Labels)+len(c.Options)+7)
+	if c.Name != "" {
+		opts = append(opts, Named(c.Name))
+	}
+	opts = append(opts,
+		StartTimeout(c.Timeout),
+		StopTimeout(c.Timeout),
+		Tags(c.Tags...),
+		DependsOn(c.DependsOn...),
+		Critical(!c.NonCritical),
+		RetryStart(c.Retry),
+	)
+	for _, k := range slices.Sorted(maps.Keys(c.Labels)) {
+		opts = append(opts, Label(k, c.Labels[k]))
+	}
+	return append(opts, c.Options...)
+}
+
+// RetryStart returns a ComponentOption that repeats a start call of the
+// component that returned an error up to n times before the component
+// fails, waiting from 100ms up to 5s in between, all within its start
+// timeout. This suits dependencies that become reachable shortly after the
+// process starts, such as a sidecar proxy.
+func RetryStart(n int) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.retries = n
+	})
+}
+
+// startRetrying calls c's start function, repeating it after errors as
+// often as RetryStart allows.
+func (g *Group) startRetrying(ctx context.Context, c *component) error {
+	for n := 0; ; n++ {
+		err := g.callStart(ctx, c, c.start)
+		if err == nil || n >= c.retries {
+			return err
+		}
+		c.recorder.add(Event{Component: c.name, Message: "start attempt " + strconv.Itoa(n+1) + " failed", Err: err})
+		if l := g.opts.logger; l != nil {
+			l.LogAttrs(ctx, slog.LevelWarn, "component start failed, retrying",
+				slog.String("component", c.name),
+				slog.Int("attempt", n+1),
+				slog.Any("error", err),
+			)
+		}
+		if !sleep(ctx, startRetry.delay(n)) {
+			return err
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// DefaultDrainTimeout is the default duration of draining. It can be
+// customized using the WithDrainTimeout option.
+const DefaultDrainTimeout = 5 * time.Second
+
+// ErrDrainContextDeadlineExceeded is returned when draining exceeds the drain timeout.
+var ErrDrainContextDeadlineExceeded = errors.New("drain context deadline exceeded")
+
+// Drainer is implemented by components that accept work from outside, such
+// as servers and consumers. Register it with the Drains component option.
+type Drainer interface {
+	// Drain stops accepting new work, such as closing listeners or
+	// unsubscribing, and lets work in flight finish. Resources needed by
+	// other components must stay open until the component stops.
+	Drain(ctx context.Context) error
+}
+
+// DrainerFunc adapts an ordinary function to the Drainer interface.
+type DrainerFunc func(ctx context.Context) error
+
+// Drain calls f(ctx).
+func (f DrainerFunc) Drain(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Drains returns a ComponentOption that registers d to be called when the
+// group shuts down, before any component is stopped, within the drain
+// timeout set with WithDrainTimeout.
+func Drains(d Drainer) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.drainer = d
+	})
+}
+
+// drain calls the drainers of all running components concurrently on the
+// worker pool, so every component stops accepting new work before any is
+// torn down, and returns their joined errors.
+func (g *Group) drain(ctx context.Context, s *schedule) error {
+	g.mu.Lock()
+	var drainers []*component
+	for _, wave := range slices.Concat(s.waves, g.late) {
+		for _, c := range wave {
+			if c.drainer != nil && c.loadState() == StateRunning {
+				drainers = append(drainers, c)
+			}
+		}
+	}
+	g.mu.Unlock()
+	if len(drainers) == 0 {
+		return nil
+	}
+
+	g.logGroup("group draining")
+	return g.callAll(ctx, drainers, g.opts.drainTimeout, PhaseDrain, ErrDrainContextDeadlineExceeded,
+		func(ctx context.Context, c *component) error {
+			return c.drainer.Drain(ctx)
+		}, nil)
+}
+
+// WithDrainTimeout returns an Option that sets how long the first steps of
+// shutdown, leaving service discovery and calling the drainers registered
+// with Drains, may take. The stop timeout begins once they are done, so a
+// hung drainer does not leave the stop functions without time: shutdown
+// takes at most the drain timeout, then the stop timeout, then the force
+// stop timeout.
+//
+// Default is DefaultDrainTimeout (5 seconds).
+func WithDrainTimeout(v time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.drainTimeout = v
+	})
+}
@@ -0,0 +1,195 @@
+// Package dynamic manages a changing set of members, such as one pipeline
+// per client connection, as a single run.Group component. Wait only starts
+// the components registered before it; members of a Set may join while the
+// group runs. Like the group's own registry, a Set keeps its members in a
+// sharded map, so that adding and removing them at a high rate takes
+// constant time and rarely contends. Stopping the component stops every
+// member still in the set.
+//
+// Members are not components of the group: they have no handle, state,
+// hooks or dependencies.
+package dynamic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/not-for-prod/run"
+)
+
+// DefaultShards is the default number of shards of a Set.
+const DefaultShards = 32
+
+// DefaultConcurrency is the default number of members stopped at the same
+// time when the set stops.
+const DefaultConcurrency = 64
+
+// ErrStopped is returned by Add once the set is stopping.
+var ErrStopped = errors.New("set stopped")
+
+// Option configures a Set.
+type Option func(*Set)
+
+// WithShards returns an Option that sets the number of shards members are
+// spread over. More shards reduce contention between concurrent calls to
+// Add and Remove. Values less than one are treated as one.
+//
+// Default is DefaultShards.
+func WithShards(n int) Option {
+	return func(s *Set) {
+		s.shards = make([]shard, max(n, 1))
+	}
+}
+
+// WithConcurrency returns an Option that limits how many members are
+// stopped at the same time when the set stops. Values less than one are
+// treated as one.
+//
+// Default is DefaultConcurrency.
+func WithConcurrency(n int) Option {
+	return func(s *Set) {
+		s.concurrency = max(n, 1)
+	}
+}
+
+// Set is a dynamic set of members.
+type Set struct {
+	shards      []shard
+	concurrency int
+	next        atomic.Uint64 // id of the next member
+	size        atomic.Int64  // members in the set
+	closed      atomic.Bool   // set once the set rejects new members
+}
+
+// shard holds the members whose id maps to it.
+type shard struct {
+	mu      sync.Mutex
+	members map[*Member]struct{}
+}
+
+// Member is a single member of a Set.
+type Member struct {
+	set   *Set
+	shard *shard
+	stop  run.Stop
+
+	once sync.Once
+	err  error // result of stop
+}
+
+// New returns an empty Set configured by opts. Members may be added as soon
+// as it is returned.
+func New(opts ...Option) *Set {
+	s := &Set{
+		shards:      make([]shard, DefaultShards),
+		concurrency: DefaultConcurrency,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add calls start and, if it returns nil, adds a member stopped with stop
+// when it is removed or the set stops. If start fails, stop is called to
+// release what it acquired and the errors are returned. Add returns
+// ErrStopped, after stopping the member, once the set is stopping.
+func (s *Set) Add(ctx context.Context, start run.Start, stop run.Stop) (*Member, error) {
+	if s.closed.Load() {
+		return nil, ErrStopped
+	}
+	if err := start(); err != nil {
+		return nil, errors.Join(err, stop(ctx))
+	}
+
+	m := &Member{set: s, stop: stop}
+	m.shard = &s.shards[s.next.Add(1)%uint64(len(s.shards))]
+
+	m.shard.mu.Lock()
+	// Checked under the shard's lock, so Stop either sees the member or Add
+	// sees the set closed.
+	if s.closed.Load() {
+		m.shard.mu.Unlock()
+		return nil, errors.Join(ErrStopped, m.Remove(ctx))
+	}
+	if m.shard.members == nil {
+		m.shard.members = make(map[*Member]struct{})
+	}
+	m.shard.members[m] = struct{}{}
+	m.shard.mu.Unlock()
+	s.size.Add(1)
+	return m, nil
+}
+
+// Remove removes the member from its set and stops it. It is safe to call
+// more than once and concurrently with the set stopping: the stop function
+// runs once, and later callers wait for it and receive the same result.
+func (m *Member) Remove(ctx context.Context) error {
+	m.shard.mu.Lock()
+	if _, ok := m.shard.members[m]; ok {
+		delete(m.shard.members, m)
+		m.set.size.Add(-1)
+	}
+	m.shard.mu.Unlock()
+
+	m.once.Do(func() {
+		m.err = m.stop(ctx)
+	})
+	return m.err
+}
+
+// Len returns the number of members in the set.
+func (s *Set) Len() int {
+	return int(s.size.Load())
+}
+
+// Close rejects further members without stopping the current ones.
+func (s *Set) Close() {
+	s.closed.Store(true)
+}
+
+// Stop rejects further members and stops every member still in the set
+// concurrently, returning their errors joined.
+func (s *Set) Stop(ctx context.Context) error {
+	s.Close()
+
+	var members []*Member
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.Lock()
+		for m := range sh.members {
+			members = append(members, m)
+		}
+		sh.mu.Unlock()
+	}
+
+	errs := make([]error, len(members))
+	sem := make(chan struct{}, s.concurrency)
+	var wg sync.WaitGroup
+	for i, m := range members {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = m.Remove(ctx)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Register adds a component to g that rejects new members once the group
+// drains and stops s when it stops, and returns its handle. Further
+// component options, such as Named, may be passed in opts.
+func (s *Set) Register(g *run.Group, opts ...run.ComponentOption) *run.Handle {
+	opts = append([]run.ComponentOption{run.Named("dynamic"), run.Drains(run.DrainerFunc(func(context.Context) error {
+		s.Close()
+		return nil
+	}))}, opts...)
+	return g.Add(func() error { return nil }, s.Stop, opts...)
+}
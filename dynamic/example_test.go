@@ -0,0 +1,57 @@
+package dynamic_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/dynamic"
+)
+
+func ExampleSet_Register() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := dynamic.New(dynamic.WithShards(8))
+	g := run.NewGroup()
+	s.Register(g, run.Named("connections"))
+
+	var closed atomic.Int32
+	start := func() error { return nil }
+	stop := func(ctx context.Context) error {
+		closed.Add(1)
+		return nil
+	}
+
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		var members []*dynamic.Member
+		for range 3 { // Clients connect.
+			m, err := s.Add(ctx, start, stop)
+			if err != nil {
+				fmt.Println("add:", err)
+				return
+			}
+			members = append(members, m)
+		}
+
+		// A client disconnects.
+		_ = members[0].Remove(ctx)
+		fmt.Println("members:", s.Len())
+		cancel()
+	})
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("wait error:", err)
+	}
+
+	fmt.Println("closed:", closed.Load())
+	_, err := s.Add(context.Background(), start, stop)
+	fmt.Println(errors.Is(err, dynamic.ErrStopped))
+	// Output:
+	// members: 2
+	// closed: 3
+	// true
+}
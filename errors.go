@@ -0,0 +1,221 @@
+package run
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ComponentError is an error of a component's start, stop or other lifecycle
+// call annotated with the component it came from. Its message is the message
+// of the underlying error; use errors.As on the error returned by Wait to
+// route failures by component or label, for example to the owning team.
+type ComponentError struct {
+	Component string            // component name
+	Labels    map[string]string // component labels, must not be modified
+	Phase     Phase             // phase the error occurred in
+	Err       error             // error returned by the component's call
+}
+
+// Error returns the message of the underlying error.
+func (e *ComponentError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ComponentError) Unwrap() error {
+	return e.Err
+}
+
+// wrap annotates err with c's identity. It returns nil for a nil err.
+func (c *component) wrap(phase Phase, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ComponentError{
+		Component: c.name,
+		Labels:    c.labels,
+		Phase:     phase,
+		Err:       err,
+	}
+}
+
+// transform applies the transformer set with WithErrorTransformer to an
+// error returned by one of c's calls.
+func (g *Group) transform(c *component, err error) error {
+	if err == nil || g.opts.transform == nil {
+		return err
+	}
+	return g.opts.transform(c.name, err)
+}
+
+// collate orders errs by component and caps them at the configured limit.
+func (g *Group) collate(errs []error) []error {
+	return g.capErrors(g.sortErrors(errs))
+}
+
+// capErrors keeps the first errors up to the limit set with WithErrorLimit
+// and summarizes the rest in a SuppressedError.
+func (g *Group) capErrors(errs []error) []error {
+	n := g.opts.errorLimit
+	if n <= 0 || len(errs) <= n {
+		return errs
+	}
+	suppressed := &SuppressedError{Errs: slices.Clone(errs[n:])}
+	return append(errs[:n:n], suppressed)
+}
+
+// SuppressedError summarizes the errors left out of a joined error by
+// WithErrorLimit, grouped by message, for example:
+//
+//	198 more errors: dial tcp 10.0.0.7:5432: connect: network is unreachable (x190); i/o timeout (x8)
+type SuppressedError struct {
+	Errs []error // suppressed errors in registration order
+}
+
+// Error returns the summary.
+func (e *SuppressedError) Error() string {
+	counts := make(map[string]int)
+	var messages []string
+	for _, err := range e.Errs {
+		msg := err.Error()
+		if counts[msg] == 0 {
+			messages = append(messages, msg)
+		}
+		counts[msg]++
+	}
+	slices.SortStableFunc(messages, func(a, b string) int {
+		return cmp.Compare(counts[b], counts[a])
+	})
+
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(len(e.Errs)))
+	b.WriteString(" more errors: ")
+	for i, msg := range messages {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(msg)
+		b.WriteString(" (x")
+		b.WriteString(strconv.Itoa(counts[msg]))
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+// Unwrap returns the suppressed errors, so errors.Is and errors.As still
+// find them.
+func (e *SuppressedError) Unwrap() []error {
+	return e.Errs
+}
+
+// sortErrors orders errs in place by the registration order of the
+// components they came from, so that the same failure yields the same joined
+// error on every run regardless of which call returned first. Errors not tied
+// to a component keep their relative order after all others. It returns errs.
+func (g *Group) sortErrors(errs []error) []error {
+	if len(errs) < 2 {
+		return errs
+	}
+
+	components := g.registry.list()
+	index := make(map[string]int, len(components))
+	for i, c := range components {
+		index[c.name] = i
+	}
+
+	position := func(err error) int {
+		var cerr *ComponentError
+		if errors.As(err, &cerr) {
+			if i, ok := index[cerr.Component]; ok {
+				return i
+			}
+		}
+		return math.MaxInt
+	}
+	slices.SortStableFunc(errs, func(a, b error) int {
+		return cmp.Compare(position(a), position(b))
+	})
+	return errs
+}
+
+// DefaultErrorsBuffer is the default capacity of the channel returned by
+// Group.Errors. It can be customized using the WithErrorsBuffer option.
+const DefaultErrorsBuffer = 64
+
+// errorStream delivers component errors as they happen.
+type errorStream struct {
+	mu     sync.Mutex
+	ch     chan error
+	closed bool
+}
+
+// send delivers err without blocking, dropping it when the buffer is full.
+func (s *errorStream) send(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	select {
+	case s.ch <- err:
+	default:
+	}
+}
+
+// close closes the channel; later errors are dropped.
+func (s *errorStream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// Errors returns a channel delivering every component error as a
+// ComponentError as it happens, rather than only in the error returned by
+// Wait — start, stop, reload and rotate failures alike — for example to
+// forward them to an error-reporting agent in real time. The channel is
+// closed when Wait returns.
+//
+// Sending never blocks the lifecycle: errors are dropped while the buffer
+// set with WithErrorsBuffer is full. Wait still returns every error.
+func (g *Group) Errors() <-chan error {
+	return g.errors.ch
+}
+
+// failed reports a failed call of c to the flight recorder, the OnError
+// callback and the Errors channel.
+func (g *Group) failed(c *component, phase Phase, err error) {
+	g.recorder.add(Event{Component: c.name, Message: string(phase) + " failed", Err: err})
+	if fn := g.opts.onError; fn != nil {
+		fn(c.name, phase, err)
+	}
+	err = c.wrap(phase, err)
+	if phase == PhaseStart {
+		g.mu.Lock()
+		if g.firstErr == nil {
+			g.firstErr = err
+		}
+		g.mu.Unlock()
+	}
+	g.errors.send(err)
+}
+
+// firstError returns the first error joined in err.
+func firstError(err error) error {
+	for {
+		joined, ok := err.(interface{ Unwrap() []error })
+		if !ok || len(joined.Unwrap()) == 0 {
+			return err
+		}
+		err = joined.Unwrap()[0]
+	}
+}
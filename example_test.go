@@ -1,9 +1,23 @@
 package run_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/not-for-prod/run"
@@ -66,6 +80,27 @@ func ExampleGroup_Wait_startContextDeadlineExceeded() {
 	// start context deadline exceeded
 }
 
+func ExampleGroup_Wait_waitContextDeadline() {
+	// The caller's deadline is shorter than the start timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	g := run.NewGroup(run.WithStartTimeout(time.Minute))
+	g.Add(func() error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	}, func(ctx context.Context) error {
+		return nil
+	})
+
+	err := g.Wait(ctx)
+	fmt.Println(err)
+	fmt.Println(errors.Is(err, run.ErrStartContextDeadlineExceeded), errors.Is(err, context.DeadlineExceeded))
+	// Output:
+	// start context deadline exceeded: context deadline exceeded of the Wait context
+	// true true
+}
+
 func ExampleGroup_Wait_stopError() {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
@@ -106,3 +141,2750 @@ func ExampleGroup_Wait_stopContextDeadlineExceeded() {
 	// fail
 	// stop context deadline exceeded
 }
+
+func ExampleWithConcurrency() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var started atomic.Int64
+	g := run.NewGroup(run.WithConcurrency(8))
+	for range 5000 {
+		g.Add(func() error {
+			if started.Add(1) == 5000 {
+				cancel()
+			}
+			return nil
+		}, func(ctx context.Context) error {
+			return nil
+		})
+	}
+
+	err := g.Wait(ctx)
+	fmt.Println(started.Load(), err)
+	// Output:
+	// 5000 <nil>
+}
+
+func ExampleNewGroupWithCapacity() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g := run.NewGroupWithCapacity(2, run.WithConcurrency(1))
+	for i := range 2 {
+		g.Add(func() error {
+			if i == 1 {
+				cancel()
+			}
+			return nil
+		}, func(ctx context.Context) error {
+			fmt.Println("stop", i)
+			return nil
+		})
+	}
+
+	err := g.Wait(ctx)
+	fmt.Println(err)
+	// Output:
+	// stop 1
+	// stop 0
+	// <nil>
+}
+
+func ExampleProvide() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g := run.NewGroup()
+
+	dsn := run.Provide(g, func(ctx context.Context) (string, run.Stop, error) {
+		return "postgres://localhost/app", func(ctx context.Context) error {
+			fmt.Println("closing database")
+			return nil
+		}, nil
+	})
+
+	g.Add(func() error {
+		v, err := dsn.Wait(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println("connected to", v)
+		cancel()
+		return nil
+	}, func(ctx context.Context) error {
+		return nil
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// connected to postgres://localhost/app
+	// closing database
+}
+
+func ExampleRequires() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g := run.NewGroup()
+
+	// The API server is registered first but requires the database handle,
+	// so the group starts the database before it and stops it after.
+	g.Add(func() error {
+		dsn, err := run.Resolve[string](g)
+		if err != nil {
+			return err
+		}
+		fmt.Println("api using", dsn)
+		cancel()
+		return nil
+	}, func(ctx context.Context) error {
+		fmt.Println("api stopped")
+		return nil
+	}, run.Named("api"), run.Requires[string]())
+
+	run.Provide(g, func(ctx context.Context) (string, run.Stop, error) {
+		fmt.Println("database started")
+		return "postgres://localhost/app", func(ctx context.Context) error {
+			fmt.Println("database stopped")
+			return nil
+		}, nil
+	}, run.Named("db"))
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// database started
+	// api using postgres://localhost/app
+	// api stopped
+	// database stopped
+}
+
+func ExampleRequires_missingProvider() {
+	g := run.NewGroup()
+	g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("api"), run.Requires[string]())
+
+	err := g.Wait(context.Background())
+	fmt.Println(err)
+	fmt.Println(errors.Is(err, run.ErrMissingProvider))
+	// Output:
+	// component "api" requires string: missing provider
+	// true
+}
+
+func ExampleCycleError() {
+	noop := func() error { return nil }
+	stop := func(ctx context.Context) error { return nil }
+
+	g := run.NewGroup()
+	g.Add(noop, stop, run.Named("config"))
+	g.Add(noop, stop, run.Named("db"), run.DependsOn("config", "cache"))
+	g.Add(noop, stop, run.Named("cache"), run.DependsOn("api"))
+	g.Add(noop, stop, run.Named("api"), run.DependsOn("db"))
+
+	err := g.Wait(context.Background())
+	fmt.Println(err)
+
+	var cycle *run.CycleError
+	if errors.As(err, &cycle) {
+		fmt.Println(cycle.Path)
+	}
+	// Output:
+	// dependency cycle: db -> cache -> api -> db
+	// [db cache api db]
+}
+
+func ExampleDependsOn() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var ready atomic.Int64
+	start := func() error {
+		ready.Add(1)
+		return nil
+	}
+	stop := func(ctx context.Context) error { return nil }
+
+	g := run.NewGroup()
+
+	// db and cache share a wave and start concurrently; api waits for both.
+	g.Add(start, stop, run.Named("db"))
+	g.Add(start, stop, run.Named("cache"))
+	g.Add(func() error {
+		fmt.Println("api started after", ready.Load(), "dependencies")
+		cancel()
+		return nil
+	}, stop, run.Named("api"), run.DependsOn("db", "cache"))
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// api started after 2 dependencies
+}
+
+func ExampleGroup_Plan() {
+	noop := func() error { return nil }
+	stop := func(ctx context.Context) error { return nil }
+
+	g := run.NewGroup(run.WithStopTimeout(5 * time.Second))
+	g.Add(noop, stop, run.Named("config"))
+	g.Add(noop, stop, run.Named("db"), run.DependsOn("config"))
+	g.Add(noop, stop, run.Named("cache"), run.DependsOn("config"))
+	g.Add(noop, stop, run.Named("api"), run.DependsOn("db", "cache"))
+	g.Add(noop, stop, run.Named("tracing"), run.Enabled(false))
+	g.Add(noop, stop, run.Named("exporter"), run.DependsOn("tracing"))
+
+	plan, err := g.Plan()
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Print(plan)
+	// Output:
+	// start (timeout 15s):
+	//   1: config
+	//   2: db, cache
+	//   3: api
+	// stop (timeout 5s):
+	//   1: api
+	//   2: cache, db
+	//   3: config
+	// disabled: tracing, exporter
+}
+
+func ExampleGroup_Plan_timeouts() {
+	noop := func() error { return nil }
+	stop := func(ctx context.Context) error { return nil }
+
+	g := run.NewGroup()
+	g.Add(noop, stop, run.Named("config"), run.StartTimeout(time.Second))
+	g.Add(noop, stop, run.Named("db"), run.DependsOn("config"), run.StopTimeout(30*time.Second))
+	g.Add(noop, stop, run.Named("api"), run.DependsOn("db"), run.StopTimeout(2*time.Second))
+
+	plan, err := g.Plan()
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	// A component's own timeout shows where it is shorter than the phase's.
+	fmt.Print(plan)
+	// Output:
+	// start (timeout 15s):
+	//   1: config (timeout 1s)
+	//   2: db
+	//   3: api
+	// stop (timeout 15s):
+	//   1: api (timeout 2s)
+	//   2: db
+	//   3: config
+}
+
+func ExampleWithStartWaveTimeout() {
+	stop := func(ctx context.Context) error { return nil }
+
+	g := run.NewGroup(
+		run.WithStartTimeout(time.Second),
+		run.WithStartWaveTimeout(50*time.Millisecond),
+	)
+	g.Add(func() error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	}, stop, run.Named("migrations"))
+	g.Add(func() error {
+		return nil
+	}, stop, run.Named("api"), run.DependsOn("migrations"))
+
+	err := g.Wait(context.Background())
+	fmt.Println(err)
+	fmt.Println(errors.Is(err, run.ErrStartContextDeadlineExceeded))
+	// Output:
+	// start context deadline exceeded: wave 1: waiting on migrations
+	// true
+}
+
+func ExamplePriority() {
+	noop := func() error { return nil }
+	stop := func(ctx context.Context) error { return nil }
+
+	g := run.NewGroup()
+	g.Add(noop, stop, run.Named("http"))
+	g.Add(noop, stop, run.Named("grpc"))
+	g.Add(noop, stop, run.Named("postgres"), run.Priority(10))
+	g.Add(noop, stop, run.Named("redis"), run.Priority(10))
+	g.Add(noop, stop, run.Named("metrics"), run.Priority(20))
+
+	plan, err := g.Plan()
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Print(plan)
+	// Output:
+	// start (timeout 15s):
+	//   1: metrics
+	//   2: postgres, redis
+	//   3: http, grpc
+	// stop (timeout 15s):
+	//   1: grpc, http
+	//   2: redis, postgres
+	//   3: metrics
+}
+
+func ExampleWithStopClasses() {
+	noop := func() error { return nil }
+	stop := func(ctx context.Context) error { return nil }
+
+	g := run.NewGroup(run.WithStopClasses("listeners", "workers", "stores", "telemetry"))
+	g.Add(noop, stop, run.Named("tracing"), run.StopClass("telemetry"))
+	g.Add(noop, stop, run.Named("postgres"), run.StopClass("stores"))
+	g.Add(noop, stop, run.Named("consumer"), run.StopClass("workers"))
+	g.Add(noop, stop, run.Named("http"), run.StopClass("listeners"))
+	g.Add(noop, stop, run.Named("grpc"), run.StopClass("listeners"))
+
+	plan, err := g.Plan()
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Print(plan)
+	// Output:
+	// start (timeout 15s):
+	//   1: tracing, postgres, consumer, http, grpc
+	// stop (timeout 15s):
+	//   1: grpc, http
+	//   2: consumer
+	//   3: postgres
+	//   4: tracing
+}
+
+func ExampleGroup_States() {
+	g := run.NewGroup(run.WithStopTimeout(50 * time.Millisecond))
+	g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("db"))
+	g.Add(func() error {
+		return errors.New("bind: address already in use")
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("http"), run.DependsOn("db"))
+	g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("worker"), run.DependsOn("http"))
+
+	fmt.Println(g.States()["http"])
+	_ = g.Wait(context.Background())
+
+	states := g.States()
+	for _, name := range []string{"db", "http", "worker"} {
+		fmt.Println(name, states[name])
+	}
+	// Output:
+	// registered
+	// db stopped
+	// http failed
+	// worker registered
+}
+
+func ExampleHandle() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g := run.NewGroup()
+	consumer := g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		fmt.Println("consumer stopped")
+		return nil
+	}, run.Named("consumer"))
+	g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		fmt.Println("api stopped")
+		return nil
+	}, run.Named("api"))
+
+	go func() {
+		<-consumer.Started()
+		// Pause consumption while the rest of the group keeps serving.
+		if err := consumer.Stop(ctx); err != nil {
+			fmt.Println("error:", err)
+		}
+		<-consumer.Done()
+		fmt.Println("consumer state:", consumer.State())
+		cancel()
+	}()
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// consumer stopped
+	// consumer state: stopped
+	// api stopped
+}
+
+func ExampleGroup_ReadyHandler() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g := run.NewGroup()
+	probe := func() int {
+		rec := httptest.NewRecorder()
+		g.ReadyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rec.Code
+	}
+
+	api := g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		fmt.Println("during stop:", probe())
+		return nil
+	})
+
+	fmt.Println("before start:", probe())
+	go func() {
+		<-api.Started()
+		for !g.Ready() {
+			time.Sleep(time.Millisecond)
+		}
+		fmt.Println("running:", probe())
+		cancel()
+	}()
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// before start: 503
+	// running: 200
+	// during stop: 503
+}
+
+func ExampleWithProgress() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g := run.NewGroup(run.WithProgress(func(p run.Progress) {
+		fmt.Println(p)
+		if p.Started == p.Total {
+			cancel()
+		}
+	}))
+
+	stop := func(ctx context.Context) error { return nil }
+	g.Add(func() error { return nil }, stop, run.Named("config"))
+	g.Add(func() error { return nil }, stop, run.Named("db-pool"), run.DependsOn("config"))
+	g.Add(func() error { return nil }, stop, run.Named("kafka-consumer"), run.DependsOn("db-pool"))
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// 1/3 started
+	// 2/3 started
+	// 3/3 started
+}
+
+// printMetrics is a Metrics implementation that prints counters and gauges.
+type printMetrics struct{}
+
+func (printMetrics) Count(name string, delta float64, labels map[string]string) {
+	fmt.Println(name, labels[run.LabelComponent], labels[run.LabelPhase], labels[run.LabelOutcome], delta)
+}
+
+func (printMetrics) Gauge(name string, value float64, labels map[string]string) {
+	fmt.Println(name, value)
+}
+
+func (printMetrics) Observe(name string, value float64, labels map[string]string) {}
+
+func ExampleWithMetrics() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g := run.NewGroup(run.WithMetrics(printMetrics{}))
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+	g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		return errors.New("flush failed")
+	}, run.Named("exporter"))
+
+	_ = g.Wait(ctx)
+	// Output:
+	// run_component_calls_total exporter start ok 1
+	// run_group_ready 1
+	// run_group_ready 0
+	// run_component_calls_total exporter stop error 1
+}
+
+func ExampleComponentFromContext() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// closeLogged is a stop helper shared by several components.
+	closeLogged := func(ctx context.Context) error {
+		info, _ := run.ComponentFromContext(ctx)
+		fmt.Println("closing", info.Name)
+		return nil
+	}
+
+	g := run.NewGroup(run.WithConcurrency(1))
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+	g.Add(func() error { return nil }, closeLogged, run.Named("postgres"))
+	g.Add(func() error { return nil }, closeLogged, run.Named("redis"))
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// closing redis
+	// closing postgres
+}
+
+func ExampleLabel() {
+	g := run.NewGroup()
+	g.Add(func() error {
+		return errors.New("permission denied")
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("ledger"), run.Label("team", "payments"), run.Label("tier", "1"))
+
+	err := g.Wait(context.Background())
+
+	var cerr *run.ComponentError
+	if errors.As(err, &cerr) {
+		fmt.Printf("%s failed to %s: %v (page team %s)\n", cerr.Component, cerr.Phase, cerr.Err, cerr.Labels["team"])
+	}
+	// Output:
+	// ledger failed to start: permission denied (page team payments)
+}
+
+func ExampleWithSelector() {
+	noop := func() error { return nil }
+	stop := func(ctx context.Context) error { return nil }
+
+	wire := func(g *run.Group) {
+		g.Add(noop, stop, run.Named("postgres"), run.Tags("infra"))
+		g.Add(noop, stop, run.Named("metrics"))
+		g.Add(noop, stop, run.Named("http"), run.Tags("api"), run.DependsOn("postgres"))
+		g.Add(noop, stop, run.Named("consumer"), run.Tags("worker"), run.DependsOn("postgres"))
+	}
+
+	for _, role := range []string{"api", "worker"} {
+		g := run.NewGroup(run.WithSelector(run.AnyOf(run.Tagged(role), run.Untagged())))
+		wire(g)
+
+		plan, err := g.Plan()
+		if err != nil {
+			fmt.Println("error:", err)
+			return
+		}
+		fmt.Println(role, plan.Start, "disabled:", plan.Disabled)
+	}
+	// Output:
+	// api [[{postgres 15s} {metrics 15s}] [{http 15s}]] disabled: [consumer]
+	// worker [[{postgres 15s} {metrics 15s}] [{consumer 15s}]] disabled: [http]
+}
+
+func ExampleBindFlags() {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	toggles := run.BindFlags(fs)
+	if err := fs.Parse([]string{"-only=api", "-disable=tracing,profiler"}); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+
+	noop := func() error { return nil }
+	stop := func(ctx context.Context) error { return nil }
+
+	g := run.NewGroup(toggles.Option())
+	g.Add(noop, stop, run.Named("postgres"))
+	g.Add(noop, stop, run.Named("tracing"))
+	g.Add(noop, stop, run.Named("profiler"))
+	g.Add(noop, stop, run.Named("http"), run.Tags("api"), run.DependsOn("postgres", "tracing"))
+	g.Add(noop, stop, run.Named("grpc"), run.Tags("api"), run.DependsOn("postgres"))
+	g.Add(noop, stop, run.Named("consumer"), run.Tags("worker"), run.DependsOn("postgres"))
+
+	plan, err := g.Plan()
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println("disabled:", plan.Disabled)
+	// Output:
+	// disabled: [tracing profiler http consumer]
+}
+
+func ExampleWithGate() {
+	// rollout stands in for a feature-flag client.
+	rollout := map[string]bool{"search-v2": false}
+
+	gate := run.GateFunc(func(ctx context.Context, c run.ComponentInfo) bool {
+		enabled, ok := rollout[c.Name]
+		return !ok || enabled
+	})
+
+	noop := func() error { return nil }
+	stop := func(ctx context.Context) error { return nil }
+
+	g := run.NewGroup(run.WithGate(gate))
+	g.Add(noop, stop, run.Named("search"))
+	g.Add(noop, stop, run.Named("search-v2"))
+	g.Add(noop, stop, run.Named("search-v2-indexer"), run.DependsOn("search-v2"))
+
+	plan, err := g.Plan()
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Print(plan)
+	// Output:
+	// start (timeout 15s):
+	//   1: search
+	// stop (timeout 15s):
+	//   1: search
+	// disabled: search-v2, search-v2-indexer
+}
+
+// elector is an Elector that wins every campaign at once and loses
+// leadership when its lost channel is closed.
+type elector struct {
+	lost chan struct{}
+}
+
+func (e *elector) Campaign(ctx context.Context) (<-chan struct{}, error) {
+	return e.lost, nil
+}
+
+func (e *elector) Resign(ctx context.Context) error {
+	return nil
+}
+
+func ExampleLead() {
+	e := &elector{lost: make(chan struct{})}
+
+	g := run.NewGroup()
+	run.Lead(g, e, func() *run.Group {
+		term := run.NewGroup()
+		term.Add(func() error {
+			fmt.Println("scheduler started")
+			return nil
+		}, func(ctx context.Context) error {
+			fmt.Println("scheduler stopped")
+			return nil
+		})
+
+		// Simulate losing leadership once the term is running.
+		term.OnReadyChange(func(ready bool) {
+			if ready {
+				close(e.lost)
+			}
+		})
+		return term
+	}, run.ExitOnLeadershipLoss())
+
+	err := g.Wait(context.Background())
+	fmt.Println("error:", err)
+	fmt.Println("leadership lost:", errors.Is(err, run.ErrLeadershipLost))
+	// Output:
+	// scheduler started
+	// scheduler stopped
+	// error: leadership lost
+	// leadership lost: true
+}
+
+// locker is a Locker that prints when it is acquired and released.
+type locker struct{}
+
+func (locker) Lock(ctx context.Context) error {
+	fmt.Println("lock acquired")
+	return nil
+}
+
+func (locker) Unlock(ctx context.Context) error {
+	fmt.Println("lock released")
+	return nil
+}
+
+func ExampleWithLock() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup(run.WithLock(locker{}))
+	g.Add(func() error {
+		fmt.Println("billing worker started")
+		return nil
+	}, func(ctx context.Context) error {
+		fmt.Println("billing worker stopped")
+		return nil
+	})
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// lock acquired
+	// billing worker started
+	// billing worker stopped
+	// lock released
+}
+
+func ExampleGroup_Rotate() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	noop := func() error { return nil }
+	stop := func(ctx context.Context) error { return nil }
+
+	g := run.NewGroup(run.WithConcurrency(1))
+	g.Add(noop, stop, run.Named("orders-db"), run.Rotates(run.RotatorFunc(func(ctx context.Context, secret string) error {
+		if secret == "db-password" {
+			fmt.Println("orders-db reconnected")
+		}
+		return nil
+	})))
+	g.Add(noop, stop, run.Named("billing-db"), run.Rotates(run.RotatorFunc(func(ctx context.Context, secret string) error {
+		return errors.New("authentication failed")
+	})))
+
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		err := g.Rotate(ctx, "db-password")
+
+		var cerr *run.ComponentError
+		if errors.As(err, &cerr) {
+			fmt.Printf("%s: %s: %v\n", cerr.Component, cerr.Phase, cerr.Err)
+		}
+		cancel()
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// orders-db reconnected
+	// billing-db: rotate: authentication failed
+}
+
+func ExampleGroup_Reload() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// rollout stands in for a feature-flag client.
+	var recommendations atomic.Bool
+	recommendations.Store(true)
+	gate := run.GateFunc(func(ctx context.Context, c run.ComponentInfo) bool {
+		return c.Name != "recommendations" || recommendations.Load()
+	})
+
+	g := run.NewGroup(run.WithGate(gate))
+	g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("api"), run.Reloads(run.ReloaderFunc(func(ctx context.Context) error {
+		fmt.Println("api config reloaded")
+		return nil
+	})))
+	recs := g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		fmt.Println("recommendations stopped")
+		return nil
+	}, run.Named("recommendations"))
+
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+
+		// Roll recommendations back, then reload as on SIGHUP.
+		recommendations.Store(false)
+		if err := g.Reload(ctx); err != nil {
+			fmt.Println("reload error:", err)
+		}
+		fmt.Println("recommendations:", recs.State())
+		cancel()
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// recommendations stopped
+	// api config reloaded
+	// recommendations: stopped
+}
+
+func ExampleGroup_Reload_rollout() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// rollout stands in for a feature-flag client; search-v2 is off at boot.
+	var searchV2 atomic.Bool
+	gate := run.GateFunc(func(ctx context.Context, c run.ComponentInfo) bool {
+		return c.Name != "search-v2" || searchV2.Load()
+	})
+
+	g := run.NewGroup(run.WithGate(gate))
+	stopped := func(name string) run.Stop {
+		return func(ctx context.Context) error {
+			fmt.Println(name, "stopped")
+			return nil
+		}
+	}
+	g.Add(func() error {
+		return nil
+	}, stopped("index"), run.Named("index"))
+	search := g.Add(func() error {
+		fmt.Println("search-v2 started")
+		return nil
+	}, stopped("search-v2"), run.Named("search-v2"), run.DependsOn("index"))
+	g.Add(func() error {
+		return errors.New("model not found")
+	}, stopped("ranker"), run.Named("ranker"), run.DependsOn("search-v2"))
+
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+
+		// Roll search-v2 out, then reload as on SIGHUP. Its dependent ranker
+		// starts once it runs, and fails.
+		searchV2.Store(true)
+		var ce *run.ComponentError
+		if err := g.Reload(ctx); errors.As(err, &ce) {
+			fmt.Println("reload error:", ce.Component, ce.Err)
+		}
+		fmt.Println("search-v2:", search.State())
+		cancel()
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// search-v2 started
+	// reload error: ranker model not found
+	// search-v2: running
+	// ranker stopped
+	// search-v2 stopped
+	// index stopped
+}
+
+// registrar is a Registrar that prints instead of calling a discovery
+// service.
+type registrar struct{}
+
+func (registrar) Register(ctx context.Context) error {
+	fmt.Println("registered with discovery")
+	return nil
+}
+
+func (registrar) Deregister(ctx context.Context) error {
+	fmt.Println("deregistered from discovery")
+	return nil
+}
+
+func ExampleWithRegistrar() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup(run.WithRegistrar(registrar{}))
+	g.Add(func() error {
+		fmt.Println("api started")
+		return nil
+	}, func(ctx context.Context) error {
+		fmt.Println("api stopped")
+		return nil
+	})
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// api started
+	// registered with discovery
+	// deregistered from discovery
+	// api stopped
+}
+
+func ExampleGroup_WriteTrace() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	noop := func() error { return nil }
+	stop := func(ctx context.Context) error { return nil }
+
+	g := run.NewGroup()
+	g.Add(noop, stop, run.Named("db"))
+	g.Add(noop, stop, run.Named("api"), run.DependsOn("db"))
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := g.WriteTrace(&buf); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+
+	var trace struct {
+		TraceEvents []struct {
+			Name  string `json:"name"`
+			Phase string `json:"ph"`
+			TID   int    `json:"tid"`
+		} `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &trace); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	for _, e := range trace.TraceEvents {
+		if e.Phase == "X" {
+			fmt.Println("track", e.TID, e.Name)
+		}
+	}
+	// Output:
+	// track 1 start
+	// track 1 stop
+	// track 2 start
+	// track 2 stop
+}
+
+func ExampleGroup_Recorder() {
+	g := run.NewGroup()
+	g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		return errors.New("flush timed out")
+	}, run.Named("exporter"))
+	g.Add(func() error {
+		return errors.New("connection refused")
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("db"), run.DependsOn("exporter"))
+
+	if err := g.Wait(context.Background()); err == nil {
+		return
+	}
+
+	// Include what led up to the failure in the crash report.
+	for _, e := range g.Recorder().Events() {
+		e.Time = time.Time{}
+		fmt.Println(strings.TrimPrefix(e.String(), "00:00:00.000 "))
+	}
+	// Output:
+	// exporter: registered -> starting
+	// exporter: starting -> running
+	// db: registered -> starting
+	// db: starting -> failed
+	// db: start failed: connection refused
+	// group stopping
+	// db: failed -> stopping
+	// db: stopping -> failed
+	// exporter: running -> stopping
+	// exporter: stopping -> failed
+	// exporter: stop failed: flush timed out
+}
+
+func ExampleGroup_Err() {
+	g := run.NewGroup()
+	g.Add(func() error {
+		return errors.New("config: missing DATABASE_URL")
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("config"))
+
+	go g.Wait(context.Background())
+
+	// Elsewhere, for example in a test or an admin endpoint.
+	for g.Err() == nil {
+		time.Sleep(time.Millisecond)
+	}
+	fmt.Println("outcome:", g.Err())
+	// Output:
+	// outcome: config: missing DATABASE_URL
+}
+
+func ExampleGroup_Errors() {
+	g := run.NewGroup()
+	g.Add(func() error {
+		return errors.New("kafka: broker unreachable")
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("consumer"))
+
+	// Forward errors as they happen, for example to an error-reporting agent.
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		for err := range g.Errors() {
+			var cerr *run.ComponentError
+			if errors.As(err, &cerr) {
+				fmt.Printf("report: %s %s: %v\n", cerr.Component, cerr.Phase, cerr.Err)
+			}
+		}
+	}()
+
+	err := g.Wait(context.Background())
+	<-forwarded
+	fmt.Println("error:", err)
+	// Output:
+	// report: consumer start: kafka: broker unreachable
+	// error: kafka: broker unreachable
+}
+
+func ExampleWithOnError() {
+	g := run.NewGroup(run.WithOnError(func(component string, phase run.Phase, err error) {
+		// Send to Sentry or another error tracker.
+		fmt.Printf("captured: %s %s: %v\n", component, phase, err)
+	}))
+	g.Add(func() error {
+		return errors.New("listen tcp :443: permission denied")
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("https"))
+
+	err := g.Wait(context.Background())
+	fmt.Println("error:", err)
+	// Output:
+	// captured: https start: listen tcp :443: permission denied
+	// error: listen tcp :443: permission denied
+}
+
+func ExampleGroup_Wait_errorOrder() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	noop := func() error { return nil }
+
+	g := run.NewGroup()
+	g.Add(noop, func(ctx context.Context) error {
+		return errors.New("db: close: connection reset")
+	}, run.Named("db"))
+	g.Add(noop, func(ctx context.Context) error {
+		return errors.New("api: shutdown: 3 requests still in flight")
+	}, run.Named("api"), run.DependsOn("db"))
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+
+	// api stops before db, but errors are listed in registration order.
+	fmt.Println(g.Wait(ctx))
+	// Output:
+	// db: close: connection reset
+	// api: shutdown: 3 requests still in flight
+}
+
+func ExampleWithErrorLimit() {
+	g := run.NewGroup(run.WithErrorLimit(2))
+	for i := range 5 {
+		g.Add(func() error {
+			if i == 4 {
+				return errors.New("i/o timeout")
+			}
+			return errors.New("network is unreachable")
+		}, func(ctx context.Context) error {
+			return nil
+		}, run.Named(fmt.Sprintf("client-%d", i)))
+	}
+
+	fmt.Println(g.Wait(context.Background()))
+	// Output:
+	// network is unreachable
+	// network is unreachable
+	// 3 more errors: network is unreachable (x2); i/o timeout (x1)
+}
+
+func ExampleWithFirstError() {
+	g := run.NewGroup(run.WithFirstError())
+	g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		return errors.New("cache: flush: connection closed")
+	}, run.Named("cache"))
+	g.Add(func() error {
+		return errors.New("config: open app.yaml: no such file or directory")
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("config"), run.DependsOn("cache"))
+
+	fmt.Println("error:", g.Wait(context.Background()))
+	fmt.Println("all errors:")
+	fmt.Println(g.AllErrors())
+	// Output:
+	// error: config: open app.yaml: no such file or directory
+	// all errors:
+	// config: open app.yaml: no such file or directory
+	// cache: flush: connection closed
+}
+
+func ExampleWithErrorTransformer() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup(run.WithErrorTransformer(func(component string, err error) error {
+		// Shutting down an already closed server is benign.
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		// Redact credentials from connection strings.
+		return errors.New(strings.ReplaceAll(err.Error(), "hunter2", "***"))
+	}))
+	g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		return fmt.Errorf("close postgres://app:hunter2@db/app: %w", errors.New("connection reset"))
+	}, run.Named("db"))
+	g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		return http.ErrServerClosed
+	}, run.Named("http"))
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+
+	fmt.Println(g.Wait(ctx))
+	// Output:
+	// close postgres://app:***@db/app: connection reset
+}
+
+func ExampleDegraded() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup()
+	g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("api"))
+	recs := g.Add(func() error {
+		// Recommendations are optional; serve without them.
+		return run.Degraded(errors.New("recommendations: model not found"))
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("recommendations"))
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			fmt.Println("degraded:", g.Degraded())
+			fmt.Println("recommendations:", recs.State(), run.SeverityOf(recs.Err()))
+			cancel()
+		}
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// degraded: true
+	// recommendations: failed degraded
+}
+
+func ExampleGroup_Go() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup()
+	g.Go(func(ctx context.Context) error {
+		// Consume until the group stops.
+		<-ctx.Done()
+		fmt.Println("consumer stopped")
+		return nil
+	}, run.Named("consumer"))
+	g.Go(func(ctx context.Context) error {
+		return errors.New("push metrics: connection refused")
+	}, run.Named("exporter"), run.Critical(false))
+
+	go func() {
+		// The exporter crashed; the consumer keeps running.
+		err := <-g.Errors()
+		fmt.Println("error:", err, "degraded:", g.Degraded())
+		cancel()
+	}()
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("wait error:", err)
+	}
+	// Output:
+	// error: push metrics: connection refused degraded: true
+	// consumer stopped
+}
+
+func ExampleRestart() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var rebalances atomic.Int32
+	g := run.NewGroup(run.WithOnError(func(component string, phase run.Phase, err error) {
+		fmt.Printf("%s %s: %v, restarting\n", component, phase, err)
+	}))
+	g.Go(func(ctx context.Context) error {
+		if rebalances.Add(1) <= 2 {
+			// The broker rebalanced the consumer group.
+			return errors.New("rebalance in progress")
+		}
+		fmt.Println("consuming")
+		cancel()
+		<-ctx.Done()
+		return nil
+	}, run.Named("consumer"), run.Restart(run.RestartPolicy{Backoff: time.Millisecond}))
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("wait error:", err)
+	}
+	fmt.Println("runs:", rebalances.Load())
+	// Output:
+	// consumer run: rebalance in progress, restarting
+	// consumer run: rebalance in progress, restarting
+	// consuming
+	// runs: 3
+}
+
+func ExampleRestartPolicy() {
+	g := run.NewGroup()
+	g.Go(func(ctx context.Context) error {
+		return errors.New("dial broker: connection refused")
+	}, run.Named("consumer"), run.Restart(run.RestartPolicy{
+		Backoff:     time.Millisecond,
+		MaxRestarts: 3,
+		Window:      time.Minute,
+	}))
+
+	err := g.Wait(context.Background())
+	fmt.Println(errors.Is(err, run.ErrRestartLimit))
+	fmt.Println(err)
+	// Output:
+	// true
+	// component "consumer": restart limit exceeded (3 restarts within 1m0s): dial broker: connection refused
+}
+
+func ExampleWithRestartLimit() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// One restart every 100ms across the group, whatever the backoff.
+	g := run.NewGroup(run.WithRestartLimit(1, 100*time.Millisecond))
+
+	began := time.Now()
+	var consumers sync.WaitGroup
+	consumers.Add(2)
+	for _, name := range []string{"orders", "payments"} {
+		var runs atomic.Int32
+		g.Go(func(ctx context.Context) error {
+			if runs.Add(1) == 1 {
+				// Both consumers lose the shared broker at once.
+				return errors.New("broker unavailable")
+			}
+			consumers.Done()
+			<-ctx.Done()
+			return nil
+		}, run.Named(name), run.Restart(run.RestartPolicy{}))
+	}
+
+	go func() {
+		consumers.Wait()
+		fmt.Println("restarts spread out:", time.Since(began) >= 50*time.Millisecond)
+		cancel()
+	}()
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("wait error:", err)
+	}
+	// Output:
+	// restarts spread out: true
+}
+
+type consumer struct{}
+
+func (consumer) Pause(ctx context.Context) error {
+	fmt.Println("consumer: stop fetching")
+	return nil
+}
+
+func (consumer) Resume(ctx context.Context) error {
+	fmt.Println("consumer: fetch again")
+	return nil
+}
+
+func ExampleGroup_Pause() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup()
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}, run.Named("orders"), run.Pauses(consumer{}))
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		go func() {
+			// Quiesce the consumer during an incident, then pick up again.
+			if err := g.Pause(ctx, "orders"); err != nil {
+				fmt.Println("pause error:", err)
+			}
+			fmt.Println(g.States()["orders"])
+			if err := g.Resume(ctx, "orders"); err != nil {
+				fmt.Println("resume error:", err)
+			}
+			fmt.Println(g.States()["orders"])
+			cancel()
+		}()
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("wait error:", err)
+	}
+	// Output:
+	// consumer: stop fetching
+	// paused
+	// consumer: fetch again
+	// running
+}
+
+func ExampleDrains() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup()
+	g.Add(func() error { return nil }, func(ctx context.Context) error {
+		fmt.Println("api: close")
+		return nil
+	}, run.Named("api"), run.Priority(1), run.Drains(run.DrainerFunc(func(ctx context.Context) error {
+		// Runs before the worker below stops, although the api stops last.
+		fmt.Println("api: stop accepting requests")
+		return nil
+	})))
+	g.Add(func() error { return nil }, func(ctx context.Context) error {
+		fmt.Println("worker: close")
+		return nil
+	}, run.Named("worker"))
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("wait error:", err)
+	}
+	// Output:
+	// api: stop accepting requests
+	// worker: close
+	// api: close
+}
+
+func ExampleWithDrainTimeout() {
+	ctx, cancel := context.WithCancel(context.Background())
+	unsubscribed := make(chan struct{})
+	defer close(unsubscribed)
+
+	g := run.NewGroup(run.WithDrainTimeout(20 * time.Millisecond))
+	g.Add(func() error { return nil }, func(ctx context.Context) error {
+		// The stop timeout begins after draining, so the stop still has time.
+		fmt.Println("consumer: close")
+		return nil
+	}, run.Named("consumer"), run.Drains(run.DrainerFunc(func(ctx context.Context) error {
+		// The broker does not acknowledge the unsubscribe in time.
+		<-unsubscribed
+		return nil
+	})))
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+
+	err := g.Wait(ctx)
+	fmt.Println(errors.Is(err, run.ErrDrainContextDeadlineExceeded))
+	// Output:
+	// consumer: close
+	// true
+}
+
+func ExampleForceStop() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup(
+		run.WithStopTimeout(50*time.Millisecond),
+		run.WithForceStopTimeout(time.Second),
+	)
+	g.Add(func() error { return nil }, func(ctx context.Context) error {
+		// A client never finishes its request.
+		<-ctx.Done()
+		return ctx.Err()
+	}, run.Named("api"), run.ForceStop(func(ctx context.Context) error {
+		fmt.Println("api: close connections")
+		return nil
+	}))
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+
+	err := g.Wait(ctx)
+	fmt.Println(errors.Is(err, run.ErrStopContextDeadlineExceeded))
+	// Output:
+	// api: close connections
+	// true
+}
+
+func ExampleForceStopAfter() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup()
+	closed := make(chan struct{})
+	g.Add(func() error { return nil }, func(ctx context.Context) error {
+		// Like http.Server.Shutdown, wait for connections until Close.
+		select {
+		case <-closed:
+			fmt.Println("api: shut down")
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}, run.Named("api"), run.ForceStopAfter(10*time.Millisecond), run.ForceStop(func(ctx context.Context) error {
+		fmt.Println("api: close connections")
+		close(closed)
+		return nil
+	}))
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("wait error:", err)
+	}
+	// Output:
+	// api: close connections
+	// api: shut down
+}
+
+func ExampleGroup_Stop() {
+	g := run.NewGroup()
+	g.Add(func() error { return nil }, func(ctx context.Context) error {
+		fmt.Println("server stopped")
+		return errors.New("flush access log: disk full")
+	})
+
+	done := make(chan error)
+	go func() {
+		done <- g.Wait(context.Background())
+	}()
+	for !g.Ready() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Several triggers, such as an admin endpoint and a signal handler, may
+	// stop the group; they all wait for the same shutdown.
+	results := make(chan error, 2)
+	for range 2 {
+		go func() {
+			results <- g.Stop(context.Background())
+		}()
+	}
+	fmt.Println("stop:", <-results)
+	fmt.Println("stop:", <-results)
+	fmt.Println("wait:", <-done)
+	// Output:
+	// server stopped
+	// stop: flush access log: disk full
+	// stop: flush access log: disk full
+	// wait: flush access log: disk full
+}
+
+func ExampleGroup_Defer() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup(run.WithStopTimeout(10 * time.Millisecond))
+	g.Defer(func() {
+		fmt.Println("flush logs")
+	})
+	g.Add(func() error {
+		g.Defer(func() {
+			fmt.Println("remove temp dir")
+		})
+		return nil
+	}, func(ctx context.Context) error {
+		// Hangs past the stop timeout.
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+
+	err := g.Wait(ctx)
+	fmt.Println(errors.Is(err, run.ErrStopContextDeadlineExceeded))
+	// Output:
+	// remove temp dir
+	// flush logs
+	// true
+}
+
+func ExampleGroup_StopCheck() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var inFlight atomic.Int32
+	g := run.NewGroup()
+	g.Add(func() error {
+		inFlight.Add(1)
+		return nil
+	}, func(ctx context.Context) error {
+		// Bug: returns without waiting for the job in flight.
+		return nil
+	}, run.Named("worker"))
+	g.StopCheck("no jobs in flight", func(ctx context.Context) error {
+		if n := inFlight.Load(); n > 0 {
+			return fmt.Errorf("%d jobs still running", n)
+		}
+		return nil
+	})
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+
+	err := g.Wait(ctx)
+	fmt.Println(errors.Is(err, run.ErrStopCheck))
+	fmt.Println(err)
+	// Output:
+	// true
+	// stop check failed: no jobs in flight: 1 jobs still running
+}
+
+func ExampleWithLeakCheck() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup(run.WithLeakCheck())
+	quit := make(chan struct{})
+	defer close(quit)
+	g.Add(func() error {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return err
+		}
+		l = g.TrackListener(l)
+
+		// Bug: neither the listener nor the accept loop are stopped.
+		go func() {
+			<-quit
+			l.Close()
+		}()
+		return nil
+	}, func(ctx context.Context) error {
+		return nil
+	})
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+
+	err := g.Wait(ctx)
+	var leaks *run.LeakError
+	if errors.As(err, &leaks) {
+		fmt.Println("goroutines:", leaks.Goroutines, "listeners:", len(leaks.Listeners))
+	}
+	// Output:
+	// goroutines: 1 listeners: 1
+}
+
+func ExampleVerify() {
+	g := run.NewGroup(run.WithVerifyTimeout(time.Second))
+	g.Add(func() error {
+		// The pool connects lazily, so starting it always succeeds.
+		return nil
+	}, func(ctx context.Context) error {
+		fmt.Println("pool closed")
+		return nil
+	}, run.Named("db"), run.Verify(func(ctx context.Context) error {
+		return errors.New("ping: password authentication failed")
+	}))
+
+	err := g.Wait(context.Background())
+	fmt.Println(errors.Is(err, run.ErrVerifyFailed))
+	fmt.Println(err)
+	// Output:
+	// pool closed
+	// true
+	// verify failed: ping: password authentication failed
+}
+
+func ExampleWarms() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup(run.WithProgress(func(p run.Progress) {
+		if p.Phase == run.PhaseWarmup {
+			fmt.Println(p)
+		}
+	}))
+	g.Add(func() error { return nil }, func(ctx context.Context) error { return nil },
+		run.Named("catalog"), run.Warms(run.WarmerFunc(func(ctx context.Context) error {
+			// Prime the cache before taking traffic.
+			return nil
+		})))
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			fmt.Println("ready")
+			cancel()
+		}
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("wait error:", err)
+	}
+	// Output:
+	// 1/1 warmed up
+	// ready
+}
+
+func ExampleWarms_classified() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errCold := errors.New("cache cold")
+	g := run.NewGroup(
+		// The transformer maps driver timeouts to an application error...
+		run.WithErrorTransformer(func(component string, err error) error {
+			if strings.Contains(err.Error(), "timeout") {
+				return fmt.Errorf("%w: %v", errCold, err)
+			}
+			return err
+		}),
+		// ...which the classifier sees, as it does on start.
+		run.WithClassifier(func(component string, phase run.Phase, err error) run.Severity {
+			if errors.Is(err, errCold) {
+				return run.SeverityDegraded
+			}
+			return run.SeverityFatal
+		}),
+	)
+	g.Add(func() error { return nil }, func(ctx context.Context) error { return nil },
+		run.Named("catalog"), run.Warms(run.WarmerFunc(func(ctx context.Context) error {
+			return errors.New("redis: i/o timeout")
+		})))
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			fmt.Println("ready, degraded:", g.Degraded())
+			cancel()
+		}
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("wait error:", err)
+	}
+	// Output:
+	// ready, degraded: true
+}
+
+func ExampleComponentContext() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	type cache struct{ refreshed chan struct{} }
+
+	g := run.NewGroup()
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+	run.Provide(g, func(ctx context.Context) (*cache, run.Stop, error) {
+		c := &cache{refreshed: make(chan struct{})}
+		// The refresher outlives the start call and ends when shutdown
+		// begins, before the stop function is called.
+		go func(ctx context.Context) {
+			defer close(c.refreshed)
+			<-ctx.Done()
+			fmt.Println("refresher stopped")
+		}(run.ComponentContext(ctx))
+		return c, func(context.Context) error {
+			<-c.refreshed
+			fmt.Println("cache closed")
+			return nil
+		}, nil
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// refresher stopped
+	// cache closed
+}
+
+func ExampleGroup_StopSubset() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup(run.WithConcurrency(1))
+	stop := func(ctx context.Context) error {
+		info, _ := run.ComponentFromContext(ctx)
+		fmt.Println("stopped", info.Name)
+		return nil
+	}
+	g.Add(func() error { return nil }, stop, run.Named("http"))
+	g.Add(func() error { return nil }, stop, run.Named("orders-consumer"), run.Tags("consumer"))
+	g.Add(func() error { return nil }, stop, run.Named("billing-consumer"), run.Tags("consumer"))
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		// Pause consumption during a backfill while http keeps serving.
+		if err := g.StopSubset(ctx, run.Tagged("consumer")); err != nil {
+			fmt.Println("error:", err)
+		}
+		fmt.Println(g.States()["http"])
+		cancel()
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// stopped billing-consumer
+	// stopped orders-consumer
+	// running
+	// stopped http
+}
+
+func ExampleGroup_RollingRestart() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup(run.WithConcurrency(1))
+	for _, name := range []string{"worker-1", "worker-2", "worker-3"} {
+		g.Add(func() error {
+			return nil
+		}, func(context.Context) error {
+			fmt.Println("stopped", name)
+			return nil
+		}, run.Named(name), run.Tags("worker"))
+	}
+	g.Add(func() error { return nil }, func(context.Context) error { return nil }, run.Named("http"))
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		defer cancel()
+		err := g.RollingRestart(ctx, run.Tagged("worker"), run.RollingPolicy{
+			Batch: 2,
+			Check: func(context.Context) error {
+				fmt.Println("healthy")
+				return nil
+			},
+		})
+		if err != nil {
+			fmt.Println("error:", err)
+		}
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// stopped worker-1
+	// stopped worker-2
+	// healthy
+	// stopped worker-3
+	// healthy
+	// stopped worker-3
+	// stopped worker-2
+	// stopped worker-1
+}
+
+func ExampleGroup_Swap() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	backend := func(version string, err error) (run.Start, run.Stop) {
+		return func() error {
+				fmt.Println("starting", version)
+				return err
+			}, func(context.Context) error {
+				fmt.Println("stopping", version)
+				return nil
+			}
+	}
+
+	g := run.NewGroup()
+	start, stop := backend("v1", nil)
+	g.Add(start, stop, run.Named("plugin"))
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		defer cancel()
+
+		// A broken replacement leaves v1 serving.
+		start, stop := backend("v2", errors.New("bad config"))
+		fmt.Println(g.Swap(ctx, "plugin", start, stop))
+
+		start, stop = backend("v3", nil)
+		fmt.Println(g.Swap(ctx, "plugin", start, stop))
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// starting v1
+	// starting v2
+	// stopping v2
+	// bad config
+	// starting v3
+	// stopping v1
+	// <nil>
+	// stopping v3
+}
+
+func ExampleGroup_Swap_transformer() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Errors are reported elsewhere, so the transformer drops them all.
+	g := run.NewGroup(run.WithErrorTransformer(func(component string, err error) error {
+		return nil
+	}))
+	g.Add(func() error { return nil }, func(context.Context) error { return nil }, run.Named("plugin"))
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		defer cancel()
+
+		// A failed swap is never reported as a success.
+		err := g.Swap(ctx, "plugin", func() error {
+			return errors.New("bad config")
+		}, func(context.Context) error { return nil })
+		fmt.Println(err)
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// bad config
+}
+
+func ExampleCanary() {
+	g := run.NewGroup(run.WithConcurrency(1))
+	for _, tenant := range []string{"acme", "globex", "initech"} {
+		g.Add(func() error {
+			fmt.Println("starting", tenant)
+			return nil
+		}, func(context.Context) error {
+			return nil
+		}, run.Named(tenant), run.Canary("tenant"), run.Verify(func(context.Context) error {
+			return errors.New("schema version mismatch")
+		}))
+	}
+
+	fmt.Println(g.Wait(context.Background()))
+	// Output:
+	// starting acme
+	// verify failed: schema version mismatch
+}
+
+func ExampleWithQuorum() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup(run.WithQuorum(2, run.Tagged("connector")))
+	for _, zone := range []string{"a", "b", "c"} {
+		g.Add(func() error {
+			if zone == "c" {
+				return errors.New("zone c unreachable")
+			}
+			return nil
+		}, func(context.Context) error {
+			return nil
+		}, run.Named("connector-"+zone), run.Tags("connector"))
+	}
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			fmt.Println("ready, degraded:", g.Degraded())
+			cancel()
+		}
+	})
+
+	fmt.Println(g.Wait(ctx))
+	// Output:
+	// ready, degraded: true
+	// <nil>
+}
+
+// labelTimeouts gives components labeled tier=batch more time to stop.
+type labelTimeouts struct{}
+
+func (labelTimeouts) StartTimeoutFor(run.ComponentInfo) time.Duration {
+	return 0
+}
+
+func (labelTimeouts) StopTimeoutFor(c run.ComponentInfo) time.Duration {
+	if c.Labels["tier"] == "batch" {
+		return time.Minute
+	}
+	return 10 * time.Millisecond
+}
+
+func ExampleWithTimeoutPolicy() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup(run.WithTimeoutPolicy(labelTimeouts{}))
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+	g.Add(func() error { return nil }, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, run.Named("http"))
+
+	fmt.Println(g.Wait(ctx))
+	// Output:
+	// stop context deadline exceeded: component timeout 10ms exceeded
+}
+
+func ExampleWithAdaptiveTimeouts() {
+	// The history of earlier runs, as persisted with json.Marshal: the
+	// database usually stops within 100ms.
+	h := run.NewHistory(0)
+	if err := json.Unmarshal([]byte(`{"durations": {"db": {"stop": [
+		100000000, 90000000, 100000000, 80000000, 100000000
+	]}}}`), h); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g := run.NewGroup(run.WithAdaptiveTimeouts(h, run.AdaptivePolicy{Margin: 50 * time.Millisecond}))
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+	g.Add(func() error { return nil }, func(ctx context.Context) error {
+		// A regression: the stop hangs.
+		<-ctx.Done()
+		return ctx.Err()
+	}, run.Named("db"))
+
+	fmt.Println(g.Wait(ctx))
+	// Output:
+	// stop context deadline exceeded: component timeout 150ms exceeded
+}
+
+func ExampleWithStats() {
+	dir, err := os.MkdirTemp("", "stats")
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	store := run.FileStats(filepath.Join(dir, "stats.json"))
+
+	// Two boots of the same binary, in which the database fails to start.
+	for range 2 {
+		g := run.NewGroup(run.WithStats(store))
+		g.Add(func() error {
+			return errors.New("connection refused")
+		}, func(context.Context) error {
+			return nil
+		}, run.Named("db"))
+		_ = g.Wait(context.Background())
+
+		stats := g.Stats()
+		fmt.Println("runs:", stats.Runs, "consecutive start failures:", stats.Components["db"].StartFailures)
+	}
+	// Output:
+	// runs: 1 consecutive start failures: 1
+	// runs: 2 consecutive start failures: 2
+}
+
+func ExampleWithBudgetAlerts() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup(
+		run.WithStopTimeout(200*time.Millisecond),
+		run.WithBudgetAlerts(func(a run.BudgetAlert) {
+			fmt.Printf("%s phase at %.0f%%, waiting on %v\n", a.Phase, a.Fraction*100, a.Pending)
+		}, 0.25, 1),
+	)
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+	g.Add(func() error { return nil }, func(context.Context) error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	}, run.Named("kafka-consumer"))
+
+	fmt.Println(g.Wait(ctx))
+	// Output:
+	// stop phase at 25%, waiting on [kafka-consumer]
+	// <nil>
+}
+
+func ExampleWithAlerts() {
+	dir, err := os.MkdirTemp("", "alerts")
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	store := run.FileStats(filepath.Join(dir, "stats.json"))
+
+	for boot := range 3 {
+		fmt.Println("boot", boot+1)
+		g := run.NewGroup(run.WithStats(store), run.WithAlerts(func(a run.Alert) {
+			fmt.Println("alert:", a)
+		}, 2))
+		g.Add(func() error {
+			return errors.New("connection refused")
+		}, func(context.Context) error {
+			return nil
+		}, run.Named("db"))
+		_ = g.Wait(context.Background())
+	}
+	// Output:
+	// boot 1
+	// boot 2
+	// alert: component "db" start failing on 2 consecutive runs
+	// boot 3
+	// alert: component "db" start failing on 3 consecutive runs
+}
+
+func ExampleGroup_HealthHandler() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup()
+	g.Add(func() error { return nil }, func(context.Context) error { return nil }, run.Named("http"))
+	g.Add(func() error {
+		return errors.New("recommendations unavailable")
+	}, func(context.Context) error {
+		return nil
+	}, run.Named("recommendations"), run.Critical(false))
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		rec := httptest.NewRecorder()
+		g.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		fmt.Print(rec.Code, " ", rec.Body.String())
+		cancel()
+	})
+
+	_ = g.Wait(ctx)
+	fmt.Println(g.Health())
+	// Output:
+	// 200 degraded
+	// down
+}
+
+func ExampleWithHealthPolling() {
+	var polls atomic.Int32
+
+	g := run.NewGroup(run.WithHealthPolling(run.HealthPolicy{
+		Interval:         10 * time.Millisecond,
+		FailureThreshold: 2,
+		OnFailure:        run.ProbeShutdown,
+	}))
+	g.Add(func() error { return nil }, func(context.Context) error { return nil },
+		run.Named("db"),
+		run.Probe(func(ctx context.Context) error {
+			if polls.Add(1) > 2 {
+				return errors.New("connection pool exhausted")
+			}
+			return nil
+		}))
+
+	fmt.Println(g.Wait(context.Background()))
+	fmt.Println(g.ProbeResults()["db"].Failures)
+	// Output:
+	// probe failed: connection pool exhausted
+	// 2
+}
+
+func ExampleGroup_DashboardHandler() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup()
+	g.Add(func() error { return nil }, func(context.Context) error { return nil }, run.Named("worker"))
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		defer cancel()
+		dashboard := g.DashboardHandler(run.DashboardOptions{Actions: true})
+
+		// The buttons post the token embedded in the page.
+		form := strings.NewReader("token=" + dashboardToken(dashboard) + "&component=worker&action=restart")
+		req := httptest.NewRequest(http.MethodPost, "/debug/components", form)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		dashboard.ServeHTTP(rec, req)
+		fmt.Println(rec.Code, rec.Header().Get("Location"))
+
+		rec = httptest.NewRecorder()
+		dashboard.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/components", nil))
+		fmt.Println(rec.Code, strings.Contains(rec.Body.String(), `<td class="running">running</td>`))
+		fmt.Println("restarts:", g.Status()[0].Restarts)
+	})
+
+	_ = g.Wait(ctx)
+	// Output:
+	// 303 /debug/components
+	// 200 true
+	// restarts: 1
+}
+
+func ExampleGroup_DashboardHandler_forgery() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup()
+	g.Add(func() error { return nil }, func(context.Context) error { return nil }, run.Named("worker"))
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		defer cancel()
+		dashboard := g.DashboardHandler(run.DashboardOptions{Actions: true})
+		post := func(form, origin string) {
+			req := httptest.NewRequest(http.MethodPost, "http://admin.internal/debug/components", strings.NewReader(form))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if origin != "" {
+				req.Header.Set("Origin", origin)
+			}
+			rec := httptest.NewRecorder()
+			dashboard.ServeHTTP(rec, req)
+			fmt.Print(rec.Code, " ", rec.Body.String())
+		}
+
+		// A form on another site, submitted through an operator's browser,
+		// is rejected by its origin, and without the page's token.
+		post("token="+dashboardToken(dashboard)+"&component=worker&action=stop", "https://evil.example")
+		post("component=worker&action=stop", "")
+		fmt.Println(g.Status()[0].State)
+	})
+
+	_ = g.Wait(ctx)
+	// Output:
+	// 403 cross-origin request
+	// 403 invalid token
+	// running
+}
+
+// dashboardToken returns the token embedded in the page served by dashboard.
+func dashboardToken(dashboard http.Handler) string {
+	rec := httptest.NewRecorder()
+	dashboard.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/components", nil))
+	_, after, _ := strings.Cut(rec.Body.String(), `name="token" value="`)
+	token, _, _ := strings.Cut(after, `"`)
+	return token
+}
+
+func ExampleAuthorizerFunc() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Anyone may look; only operators may restart or stop.
+	operators := run.AuthorizerFunc(func(ctx context.Context, cmd run.Command) error {
+		if cmd.Name == run.CommandStatus || cmd.Request.Header.Get("X-Role") == "operator" {
+			return nil
+		}
+		return fmt.Errorf("%s needs the operator role", cmd.Name)
+	})
+
+	g := run.NewGroup()
+	g.Add(func() error { return nil }, func(context.Context) error { return nil }, run.Named("worker"))
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		defer cancel()
+		dashboard := g.DashboardHandler(run.DashboardOptions{Actions: true, Authorizer: operators})
+
+		token := dashboardToken(dashboard)
+		for _, role := range []string{"viewer", "operator"} {
+			form := strings.NewReader("token=" + token + "&component=worker&action=restart")
+			req := httptest.NewRequest(http.MethodPost, "/debug/components", form)
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("X-Role", role)
+			rec := httptest.NewRecorder()
+			dashboard.ServeHTTP(rec, req)
+			fmt.Print(role, ": ", rec.Code, " ", rec.Body.String())
+			if rec.Code == http.StatusSeeOther {
+				fmt.Println()
+			}
+		}
+	})
+
+	_ = g.Wait(ctx)
+	// Output:
+	// viewer: 403 unauthorized: restart needs the operator role
+	// operator: 303
+}
+
+func ExampleHealthcheck() {
+	dir, _ := os.MkdirTemp("", "health")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "health")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g := run.NewGroup(run.WithHealthFile(path, 10*time.Millisecond))
+	g.Add(func() error { return nil }, func(context.Context) error { return nil })
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		go func() {
+			defer cancel()
+			// The file is refreshed every 10ms; what a HEALTHCHECK running
+			// HealthcheckMain would see once it has been:
+			for run.Healthcheck(path) != nil {
+				time.Sleep(5 * time.Millisecond)
+			}
+			fmt.Println("healthy")
+		}()
+	})
+
+	_ = g.Wait(ctx)
+	err := run.Healthcheck(path)
+	fmt.Println(errors.Is(err, run.ErrUnhealthy), errors.Is(err, os.ErrNotExist))
+	// Output:
+	// healthy
+	// true true
+}
+
+func ExampleWithHealthFile_leakCheck() {
+	dir, _ := os.MkdirTemp("", "health")
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g := run.NewGroup(
+		run.WithHealthFile(filepath.Join(dir, "health"), 10*time.Millisecond),
+		run.WithLeakCheck(),
+	)
+	g.Add(func() error { return nil }, func(context.Context) error { return nil })
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+
+	// The health file writer is not reported as a leak.
+	fmt.Println(g.Wait(ctx))
+	// Output:
+	// <nil>
+}
+
+func ExampleGroup_Start() {
+	g := run.NewGroup()
+	g.Add(func() error {
+		fmt.Println("db started")
+		return nil
+	}, func(ctx context.Context) error {
+		fmt.Println("db stopped")
+		return nil
+	})
+
+	// Hooks of a framework that owns the main loop.
+	onStart := func(ctx context.Context) error { return g.Start(ctx) }
+	onStop := func(ctx context.Context) error { return g.Stop(ctx) }
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	err := onStart(ctx)
+	cancel() // The start context ends with the hook; the group keeps running.
+	fmt.Println("start:", err, "ready:", g.Ready())
+
+	fmt.Println("stop:", onStop(context.Background()))
+	fmt.Println("wait:", g.Wait(context.Background()))
+	// Output:
+	// db started
+	// start: <nil> ready: true
+	// db stopped
+	// stop: <nil>
+	// wait: <nil>
+}
+
+func ExampleGroup_Start_afterWait() {
+	g := run.NewGroup()
+	g.Add(func() error {
+		fmt.Println("db started")
+		return nil
+	}, func(ctx context.Context) error {
+		fmt.Println("db stopped")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			// Wait already runs the group; nothing starts twice.
+			fmt.Println("start:", g.Start(context.Background()))
+			cancel()
+		}
+	})
+	fmt.Println("wait:", g.Wait(ctx))
+	fmt.Println("wait again:", g.Wait(context.Background()))
+	// Output:
+	// db started
+	// start: group already started
+	// db stopped
+	// wait: <nil>
+	// wait again: group already started
+}
+
+func ExampleRun() {
+	g := run.NewGroup()
+	g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		fmt.Println("flushed")
+		return nil
+	})
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			// What the process manager does to stop the service.
+			p, _ := os.FindProcess(os.Getpid())
+			_ = p.Signal(os.Interrupt)
+		}
+	})
+
+	err := run.Run(context.Background(), g)
+	fmt.Println("clean exit:", err == nil)
+	// Output:
+	// flushed
+	// clean exit: true
+}
+
+func ExampleGroup_AddComponent() {
+	g := run.NewGroup()
+	g.AddComponent(run.Component{
+		Name:  "config",
+		Start: func() error { fmt.Println("config loaded"); return nil },
+	})
+
+	attempts := 0
+	g.AddComponent(run.Component{
+		Name: "kafka",
+		Start: func() error {
+			attempts++
+			if attempts == 1 {
+				return errors.New("broker not reachable")
+			}
+			fmt.Println("kafka connected after", attempts, "attempts")
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			fmt.Println("kafka closed")
+			return nil
+		},
+		Timeout:   10 * time.Second,
+		DependsOn: []string{"config"},
+		Retry:     3,
+	})
+
+	if err := g.Start(context.Background()); err != nil {
+		fmt.Println("start:", err)
+		return
+	}
+	fmt.Println("stop:", g.Stop(context.Background()))
+	// Output:
+	// config loaded
+	// kafka connected after 2 attempts
+	// kafka closed
+	// stop: <nil>
+}
+
+func ExampleGroup_AddAll() {
+	tenants := []string{"acme", "globex", "initech"}
+
+	components := make([]run.Component, 0, len(tenants))
+	for _, tenant := range tenants {
+		components = append(components, run.Component{
+			Name: "pipeline-" + tenant,
+			Tags: []string{"pipeline"},
+		})
+	}
+
+	g := run.NewGroup()
+	for _, h := range g.AddAll(components...) {
+		fmt.Println(h.Name())
+	}
+	// Output:
+	// pipeline-acme
+	// pipeline-globex
+	// pipeline-initech
+}
+
+func ExampleStopTimeout() {
+	g := run.NewGroup(run.WithStopTimeout(5 * time.Second))
+	g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		<-ctx.Done() // A stop call that hangs.
+		return ctx.Err()
+	}, run.Named("db"), run.Critical(false), run.StopTimeout(50*time.Millisecond))
+
+	if err := g.Start(context.Background()); err != nil {
+		fmt.Println("start:", err)
+		return
+	}
+	err := g.Stop(context.Background())
+	fmt.Println(errors.Is(err, run.ErrStopContextDeadlineExceeded))
+	fmt.Println(g.States()["db"])
+	// Output:
+	// true
+	// timed out
+}
+
+func ExampleNewComponent() {
+	g := run.NewGroup()
+	g.AddComponent(run.Component{Name: "config"})
+	g.AddComponent(run.NewComponent("kafka").
+		Start(func() error {
+			fmt.Println("kafka connected")
+			return nil
+		}).
+		Stop(func(ctx context.Context) error {
+			fmt.Println("kafka closed")
+			return nil
+		}).
+		DependsOn("config").
+		Retry(3).
+		Label("team", "data").
+		Build())
+
+	if err := g.Start(context.Background()); err != nil {
+		fmt.Println("start:", err)
+		return
+	}
+	fmt.Println(g.Status()[1].Labels["team"])
+	fmt.Println("stop:", g.Stop(context.Background()))
+	// Output:
+	// kafka connected
+	// data
+	// kafka closed
+	// stop: <nil>
+}
+
+func ExampleGroup_Stop_trace() {
+	type traceKey struct{}
+
+	g := run.NewGroup()
+	g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		fmt.Println("flushing for", ctx.Value(traceKey{}))
+		return nil
+	})
+
+	if err := g.Start(context.Background()); err != nil {
+		fmt.Println("start:", err)
+		return
+	}
+
+	// An admin request carrying a trace triggers shutdown.
+	req := context.WithValue(context.Background(), traceKey{}, "trace-4bf92f35")
+	fmt.Println("stop:", g.Stop(req))
+	// Output:
+	// flushing for trace-4bf92f35
+	// stop: <nil>
+}
+
+func ExampleWithTransitionLog() {
+	// Drop times and durations from the output so that it is stable.
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch a.Key {
+			case slog.TimeKey, "elapsed", "duration":
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	g := run.NewGroup(run.WithLogger(logger), run.WithTransitionLog(slog.LevelInfo))
+	g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("db"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+	_ = g.Wait(ctx)
+	// Output:
+	// level=INFO msg="component starting" component=db phase=start state=starting previous=registered
+	// level=INFO msg="component running" component=db phase=start state=running previous=starting outcome=ok
+	// level=INFO msg="component start succeeded" component=db phase=start
+	// level=INFO msg="group ready"
+	// level=INFO msg="group stopping"
+	// level=INFO msg="component stopping" component=db phase=stop state=stopping previous=running
+	// level=INFO msg="component stopped" component=db phase=stop state=stopped previous=stopping outcome=ok
+	// level=INFO msg="component stop succeeded" component=db phase=stop
+}
+
+func ExampleWithStopClasses_dependencies() {
+	noop := func() error { return nil }
+	stop := func(ctx context.Context) error { return nil }
+
+	g := run.NewGroup(run.WithStopClasses("listeners", "stores"))
+	g.Add(noop, stop, run.Named("postgres"), run.StopClass("stores"))
+	g.Add(noop, stop, run.Named("redis"), run.StopClass("stores"))
+	g.Add(noop, stop, run.Named("cache"), run.StopClass("stores"), run.DependsOn("postgres", "redis"))
+	g.Add(noop, stop, run.Named("http"), run.StopClass("listeners"), run.DependsOn("cache"))
+
+	plan, err := g.Plan()
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Print(plan)
+	// Output:
+	// start (timeout 15s):
+	//   1: postgres, redis
+	//   2: cache
+	//   3: http
+	// stop (timeout 15s):
+	//   1: http
+	//   2: cache
+	//   3: redis, postgres
+}
+
+func ExampleWithListener() {
+	g := run.NewGroup(run.WithListener("http", "tcp", "127.0.0.1:0"))
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "hello")
+	})}
+	var addr string
+	g.Add(func() error {
+		l, err := g.Listener("http")
+		if err != nil {
+			return err
+		}
+		addr = l.Addr().String()
+		go srv.Serve(l)
+		return nil
+	}, srv.Shutdown, run.Named("http"))
+
+	if err := g.Start(context.Background()); err != nil {
+		fmt.Println("start:", err)
+		return
+	}
+	resp, err := http.Get("http://" + addr)
+	if err != nil {
+		fmt.Println("get:", err)
+		return
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	fmt.Print(string(body))
+
+	fmt.Println("stop:", g.Stop(context.Background()))
+	_, err = g.Listener("http")
+	fmt.Println(errors.Is(err, run.ErrNotListening))
+	// Output:
+	// hello
+	// stop: <nil>
+	// true
+}
+
+func ExampleWithListener_duplicate() {
+	g := run.NewGroup(
+		run.WithListener("http", "tcp", "127.0.0.1:0"),
+		run.WithListener("http", "tcp", "127.0.0.1:0"),
+	)
+	g.Add(func() error {
+		fmt.Println("never started")
+		return nil
+	}, func(ctx context.Context) error {
+		return nil
+	})
+
+	err := g.Wait(context.Background())
+	fmt.Println(err)
+	fmt.Println(errors.Is(err, run.ErrDuplicateListener))
+	// Output:
+	// listener "http": duplicate listener name
+	// true
+}
+
+func ExampleWithPortCheck() {
+	// Another process holds the metrics port.
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Println("listen:", err)
+		return
+	}
+	defer taken.Close()
+
+	g := run.NewGroup(
+		run.WithListener("http", "tcp", "127.0.0.1:0"),
+		run.WithPortCheck("metrics", "tcp", taken.Addr().String()),
+		run.WithPortCheck("admin", "tcp", taken.Addr().String()),
+	)
+	g.Add(func() error {
+		fmt.Println("never started")
+		return nil
+	}, func(ctx context.Context) error {
+		return nil
+	})
+
+	err = g.CheckPorts(context.Background())
+	fmt.Println(errors.Is(err, run.ErrAddressInUse))
+
+	var addrErr *run.AddressError
+	if errors.As(g.Wait(context.Background()), &addrErr) {
+		for _, c := range addrErr.Conflicts {
+			fmt.Println(c.Name, errors.Is(c.Err, syscall.EADDRINUSE))
+		}
+	}
+	// Output:
+	// true
+	// metrics true
+	// admin true
+}
+
+func ExampleAddressError() {
+	g := run.NewGroup(run.WithPortCheck("metrics", "tcp", "127.0.0.1:70000"))
+
+	// The port is not taken, but no address can be bound with it.
+	err := g.CheckPorts(context.Background())
+	fmt.Println(err)
+	fmt.Println(errors.Is(err, run.ErrAddressInUse))
+	// Output:
+	// cannot bind: "metrics" (tcp 127.0.0.1:70000): listen tcp: address 70000: invalid port
+	// false
+}
+
+func ExampleHandle_Stop_starting() {
+	g := run.NewGroup()
+	connecting := make(chan struct{})
+	connect := make(chan struct{})
+	h := g.Add(func() error {
+		close(connecting)
+		<-connect // Slow handshake.
+		fmt.Println("connected")
+		return nil
+	}, func(ctx context.Context) error {
+		fmt.Println("disconnected")
+		return nil
+	}, run.Named("db"))
+
+	go func() { _ = g.Wait(context.Background()) }()
+	defer g.Stop(context.Background())
+
+	<-connecting
+	stopped := make(chan error)
+	go func() { stopped <- h.Stop(context.Background()) }()
+
+	// The stop waits for the start that is still running.
+	time.Sleep(10 * time.Millisecond)
+	close(connect)
+	fmt.Println(<-stopped, h.State())
+	// Output:
+	// connected
+	// disconnected
+	// <nil> stopped
+}
+
+func ExampleHandle_Done_failedBeforeStart() {
+	g := run.NewGroup()
+	noop := func(context.Context) error { return nil }
+	api := g.Add(func() error { return nil }, noop, run.Named("api"), run.DependsOn("db"))
+	g.Add(func() error { return nil }, noop, run.Named("db"), run.DependsOn("api"))
+
+	err := g.Wait(context.Background())
+	fmt.Println(errors.Is(err, run.ErrDependencyCycle))
+
+	// Nothing started, and waiting on the component returns right away.
+	<-api.Done()
+	fmt.Println(api.State())
+	// Output:
+	// true
+	// stopped
+}
+
+func ExampleHandle_Remove() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g := run.NewGroup()
+	pipeline := func(name string) *run.Handle {
+		return g.Add(func() error {
+			return nil
+		}, func(ctx context.Context) error {
+			fmt.Println(name, "closed")
+			return nil
+		}, run.Named(name))
+	}
+	conn1 := pipeline("conn-1")
+	pipeline("conn-2")
+
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+
+		// The client of conn-1 disconnected.
+		if err := conn1.Remove(ctx); err != nil {
+			fmt.Println("remove:", err)
+		}
+		for _, s := range g.Status() {
+			fmt.Println(s.Name, s.State)
+		}
+		cancel()
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// conn-1 closed
+	// conn-2 running
+	// conn-2 closed
+}
@@ -0,0 +1,73 @@
+package run
+
+import (
+	"flag"
+	"strings"
+)
+
+// Toggles selects components from command-line flags, so operators can
+// switch components on and off at launch without code changes. Entries match
+// component names as well as tags.
+type Toggles struct {
+	// Only lists the components to run, together with their dependencies.
+	// Empty means all components.
+	Only ListFlag
+
+	// Disable lists the components to skip, together with everything that
+	// depends on them.
+	Disable ListFlag
+}
+
+// BindFlags registers the -only and -disable flags on fs and returns the
+// Toggles they fill in, for example:
+//
+//	-only=api -disable=tracing,profiler
+//
+// Pass the result of Toggles.Option to NewGroup after fs has been parsed.
+// For pflag, register the Toggles fields directly, since ListFlag also
+// implements pflag.Value.
+func BindFlags(fs *flag.FlagSet) *Toggles {
+	t := &Toggles{}
+	fs.Var(&t.Only, "only", "comma-separated component names or tags to run, with their dependencies")
+	fs.Var(&t.Disable, "disable", "comma-separated component names or tags to skip, with their dependents")
+	return t
+}
+
+// Option returns an Option applying the toggles to a group.
+func (t *Toggles) Option() Option {
+	return optionFunc(func(o *options) {
+		if len(t.Only) > 0 {
+			o.selector = AnyOf(Names(t.Only...), Tagged(t.Only...))
+		}
+		if len(t.Disable) > 0 {
+			o.disabled = AnyOf(Names(t.Disable...), Tagged(t.Disable...))
+		}
+	})
+}
+
+// ListFlag is a comma-separated list flag value. Repeating the flag appends
+// to the list. It implements flag.Value and pflag.Value.
+type ListFlag []string
+
+// String implements flag.Value.
+func (l *ListFlag) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+// Set implements flag.Value.
+func (l *ListFlag) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
+
+// Type implements pflag.Value.
+func (l *ListFlag) Type() string {
+	return "strings"
+}
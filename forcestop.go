@@ -0,0 +1,111 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultForceStopTimeout is the default duration of the forceful stop phase.
+// It can be customized using the WithForceStopTimeout option.
+const DefaultForceStopTimeout = 5 * time.Second
+
+// ErrForceStopContextDeadlineExceeded is returned when the forceful stop phase exceeds its timeout.
+var ErrForceStopContextDeadlineExceeded = errors.New("force stop context deadline exceeded")
+
+// ForceStop returns a ComponentOption that registers a forceful stop
+// function, such as http.Server.Close next to Shutdown as the graceful stop
+// function. It is called in the forceful stop phase when the graceful stop
+// function did not return nil within the stop timeout, so the component's
+// resources are released even when the graceful stop hangs.
+func ForceStop(stop Stop) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.forceStop = stop
+	})
+}
+
+// ForceStopAfter returns a ComponentOption that escalates the component's
+// own stop: if its graceful stop function has not returned d after it was
+// called, the force stop function registered with ForceStop is called right
+// away, within the remaining stop timeout, instead of in the forceful stop
+// phase after every other component. The graceful stop function is still
+// waited for, and the errors of both calls are returned joined.
+//
+// Zero, the default, waits for the graceful stop until the stop timeout.
+func ForceStopAfter(d time.Duration) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.forceAfter = d
+	})
+}
+
+// callStop calls stop for c, escalating to c's force stop function when it
+// has not returned by c's escalation deadline.
+func (g *Group) callStop(ctx context.Context, c *component, stop Stop) error {
+	if c.forceStop == nil || c.forceAfter <= 0 {
+		return stop(ctx)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- stop(ctx)
+	}()
+
+	timer := time.NewTimer(c.forceAfter)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+	}
+
+	g.mu.Lock()
+	c.forced = true
+	g.mu.Unlock()
+
+	// The joined error is transformed as a stop error; only the recorded
+	// force stop error is transformed here.
+	began := time.Now()
+	forceErr := c.forceStop(ctx)
+	g.record(c, PhaseForceStop, began, g.transform(c, forceErr))
+
+	select {
+	case err := <-done:
+		return errors.Join(err, forceErr)
+	case <-ctx.Done():
+		return errors.Join(ctx.Err(), forceErr)
+	}
+}
+
+// forceStop runs the forceful stop phase: it calls the force stop functions
+// of all started components that did not stop cleanly and were not forced
+// already concurrently on the worker pool, within the force stop timeout,
+// and returns their joined errors.
+func (g *Group) forceStop(s *schedule) error {
+	g.mu.Lock()
+	var forced []*component
+	for _, wave := range g.stopOrder(s, func(c *component) bool {
+		return c.attempted && c.forceStop != nil && !c.forced && !c.stoppedCleanly()
+	}) {
+		forced = append(forced, wave...)
+	}
+	g.mu.Unlock()
+	if len(forced) == 0 {
+		return nil
+	}
+
+	g.logGroup("group stopping forcefully")
+	return g.callAll(g.stopContext(), forced, g.opts.forceStopTimeout, PhaseForceStop, ErrForceStopContextDeadlineExceeded,
+		func(ctx context.Context, c *component) error {
+			return c.forceStop(ctx)
+		}, nil)
+}
+
+// stoppedCleanly reports whether c's graceful stop function returned nil in
+// time. The caller must hold g.mu.
+func (c *component) stoppedCleanly() bool {
+	switch c.loadState() {
+	case StateStopped, StateFailed:
+		return c.stopErr == nil
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package run
+
+import "context"
+
+// Gate decides whether a component may start, for example by consulting a
+// feature-flag service, so components can be rolled out and rolled back
+// without a deploy. Components a gate denies are skipped as if disabled,
+// together with everything that depends on them, until Group.Reload finds
+// them allowed.
+type Gate interface {
+	// Allow reports whether the component may start. It is called for every
+	// component before the start phase begins, with the context passed to
+	// Wait, and again by Group.Reload. It must be safe for concurrent use.
+	Allow(ctx context.Context, c ComponentInfo) bool
+}
+
+// GateFunc adapts an ordinary function to the Gate interface.
+type GateFunc func(ctx context.Context, c ComponentInfo) bool
+
+// Allow calls f(ctx, c).
+func (f GateFunc) Allow(ctx context.Context, c ComponentInfo) bool {
+	return f(ctx, c)
+}
+
+// denied returns the components the configured gate does not allow. The gate
+// is consulted without holding g.mu, so it may use the group.
+func (g *Group) denied(ctx context.Context) map[*component]bool {
+	if g.opts.gate == nil {
+		return nil
+	}
+
+	denied := make(map[*component]bool)
+	for _, c := range g.registry.list() {
+		if !g.opts.gate.Allow(ctx, c.info()) {
+			denied[c] = true
+		}
+	}
+	return denied
+}
@@ -1,5 +1,3 @@
 module github.com/not-for-prod/run
 
 go 1.24.3
-
-require golang.org/x/sync v0.14.0 // indirect
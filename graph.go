@@ -0,0 +1,419 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrMissingProvider is returned when a component requires a value that no component provides.
+	ErrMissingProvider = errors.New("missing provider")
+
+	// ErrAmbiguousProvider is returned when a required type is provided by several components
+	// and the requirement does not name one of them.
+	ErrAmbiguousProvider = errors.New("ambiguous provider")
+
+	// ErrDuplicateName is returned when two components share the same name.
+	ErrDuplicateName = errors.New("duplicate component name")
+
+	// ErrUnknownComponent is returned when a component depends on a name that is not registered.
+	ErrUnknownComponent = errors.New("unknown component")
+
+	// ErrPriorityInversion is returned when a component depends on a component of lower priority.
+	ErrPriorityInversion = errors.New("priority inversion")
+
+	// ErrUnknownStopClass is returned when a component uses a stop class not declared with WithStopClasses.
+	ErrUnknownStopClass = errors.New("unknown stop class")
+
+	// ErrDependencyCycle is returned, wrapped in a CycleError, when dependencies form a cycle.
+	ErrDependencyCycle = errors.New("dependency cycle")
+)
+
+// CycleError reports components whose dependencies form a cycle. Wait
+// returns it before any start function runs.
+type CycleError struct {
+	// Path lists the component names along the cycle; each component depends
+	// on the next, and the last entry repeats the first.
+	Path []string
+}
+
+// Error implements the error interface.
+func (e *CycleError) Error() string {
+	return ErrDependencyCycle.Error() + ": " + strings.Join(e.Path, " -> ")
+}
+
+// Unwrap returns ErrDependencyCycle so callers can match with errors.Is.
+func (e *CycleError) Unwrap() error {
+	return ErrDependencyCycle
+}
+
+// provider returns the component among cs that satisfies d.
+func (g *Group) provider(cs []*component, d dependency) (*component, error) {
+	var found *component
+	for _, c := range cs {
+		if c.provides != d.typ || (d.name != "" && c.name != d.name) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("%s: %w", d, ErrAmbiguousProvider)
+		}
+		found = c
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("%s: %w", d, ErrMissingProvider)
+	}
+	return found, nil
+}
+
+// schedule is the order in which a group's components are started and
+// stopped.
+type schedule struct {
+	// waves holds the enabled components in start order. Components within a
+	// wave start concurrently; a wave starts only after the previous one has
+	// started successfully. Stop walks the waves in reverse.
+	waves [][]*component
+
+	// disabled holds the components that are skipped, either explicitly or
+	// because one of their dependencies is disabled, in registration order.
+	disabled []*component
+
+	// stopClasses lists the stop classes in the order they are stopped, or
+	// nil when stop mirrors the start waves.
+	stopClasses []string
+
+	// gated holds the disabled components that only the gate switched off,
+	// directly or through a dependency, in dependency order. Group.Reload
+	// starts them once the gate allows them.
+	gated []*component
+
+	// deps maps each component to the components it depends on.
+	deps map[*component][]*component
+
+	// quorums maps the members of quorums set with WithQuorum to their
+	// quorum.
+	quorums map[*component]*quorum
+}
+
+// schedule validates the registered components and computes their start
+// order, consulting the gate with ctx. The caller must not hold g.mu.
+func (g *Group) schedule(ctx context.Context) (*schedule, error) {
+	denied := g.denied(ctx)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	components := g.registry.list()
+	deps, err := g.dependencies(components)
+	if err != nil {
+		return nil, err
+	}
+
+	waves, err := g.waves(components, deps)
+	if err != nil {
+		return nil, err
+	}
+
+	// With a selector, only selected components and everything they depend
+	// on run; walking the waves backwards visits dependents first.
+	var needed map[*component]bool
+	if sel := g.opts.selector; sel != nil {
+		needed = make(map[*component]bool)
+		for _, wave := range slices.Backward(waves) {
+			for _, c := range wave {
+				if needed[c] || sel(c.info()) {
+					needed[c] = true
+					for _, p := range deps[c] {
+						needed[p] = true
+					}
+				}
+			}
+		}
+	}
+
+	// Waves are in dependency order, so a single pass propagates disabling
+	// from providers to everything that depends on them.
+	off := make(map[*component]bool)
+	for _, wave := range waves {
+		for _, c := range wave {
+			if g.skipped(c, needed, denied) {
+				off[c] = true
+				continue
+			}
+			for _, p := range deps[c] {
+				if off[p] {
+					off[c] = true
+					break
+				}
+			}
+		}
+	}
+
+	// A second pass without the gate tells the components that only the gate
+	// switched off.
+	var gated []*component
+	if len(denied) > 0 {
+		skipped := make(map[*component]bool)
+		for _, wave := range waves {
+			for _, c := range wave {
+				switch {
+				case g.skipped(c, needed, nil), slices.ContainsFunc(deps[c], func(p *component) bool { return skipped[p] }):
+					skipped[c] = true
+				case off[c]:
+					gated = append(gated, c)
+				}
+			}
+		}
+	}
+
+	s := &schedule{waves: waves, gated: gated, deps: deps}
+	if len(g.opts.stopClasses) > 0 {
+		s.stopClasses = g.opts.stopClasses
+		if !slices.Contains(s.stopClasses, "") {
+			s.stopClasses = append([]string{""}, s.stopClasses...)
+		}
+	}
+	for _, c := range components {
+		if c.stopClass != "" && !slices.Contains(s.stopClasses, c.stopClass) {
+			return nil, fmt.Errorf("component %q: %q: %w", c.name, c.stopClass, ErrUnknownStopClass)
+		}
+	}
+	if len(off) > 0 {
+		s.waves = s.waves[:0:0]
+		for _, wave := range waves {
+			enabled := slices.DeleteFunc(slices.Clone(wave), func(c *component) bool {
+				return off[c]
+			})
+			if len(enabled) > 0 {
+				s.waves = append(s.waves, enabled)
+			}
+		}
+		for _, c := range components {
+			if off[c] {
+				s.disabled = append(s.disabled, c)
+			}
+		}
+	}
+	s.waves = canaries(s.waves)
+	s.quorums = g.quorums(s.waves)
+	return s, nil
+}
+
+// skipped reports whether c itself is switched off, before taking its
+// dependencies into account. The caller must hold g.mu.
+func (g *Group) skipped(c *component, needed, denied map[*component]bool) bool {
+	switch {
+	case c.disabled, denied[c]:
+		return true
+	case needed != nil && !needed[c]:
+		return true
+	case g.opts.disabled != nil && g.opts.disabled(c.info()):
+		return true
+	}
+	return false
+}
+
+// stopWaves returns the waves in stop order, keeping only components for
+// which include reports true.
+//
+// By default these are the start waves reversed, each wave in reverse
+// registration order. With stop classes the classes stop in class order,
+// each as the reversed start waves of its components, so components of a
+// class stop concurrently except that dependents still stop before their
+// dependencies.
+func (s *schedule) stopWaves(include func(*component) bool) [][]*component {
+	if len(s.stopClasses) > 0 {
+		classes := make(map[string][][]*component, len(s.stopClasses))
+		for _, wave := range slices.Backward(s.waves) {
+			stoppable := make(map[string][]*component)
+			for _, c := range slices.Backward(wave) {
+				if include(c) {
+					stoppable[c.stopClass] = append(stoppable[c.stopClass], c)
+				}
+			}
+			for class, cs := range stoppable {
+				classes[class] = append(classes[class], cs)
+			}
+		}
+
+		var waves [][]*component
+		for _, class := range s.stopClasses {
+			waves = append(waves, classes[class]...)
+		}
+		return waves
+	}
+
+	reversed := make([][]*component, 0, len(s.waves))
+	for _, wave := range slices.Backward(s.waves) {
+		var stoppable []*component
+		for _, c := range slices.Backward(wave) {
+			if include(c) {
+				stoppable = append(stoppable, c)
+			}
+		}
+		if len(stoppable) > 0 {
+			reversed = append(reversed, stoppable)
+		}
+	}
+	return reversed
+}
+
+// dependencies resolves the declared dependencies of every component in cs
+// to the components they refer to.
+func (g *Group) dependencies(cs []*component) (map[*component][]*component, error) {
+	names := make(map[string]*component, len(cs))
+	for _, c := range cs {
+		if _, ok := names[c.name]; ok {
+			return nil, fmt.Errorf("%q: %w", c.name, ErrDuplicateName)
+		}
+		names[c.name] = c
+	}
+
+	deps := make(map[*component][]*component)
+	for _, c := range cs {
+		for _, name := range c.dependsOn {
+			p, ok := names[name]
+			if !ok {
+				return nil, fmt.Errorf("component %q depends on %q: %w", c.name, name, ErrUnknownComponent)
+			}
+			deps[c] = append(deps[c], p)
+		}
+		for _, d := range c.requires {
+			p, err := g.provider(cs, d)
+			if err != nil {
+				return nil, fmt.Errorf("component %q requires %w", c.name, err)
+			}
+			deps[c] = append(deps[c], p)
+		}
+	}
+	return deps, nil
+}
+
+// waves groups the components cs, in registration order, into start waves.
+//
+// Components are first split into priority classes; every wave of a higher
+// class precedes every wave of a lower one. Within a class, each wave holds
+// every component whose dependencies are all in earlier waves, in
+// registration order. Without priorities or dependencies all components form
+// a single wave. A dependency cycle is reported as a CycleError.
+func (g *Group) waves(cs []*component, deps map[*component][]*component) ([][]*component, error) {
+	if len(cs) == 0 {
+		return nil, nil
+	}
+
+	classes := make(map[int][]int)
+	for i, c := range cs {
+		classes[c.priority] = append(classes[c.priority], i)
+	}
+	if len(deps) == 0 && len(classes) == 1 {
+		return [][]*component{slices.Clone(cs)}, nil
+	}
+
+	priorities := slices.Collect(maps.Keys(classes))
+	slices.Sort(priorities)
+	slices.Reverse(priorities)
+
+	// Only dependencies within the same class constrain the order inside the
+	// class; providers of a higher class are already started by then.
+	index := make(map[*component]int, len(cs))
+	for i, c := range cs {
+		index[c] = i
+	}
+
+	pending := make([]int, len(cs))
+	dependents := make(map[*component][]*component)
+	for i, c := range cs {
+		for _, p := range deps[c] {
+			switch {
+			case p.priority < c.priority:
+				return nil, fmt.Errorf("component %q (priority %d) depends on %q (priority %d): %w",
+					c.name, c.priority, p.name, p.priority, ErrPriorityInversion)
+			case p.priority == c.priority:
+				pending[i]++
+				dependents[p] = append(dependents[p], c)
+			}
+		}
+	}
+
+	// Kahn's algorithm per class, taking every ready component at once as the
+	// next wave.
+	var waves [][]*component
+	for _, priority := range priorities {
+		members := classes[priority]
+
+		var ready []int
+		for _, i := range members {
+			if pending[i] == 0 {
+				ready = append(ready, i)
+			}
+		}
+
+		scheduled := 0
+		for len(ready) > 0 {
+			wave := make([]*component, len(ready))
+			var next []int
+			for j, i := range ready {
+				c := cs[i]
+				wave[j] = c
+				for _, d := range dependents[c] {
+					k := index[d]
+					if pending[k]--; pending[k] == 0 {
+						next = append(next, k)
+					}
+				}
+			}
+
+			slices.Sort(next)
+			ready = next
+			waves = append(waves, wave)
+			scheduled += len(wave)
+		}
+
+		if scheduled < len(members) {
+			return nil, &CycleError{Path: g.cycle(cs, deps, func(c *component) bool {
+				return pending[index[c]] > 0
+			})}
+		}
+	}
+
+	return waves, nil
+}
+
+// cycle returns the names along a dependency cycle among the components of
+// cs for which unscheduled reports true. Every such component has an
+// unscheduled dependency, so following those edges from the first one must
+// revisit a component.
+func (g *Group) cycle(cs []*component, deps map[*component][]*component, unscheduled func(*component) bool) []string {
+	i := slices.IndexFunc(cs, unscheduled)
+	if i < 0 {
+		return nil
+	}
+
+	var path []*component
+	seen := make(map[*component]int)
+	for c := cs[i]; ; {
+		if at, ok := seen[c]; ok {
+			path = append(path[at:], c)
+			break
+		}
+		seen[c] = len(path)
+		path = append(path, c)
+
+		for _, p := range deps[c] {
+			if unscheduled(p) {
+				c = p
+				break
+			}
+		}
+	}
+
+	names := make([]string, len(path))
+	for i, c := range path {
+		names[i] = c.name
+	}
+	return names
+}
@@ -3,7 +3,13 @@ package run
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var (
@@ -14,6 +20,11 @@ var (
 	ErrStopContextDeadlineExceeded = errors.New("stop context deadline exceeded")
 )
 
+// errStartWaitDeadline is returned when the deadline of the context passed
+// to Wait expires during the start phase, before the start timeout. It
+// matches both ErrStartContextDeadlineExceeded and context.DeadlineExceeded.
+var errStartWaitDeadline = fmt.Errorf("%w: %w of the Wait context", ErrStartContextDeadlineExceeded, context.DeadlineExceeded)
+
 // Start is a function that initializes a component. It should return quickly or return an error.
 type Start func() error
 
@@ -22,10 +33,50 @@ type Stop func(ctx context.Context) error
 
 // Group manages the coordinated startup and shutdown of multiple components.
 type Group struct {
-	opts     options // configuration options (e.g., timeouts)
-	mu       sync.Mutex
-	starters []Start // registered start functions
-	stoppers []Stop  // registered stop functions
+	opts       options // configuration options (e.g., timeouts)
+	mu         sync.Mutex
+	registry   registry       // registered components
+	errs       []error        // error buffer shared by the start and stop phases
+	stopping   bool           // set when shutdown begins; no further starts are attempted
+	running    *schedule      // schedule of the current run, nil before Wait
+	late       [][]*component // waves of gated components started by Reload
+	registered int            // number of registrars registered
+	err        error          // result of Wait
+	allErr     error          // every error of the run, joined
+	firstErr   error          // first start error to occur
+	ready      atomic.Bool    // set once all components started, cleared when shutdown begins
+	degraded   atomic.Bool    // set once a component failed with SeverityDegraded
+	warming    atomic.Bool    // set during the warmup phase
+	readyHooks []func(ready bool)
+	progressMu sync.Mutex    // serializes progress callbacks
+	exit       chan struct{} // closed when a component asks the whole group to stop
+	exitErr    error         // reason for closing exit
+	exitOnce   sync.Once
+	recorder   *Recorder   // flight recorder of recent lifecycle events, nil when disabled
+	stateLog   *stateLog   // logs state changes of components, nil when disabled
+	errors     errorStream // component errors as they happen
+
+	restartLimit *tokenBucket // limits restarts across the group, nil for no limit
+	stats        Stats        // lifecycle statistics kept with WithStats, guarded by mu
+	life         lifetime     // parent of the component contexts, canceled when shutdown begins
+
+	probes  map[*component]*probeState // cached results of the health poller
+	polling chan struct{}              // closed when the health poller returned, nil without one
+
+	bound map[string]*managedListener // declared with WithListener, bound while Wait runs
+
+	finalizers   []func()           // registered with Defer, in registration order
+	stopChecks   []stopCheck        // registered with StopCheck, in registration order
+	listeners    []*trackedListener // registered with TrackListener
+	leakBaseline leakBaseline       // resources held when Wait began, for WithLeakCheck
+	cancel       context.CancelFunc // cancels the context of Wait, nil outside Wait
+	stopCalled   bool               // set by Stop, so a later Wait stops right away
+	stopTrigger  context.Context    // context of the first Stop call, for the values of stop calls
+	startCalled  bool               // set by Start, so Wait joins the run it began
+	waitCalled   bool               // set by Wait, so a later Start or Wait does not run the group again
+	stopResult   error              // result of the stop phase
+	stopped      chan struct{}      // closed when Wait returns
+	stoppedOnce  sync.Once
 }
 
 // NewGroup creates a new Group with the given options.
@@ -34,134 +85,696 @@ func NewGroup(options ...Option) *Group {
 	for _, opt := range options {
 		opt.apply(&opts)
 	}
-	return &Group{opts: opts}
+	g := &Group{
+		opts:     opts,
+		exit:     make(chan struct{}),
+		recorder: newRecorder(opts.recorderSize),
+		stateLog: newStateLog(opts.logger, opts.stateLog),
+		errors:   errorStream{ch: make(chan error, opts.errorsBuffer)},
+
+		restartLimit: newTokenBucket(opts.restartLimit, opts.restartPer),
+		stopped:      make(chan struct{}),
+	}
+	if opts.leakCheck {
+		g.stopChecks = append(g.stopChecks, stopCheck{name: "leaks", check: g.checkLeaks})
+	}
+	return g
+}
+
+// NewGroupWithCapacity creates a new Group with the given options and
+// preallocated room for n components, so registering them and running the
+// start and stop phases does not need to grow internal buffers.
+func NewGroupWithCapacity(n int, options ...Option) *Group {
+	g := NewGroup(options...)
+	g.Grow(n)
+	return g
 }
 
-// Add registers a start and stop function to the group.
+// Grow ensures the group has room for at least n more components without
+// reallocating its internal buffers. It is a capacity hint only and does not
+// change behavior.
+func (g *Group) Grow(n int) {
+	if n <= 0 {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.registry.grow(n)
+	g.errs = slices.Grow(g.errs[:0], g.registry.len()+n)
+}
+
+// Add registers a start and stop function to the group, configured by the
+// given component options, and returns a Handle to the component. The
+// options default to the group's settings, so adopting a new per-component
+// setting only touches the call sites that need it:
+//
+//	g.Add(start, stop, run.Named("db"), run.Critical(false), run.StopTimeout(30*time.Second))
 //
 // Start is called during Group.Wait to initialize the component.
 // Stop is called during shutdown or if any Start function fails.
-func (g *Group) Add(start Start, stop Stop) *Group {
-	g.mu.Lock()
-	defer g.mu.Unlock()
+func (g *Group) Add(start Start, stop Stop, opts ...ComponentOption) *Handle {
+	return g.add(&component{
+		start: func(context.Context) error { return start() },
+		stop:  stop,
+	}, opts)
+}
 
-	g.starters = append(g.starters, start)
-	g.stoppers = append(g.stoppers, stop)
-	return g
+// add applies opts to c, registers it and returns its handle.
+func (g *Group) add(c *component, opts []ComponentOption) *Handle {
+	g.prepare(c, opts)
+	return g.insert(c, g.registry.reserve(1))
+}
+
+// prepare initializes c and applies opts to it.
+func (g *Group) prepare(c *component, opts []ComponentOption) {
+	c.started = make(chan struct{})
+	c.done = make(chan struct{})
+	c.recorder = g.recorder
+	c.stateLog = g.stateLog
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+}
+
+// insert registers the prepared component c with sequence number seq and
+// returns its handle.
+func (g *Group) insert(c *component, seq uint64) *Handle {
+	c.seq = seq
+	if c.name == "" {
+		c.name = "component-" + strconv.FormatUint(seq-1, 10)
+	}
+	h := &Handle{g: g, c: c}
+	g.registry.add(h, c)
+	return h
 }
 
 // Wait starts all registered components, waits for completion or error,
 // and ensures stop functions are called in reverse order.
 //
 // The behavior is as follows:
-// 1. Starts all components concurrently within a start timeout.
-// 2. If any start fails, calls all stop functions.
-// 3. If start times out, calls stop functions and returns a timeout error.
-// 4. If all components start successfully, blocks until ctx is canceled, then stops.
+// 1. Orders components into waves so that dependencies start before their dependents.
+// 2. Starts each wave concurrently on a bounded worker pool, all within a start timeout.
+// 3. If any start fails, calls the stop functions of all components whose start was called.
+// 4. If start times out, calls those stop functions and returns a timeout error.
+// 5. If all components start successfully, warms them up, registers the instance for discovery, waits for ctx to be canceled, then stops.
+//
+// A deadline of ctx earlier than the start timeout shortens the start phase;
+// when it expires the timeout error also matches context.DeadlineExceeded
+// and says so, while canceling ctx stops the group without an error.
+//
+// Stop functions are called with a context that carries the values of ctx,
+// but not its cancellation, so a trace or request ID in ctx reaches them.
+//
+// Before any stop function is called, the drainers of all running components
+// registered with Drains are called concurrently, within the drain timeout
+// that precedes the stop timeout. Once every stop function returned or the
+// stop timeout expired, the force stop functions of components that did not
+// stop cleanly are called within the force stop timeout.
+//
+// A component may also end the group early, such as a leader that lost its
+// leadership; Wait then stops all components and returns the reason.
+//
+// Wait returns an error without starting anything when requirements cannot be
+// satisfied, the lock set with WithLock cannot be acquired or addresses
+// declared with WithListener or WithPortCheck cannot be bound.
+//
+// Errors of several components are joined in component registration order,
+// whatever order the calls returned in.
+//
+// After Start, Wait does not start the group again: it waits for the run
+// Start began to end, stopping it when ctx is canceled, and returns its
+// error. Otherwise a group runs once: a second Wait returns ErrStarted.
 func (g *Group) Wait(ctx context.Context) error {
+	g.mu.Lock()
+	started, waited := g.startCalled, g.waitCalled
+	g.waitCalled = true
+	g.mu.Unlock()
+	switch {
+	case started:
+		return g.join(ctx)
+	case waited:
+		return ErrStarted
+	}
+	return g.serve(ctx)
+}
+
+// serve runs the group until ctx is canceled, implementing Wait.
+func (g *Group) serve(ctx context.Context) error {
+	// The health file writer runs until after the stop checks, so it is
+	// part of the leak baseline.
+	stopHealthFile := g.writeHealthFile()
+
+	if g.opts.leakCheck {
+		baseline := snapshotLeaks()
+		g.mu.Lock()
+		g.leakBaseline = baseline
+		g.mu.Unlock()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	g.mu.Lock()
+	if g.stopCalled {
+		cancel()
+	}
+	g.cancel = cancel
+	g.mu.Unlock()
+
+	g.life.begin(ctx)
+	defer g.life.end()
+
+	g.loadStats(ctx)
+	all := g.wait(ctx)
+	stopHealthFile()
+	g.finalize()
+	g.saveStats()
+
+	g.mu.Lock()
+	err := all
+	if all != nil && g.opts.firstError {
+		err = g.firstErr
+		if err == nil {
+			err = firstError(all)
+		}
+	}
+	g.err, g.allErr = err, all
+	g.cancel = nil
+	g.mu.Unlock()
+	g.errors.close()
+	g.stoppedOnce.Do(func() {
+		close(g.stopped)
+	})
+	return err
+}
+
+// Defer registers fn to be called when Wait returns, after the stop phase,
+// whatever its outcome — also after stop timeouts, stop errors and failures
+// to start — for cleanup that must not be skipped, such as flushing logs,
+// removing temporary directories or syncing telemetry. Finalizers run in
+// reverse registration order, like deferred calls, and a panicking finalizer
+// does not prevent the others from running. Defer may be called from start
+// functions.
+func (g *Group) Defer(fn func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.finalizers = append(g.finalizers, fn)
+}
+
+// finalize calls the functions registered with Defer.
+func (g *Group) finalize() {
+	g.mu.Lock()
+	finalizers := g.finalizers
+	g.finalizers = nil
+	g.mu.Unlock()
+
+	for _, fn := range finalizers {
+		defer fn()
+	}
+}
+
+// Stop shuts the group down as if the context passed to Wait had been
+// canceled, waits until Wait has stopped every component, and returns the
+// errors of the stop phase. It is safe to call any number of times, from
+// any goroutine and concurrently with Wait, so shutdown can be wired to
+// several triggers: every call waits for the same shutdown and returns the
+// same result. Stop called before Wait makes Wait stop right away.
+//
+// The stop calls receive the values of the ctx of the first Stop call, such
+// as the trace or request ID of an admin request, on top of those of the
+// context passed to Wait, so shutdown logs and spans correlate with what
+// triggered them.
+//
+// If ctx is done first, Stop returns ctx.Err() and shutdown continues.
+func (g *Group) Stop(ctx context.Context) error {
+	g.mu.Lock()
+	if !g.stopCalled {
+		g.stopTrigger = ctx
+	}
+	g.stopCalled = true
+	cancel := g.cancel
+	g.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+
+	select {
+	case <-g.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.stopResult
+}
+
+// Err returns the error Wait returned, so code that did not call Wait, such
+// as an admin endpoint or a test, can inspect the outcome. It returns nil
+// before Wait has returned. Status reports the outcome of each component.
+func (g *Group) Err() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.err
+}
+
+// AllErrors returns every error of the run joined, even when Wait returned
+// only the first one because of WithFirstError. It returns nil before Wait
+// has returned.
+func (g *Group) AllErrors() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.allErr
+}
+
+// wait implements Wait.
+func (g *Group) wait(ctx context.Context) error {
+	s, err := g.schedule(ctx)
+	if err != nil {
+		g.abandon()
+		return err
+	}
+
+	g.mu.Lock()
+	g.running = s
+	g.mu.Unlock()
+
 	startCtx, startCancel := context.WithTimeout(ctx, g.opts.startTimeout)
 	defer startCancel()
 
-	var wg sync.WaitGroup
-	startErrors := make(chan error, len(g.starters))
+	if err := g.lock(ctx, startCtx); err != nil {
+		g.abandon()
+		return err
+	}
 
-	// Start all registered Start functions concurrently.
-	for _, start := range g.starters {
-		wg.Add(1)
-		go func(a Start) {
-			defer wg.Done()
-			if err := a(); err != nil {
-				startErrors <- err
-			}
-		}(start)
+	// Bind the declared listeners before anything starts.
+	if err := g.listen(startCtx); err != nil {
+		g.abandon()
+		return errors.Join(err, g.unlock(context.Background()))
 	}
 
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(startErrors)
-		close(done)
-	}()
+	// Start components wave by wave on the worker pool.
+	started := g.runWaves(startCtx, s.waves, phaseConfig{
+		failFast:    true,
+		waveTimeout: g.opts.startWaveTimeout,
+		deadlineErr: ErrStartContextDeadlineExceeded,
+		inFlight:    StateStarting,
+	}, func(ctx context.Context, c *component) error {
+		err := g.startComponent(ctx, c)
+		g.reportProgress(s)
+		return err
+	})
+	budget := g.opts.startTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		budget = min(budget, time.Until(deadline))
+	}
+	stopWatch := g.watchBudget(PhaseStart, budget, StateStarting)
+
+	// timedOut stops the components after the start phase ran out of time
+	// and returns err with the stop errors.
+	timedOut := func(err error) error {
+		timeOut(s.waves, StateStarting)
+		if stopErr := g.stop(s); stopErr != nil {
+			return errors.Join(err, stopErr)
+		}
+		return err
+	}
 
 	select {
 	case <-ctx.Done():
+		stopWatch()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// The external deadline came before the start timeout.
+			return timedOut(errStartWaitDeadline)
+		}
 		// External context canceled — stop components.
-		return g.stop()
+		return g.stop(s)
+
+	case <-g.exit:
+		// A component ended the group — stop components.
+		stopWatch()
+		return g.exited(s)
 
 	case <-startCtx.Done():
-		// Start phase timed out — stop components and return timeout error.
-		err := g.stop()
-		if err != nil {
-			return errors.Join(ErrStartContextDeadlineExceeded, err)
+		stopWatch()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// The external deadline came before the start timeout.
+			return timedOut(errStartWaitDeadline)
+		}
+		if ctx.Err() != nil {
+			// External context canceled — startCtx inherits its cancellation.
+			return g.stop(s)
 		}
-		return ErrStartContextDeadlineExceeded
 
-	case <-done:
+		// Start phase timed out — stop components and return timeout error.
+		return timedOut(ErrStartContextDeadlineExceeded)
+
+	case <-started.done:
+		stopWatch()
+
 		// All starters completed, now check for any errors.
-		var errs []error
-		for err := range startErrors {
-			errs = append(errs, err)
-		}
-		if len(errs) > 0 {
-			stopErr := g.stop()
+		if errs := g.collate(started.errors()); len(errs) > 0 {
+			stopErr := g.stop(s)
 			if stopErr != nil {
 				errs = append(errs, stopErr)
 			}
 			return errors.Join(errs...)
 		}
 
-		// Successful start — wait for external signal to stop.
-		<-ctx.Done()
-		return g.stop()
+		// Members failing concurrently may have left a quorum short.
+		if err := g.checkQuorums(s); err != nil {
+			return errors.Join(err, g.stop(s))
+		}
+
+		// Successful start — warm up, announce the instance, then wait for
+		// external signal to stop.
+		warming := time.Now()
+		if err := g.warmup(ctx, s); err != nil {
+			if ctx.Err() != nil {
+				return g.stop(s)
+			}
+			return errors.Join(err, g.stop(s))
+		}
+
+		// The warmup does not count towards the start timeout.
+		deadline, _ := startCtx.Deadline()
+		registerCtx, registerCancel := context.WithDeadline(ctx, deadline.Add(time.Since(warming)))
+		defer registerCancel()
+		if err := g.register(registerCtx); err != nil {
+			return errors.Join(err, g.stop(s))
+		}
+		g.setReady(true)
+		if g.opts.healthPolicy != nil {
+			g.mu.Lock()
+			g.probes = make(map[*component]*probeState)
+			g.polling = make(chan struct{})
+			g.mu.Unlock()
+			go g.poll(g.life.context(), g.polling)
+		}
+		select {
+		case <-ctx.Done():
+			return g.stop(s)
+		case <-g.exit:
+			return g.exited(s)
+		}
 	}
 }
 
-// stop shuts down all registered components in reverse order.
-//
-// Stops run concurrently within a stop timeout.
-// Errors from any stop function are collected and returned.
-func (g *Group) stop() error {
-	stopCtx, stopCancel := context.WithTimeout(context.Background(), g.opts.stopTimeout)
-	defer stopCancel()
+// exitGroup asks Wait to stop all components and return err. Only the first
+// call has an effect.
+func (g *Group) exitGroup(err error) {
+	g.exitOnce.Do(func() {
+		g.mu.Lock()
+		g.exitErr = err
+		g.mu.Unlock()
+		close(g.exit)
+	})
+}
+
+// exited stops the group after exitGroup and returns the exit reason
+// together with any stop errors.
+func (g *Group) exited(s *schedule) error {
+	g.mu.Lock()
+	err := g.exitErr
+	g.mu.Unlock()
+
+	g.logGroup("group exiting", slog.Any("reason", err))
+	return errors.Join(err, g.stop(s))
+}
+
+// abandon marks every component as stopped without starting it, for runs
+// that fail before the start phase, so that waiting on Handle.Done returns.
+func (g *Group) abandon() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, c := range g.registry.list() {
+		if !c.attempted {
+			c.transition(StateRegistered, StateStopped)
+			c.closeDone()
+		}
+	}
+}
+
+// startComponent calls c's start function unless shutdown has already begun
+// or the component was stopped through its handle.
+func (g *Group) startComponent(ctx context.Context, c *component) error {
+	g.mu.Lock()
+	if g.stopping || c.stopping {
+		g.mu.Unlock()
+		return context.Cause(ctx)
+	}
+	if p := g.failedDependency(c); p != nil {
+		// The dependency failed without aborting the group; skip c with the
+		// same severity.
+		err := withSeverity(fmt.Errorf("%w: %q", ErrDependencyFailed, p.name), SeverityOf(p.err))
+		c.transition(StateRegistered, StateFailed)
+		c.err = c.wrap(PhaseStart, err)
+		g.mu.Unlock()
+		g.failed(c, PhaseStart, err)
+		if SeverityOf(err) != SeverityFatal {
+			return nil
+		}
+		return c.wrap(PhaseStart, err)
+	}
+	c.attempted = true
+	c.startDone = make(chan struct{})
+	c.endStart = sync.OnceFunc(func() { close(c.startDone) })
+	c.transition(StateRegistered, StateStarting)
+	endStart := c.endStart
+	g.mu.Unlock()
+	g.beginLife(c)
+	defer endStart()
+
+	began := time.Now()
+	err := c.within(ctx, g.startTimeout(c), StateStarting, ErrStartContextDeadlineExceeded, func(ctx context.Context) error {
+		return g.startRetrying(withComponent(ctx, c), c)
+	})
+	err = g.classify(c, PhaseStart, g.transform(c, err))
+	c.finish(StateStarting, StateRunning, err)
+	if SeverityOf(err) == SeverityDegraded {
+		g.degrade()
+	}
+	g.record(c, PhaseStart, began, err)
+	if err != nil {
+		err = c.wrap(PhaseStart, err)
+		g.mu.Lock()
+		c.err = err
+		g.mu.Unlock()
 
-	var wg sync.WaitGroup
-	stopErrors := make(chan error, len(g.stoppers))
-
-	// Stop in reverse order of Add
-	for i := len(g.stoppers) - 1; i >= 0; i-- {
-		stopper := g.stoppers[i]
-		wg.Add(1)
-		go func(a Stop) {
-			defer wg.Done()
-			if err := a(stopCtx); err != nil {
-				stopErrors <- err
+		// Only fatal errors abort the start phase.
+		if SeverityOf(err) != SeverityFatal {
+			if g.quorumOf(c) != nil {
+				go g.retryStart(c)
 			}
-		}(stopper)
+			return nil
+		}
+		return err
+	}
+
+	close(c.started)
+	return nil
+}
+
+// stopComponent calls c's stop function once. Callers that find the
+// component already stopping wait for that stop to finish, or for ctx, and
+// report first as false.
+//
+// A component whose start was never called is only marked as done. One
+// whose start call is still running is stopped once it returned; if ctx is
+// done first, stopComponent returns ctx.Err() and leaves the stop to a
+// goroutine.
+func (g *Group) stopComponent(ctx context.Context, c *component) (first bool, err error) {
+	g.mu.Lock()
+	if c.stopping {
+		g.mu.Unlock()
+		select {
+		case <-c.done:
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			return false, c.stopErr
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+	c.stopping = true
+	attempted, startDone := c.attempted, c.startDone
+	g.mu.Unlock()
+
+	if !attempted {
+		c.transition(StateRegistered, StateStopped)
+		c.closeDone()
+		return true, nil
+	}
+
+	// Never stop a component while its start function runs.
+	if startDone != nil {
+		select {
+		case <-startDone:
+		case <-ctx.Done():
+			go func() {
+				<-startDone
+				_ = g.stopStarted(context.WithoutCancel(ctx), c)
+			}()
+			return true, ctx.Err()
+		}
+	}
+	return true, g.stopStarted(ctx, c)
+}
+
+// stopStarted calls the stop function of c, whose start returned, and
+// records the result.
+func (g *Group) stopStarted(ctx context.Context, c *component) error {
+	// A component whose start failed stays failed after a clean stop, and a
+	// component paused without a Pauser is already stopped.
+	c.pauseMu.Lock()
+	done := StateStopped
+	stop := c.stop
+	switch c.swap(StateStopping) {
+	case StateFailed:
+		done = StateFailed
+	case StatePaused:
+		if c.pauser == nil {
+			stop = func(context.Context) error { return nil }
+		}
+	}
+	c.life.end()
+	began := time.Now()
+	err := c.within(ctx, g.stopTimeout(c), StateStopping, ErrStopContextDeadlineExceeded, func(ctx context.Context) error {
+		return g.callStop(withComponent(ctx, c), c, stop)
+	})
+	err = g.classify(c, PhaseStop, g.transform(c, err))
+	c.finish(StateStopping, done, err)
+	c.pauseMu.Unlock()
+	g.record(c, PhaseStop, began, err)
+	err = c.wrap(PhaseStop, err)
+
+	g.mu.Lock()
+	c.stopErr = err
+	if c.err == nil {
+		c.err = err
+	}
+	g.mu.Unlock()
+	c.closeDone()
+	return err
+}
+
+// stop shuts down every component whose start was called, walking the start
+// waves in reverse and each wave in reverse registration order. Components
+// within a wave stop concurrently.
+//
+// Stops run concurrently on a bounded worker pool within a stop timeout,
+// which begins once leaving service discovery and draining, bounded by the
+// drain timeout, are done. Errors from stop functions that completed in
+// time are collected and returned.
+func (g *Group) stop(s *schedule) error {
+	drainCtx, drainCancel := context.WithTimeout(g.stopContext(), g.opts.drainTimeout)
+	defer drainCancel()
+
+	g.logGroup("group stopping")
+	g.life.end()
+
+	// Let the health poller return before components stop.
+	g.mu.Lock()
+	polling := g.polling
+	g.mu.Unlock()
+	if polling != nil {
+		select {
+		case <-polling:
+		case <-drainCtx.Done():
+		}
 	}
 
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(done)
-		close(stopErrors)
-	}()
+	g.setReady(false)
 
+	// Leave service discovery before anything stops.
 	var errs []error
+	if err := g.deregister(drainCtx); err != nil {
+		errs = append(errs, err)
+	}
+
+	g.mu.Lock()
+	g.stopping = true
+	g.mu.Unlock()
+
+	// Stop accepting new work everywhere before anything is torn down.
+	if err := g.drain(drainCtx, s); err != nil {
+		errs = append(errs, err)
+	}
+	drainCancel()
+
+	stopCtx, stopCancel := context.WithTimeout(g.stopContext(), g.opts.stopTimeout)
+	defer stopCancel()
+
+	g.mu.Lock()
+	waves := g.stopOrder(s, func(c *component) bool {
+		return c.attempted
+	})
+	g.mu.Unlock()
+
+	stopWatch := g.watchBudget(PhaseStop, g.opts.stopTimeout, StateStopping)
+	stopped := g.runWaves(stopCtx, waves, phaseConfig{
+		waveTimeout: g.opts.stopWaveTimeout,
+		deadlineErr: ErrStopContextDeadlineExceeded,
+		inFlight:    StateStopping,
+	}, func(ctx context.Context, c *component) error {
+		// Errors of components stopped through their handle were already
+		// reported to that caller.
+		if first, err := g.stopComponent(ctx, c); first && SeverityOf(err) != SeverityIgnorable {
+			return err
+		}
+		return nil
+	})
 
 	// Wait for stop to complete or timeout
 	select {
 	case <-stopCtx.Done():
+		timeOut(waves, StateStopping)
 		errs = append(errs, ErrStopContextDeadlineExceeded)
-	case <-done:
+	case <-stopped.done:
 	}
+	stopWatch()
 
 	// Collect stop errors
-	for err := range stopErrors {
+	errs = append(errs, g.collate(stopped.errors())...)
+
+	// Release what the graceful stop left behind.
+	if err := g.forceStop(s); err != nil {
 		errs = append(errs, err)
 	}
 
-	if len(errs) == 0 {
-		return nil
+	// Close the declared listeners components may have left open.
+	if err := g.closeListeners(); err != nil {
+		errs = append(errs, err)
 	}
-	return errors.Join(errs...)
+
+	// Release the lock only once every component has stopped.
+	if err := g.unlock(stopCtx); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Components that were never started will not run anymore.
+	g.mu.Lock()
+	for _, c := range g.registry.list() {
+		if !c.attempted {
+			c.closeDone()
+		}
+	}
+	g.mu.Unlock()
+
+	// Verify that nothing was left behind.
+	if err := g.checkStopped(); err != nil {
+		errs = append(errs, err)
+	}
+
+	err := errors.Join(errs...)
+	g.mu.Lock()
+	g.stopResult = err
+	g.mu.Unlock()
+	return err
 }
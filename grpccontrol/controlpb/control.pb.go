@@ -0,0 +1,538 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: grpccontrol/controlpb/control.proto
+
+package controlpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type StatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_grpccontrol_controlpb_control_proto_rawDescGZIP(), []int{0}
+}
+
+type StatusResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Health of the group: "ok", "degraded" or "down".
+	Health string `protobuf:"bytes,1,opt,name=health,proto3" json:"health,omitempty"`
+	// Whether every component has started and the group is not shutting down.
+	Ready bool `protobuf:"varint,2,opt,name=ready,proto3" json:"ready,omitempty"`
+	// Components in registration order.
+	Components    []*Component `protobuf:"bytes,3,rep,name=components,proto3" json:"components,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_grpccontrol_controlpb_control_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StatusResponse) GetHealth() string {
+	if x != nil {
+		return x.Health
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetReady() bool {
+	if x != nil {
+		return x.Ready
+	}
+	return false
+}
+
+func (x *StatusResponse) GetComponents() []*Component {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+// Component is a snapshot of a single component.
+type Component struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Name   string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Labels map[string]string      `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Lifecycle state, such as "running" or "stopped".
+	State string `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	// Time the component entered its state, unset while registered.
+	Since    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=since,proto3" json:"since,omitempty"`
+	Restarts int32                  `protobuf:"varint,5,opt,name=restarts,proto3" json:"restarts,omitempty"`
+	// Start or stop error, empty if none.
+	Error         string `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Component) Reset() {
+	*x = Component{}
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Component) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Component) ProtoMessage() {}
+
+func (x *Component) ProtoReflect() protoreflect.Message {
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Component.ProtoReflect.Descriptor instead.
+func (*Component) Descriptor() ([]byte, []int) {
+	return file_grpccontrol_controlpb_control_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Component) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Component) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Component) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Component) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *Component) GetRestarts() int32 {
+	if x != nil {
+		return x.Restarts
+	}
+	return 0
+}
+
+func (x *Component) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ShutdownRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShutdownRequest) Reset() {
+	*x = ShutdownRequest{}
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShutdownRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShutdownRequest) ProtoMessage() {}
+
+func (x *ShutdownRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShutdownRequest.ProtoReflect.Descriptor instead.
+func (*ShutdownRequest) Descriptor() ([]byte, []int) {
+	return file_grpccontrol_controlpb_control_proto_rawDescGZIP(), []int{3}
+}
+
+type ShutdownResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShutdownResponse) Reset() {
+	*x = ShutdownResponse{}
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShutdownResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShutdownResponse) ProtoMessage() {}
+
+func (x *ShutdownResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShutdownResponse.ProtoReflect.Descriptor instead.
+func (*ShutdownResponse) Descriptor() ([]byte, []int) {
+	return file_grpccontrol_controlpb_control_proto_rawDescGZIP(), []int{4}
+}
+
+type RestartComponentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestartComponentRequest) Reset() {
+	*x = RestartComponentRequest{}
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestartComponentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestartComponentRequest) ProtoMessage() {}
+
+func (x *RestartComponentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestartComponentRequest.ProtoReflect.Descriptor instead.
+func (*RestartComponentRequest) Descriptor() ([]byte, []int) {
+	return file_grpccontrol_controlpb_control_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RestartComponentRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type RestartComponentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestartComponentResponse) Reset() {
+	*x = RestartComponentResponse{}
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestartComponentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestartComponentResponse) ProtoMessage() {}
+
+func (x *RestartComponentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestartComponentResponse.ProtoReflect.Descriptor instead.
+func (*RestartComponentResponse) Descriptor() ([]byte, []int) {
+	return file_grpccontrol_controlpb_control_proto_rawDescGZIP(), []int{6}
+}
+
+type ReloadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReloadRequest) Reset() {
+	*x = ReloadRequest{}
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadRequest) ProtoMessage() {}
+
+func (x *ReloadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadRequest.ProtoReflect.Descriptor instead.
+func (*ReloadRequest) Descriptor() ([]byte, []int) {
+	return file_grpccontrol_controlpb_control_proto_rawDescGZIP(), []int{7}
+}
+
+type ReloadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReloadResponse) Reset() {
+	*x = ReloadResponse{}
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadResponse) ProtoMessage() {}
+
+func (x *ReloadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_grpccontrol_controlpb_control_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadResponse.ProtoReflect.Descriptor instead.
+func (*ReloadResponse) Descriptor() ([]byte, []int) {
+	return file_grpccontrol_controlpb_control_proto_rawDescGZIP(), []int{8}
+}
+
+var File_grpccontrol_controlpb_control_proto protoreflect.FileDescriptor
+
+const file_grpccontrol_controlpb_control_proto_rawDesc = "" +
+	"\n" +
+	"#grpccontrol/controlpb/control.proto\x12\x0erun.control.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x0f\n" +
+	"\rStatusRequest\"y\n" +
+	"\x0eStatusResponse\x12\x16\n" +
+	"\x06health\x18\x01 \x01(\tR\x06health\x12\x14\n" +
+	"\x05ready\x18\x02 \x01(\bR\x05ready\x129\n" +
+	"\n" +
+	"components\x18\x03 \x03(\v2\x19.run.control.v1.ComponentR\n" +
+	"components\"\x93\x02\n" +
+	"\tComponent\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12=\n" +
+	"\x06labels\x18\x02 \x03(\v2%.run.control.v1.Component.LabelsEntryR\x06labels\x12\x14\n" +
+	"\x05state\x18\x03 \x01(\tR\x05state\x120\n" +
+	"\x05since\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x12\x1a\n" +
+	"\brestarts\x18\x05 \x01(\x05R\brestarts\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x11\n" +
+	"\x0fShutdownRequest\"\x12\n" +
+	"\x10ShutdownResponse\"-\n" +
+	"\x17RestartComponentRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x1a\n" +
+	"\x18RestartComponentResponse\"\x0f\n" +
+	"\rReloadRequest\"\x10\n" +
+	"\x0eReloadResponse2\xd1\x02\n" +
+	"\aControl\x12G\n" +
+	"\x06Status\x12\x1d.run.control.v1.StatusRequest\x1a\x1e.run.control.v1.StatusResponse\x12M\n" +
+	"\bShutdown\x12\x1f.run.control.v1.ShutdownRequest\x1a .run.control.v1.ShutdownResponse\x12e\n" +
+	"\x10RestartComponent\x12'.run.control.v1.RestartComponentRequest\x1a(.run.control.v1.RestartComponentResponse\x12G\n" +
+	"\x06Reload\x12\x1d.run.control.v1.ReloadRequest\x1a\x1e.run.control.v1.ReloadResponseB3Z1github.com/not-for-prod/run/grpccontrol/controlpbb\x06proto3"
+
+var (
+	file_grpccontrol_controlpb_control_proto_rawDescOnce sync.Once
+	file_grpccontrol_controlpb_control_proto_rawDescData []byte
+)
+
+func file_grpccontrol_controlpb_control_proto_rawDescGZIP() []byte {
+	file_grpccontrol_controlpb_control_proto_rawDescOnce.Do(func() {
+		file_grpccontrol_controlpb_control_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_grpccontrol_controlpb_control_proto_rawDesc), len(file_grpccontrol_controlpb_control_proto_rawDesc)))
+	})
+	return file_grpccontrol_controlpb_control_proto_rawDescData
+}
+
+var file_grpccontrol_controlpb_control_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_grpccontrol_controlpb_control_proto_goTypes = []any{
+	(*StatusRequest)(nil),            // 0: run.control.v1.StatusRequest
+	(*StatusResponse)(nil),           // 1: run.control.v1.StatusResponse
+	(*Component)(nil),                // 2: run.control.v1.Component
+	(*ShutdownRequest)(nil),          // 3: run.control.v1.ShutdownRequest
+	(*ShutdownResponse)(nil),         // 4: run.control.v1.ShutdownResponse
+	(*RestartComponentRequest)(nil),  // 5: run.control.v1.RestartComponentRequest
+	(*RestartComponentResponse)(nil), // 6: run.control.v1.RestartComponentResponse
+	(*ReloadRequest)(nil),            // 7: run.control.v1.ReloadRequest
+	(*ReloadResponse)(nil),           // 8: run.control.v1.ReloadResponse
+	nil,                              // 9: run.control.v1.Component.LabelsEntry
+	(*timestamppb.Timestamp)(nil),    // 10: google.protobuf.Timestamp
+}
+var file_grpccontrol_controlpb_control_proto_depIdxs = []int32{
+	2,  // 0: run.control.v1.StatusResponse.components:type_name -> run.control.v1.Component
+	9,  // 1: run.control.v1.Component.labels:type_name -> run.control.v1.Component.LabelsEntry
+	10, // 2: run.control.v1.Component.since:type_name -> google.protobuf.Timestamp
+	0,  // 3: run.control.v1.Control.Status:input_type -> run.control.v1.StatusRequest
+	3,  // 4: run.control.v1.Control.Shutdown:input_type -> run.control.v1.ShutdownRequest
+	5,  // 5: run.control.v1.Control.RestartComponent:input_type -> run.control.v1.RestartComponentRequest
+	7,  // 6: run.control.v1.Control.Reload:input_type -> run.control.v1.ReloadRequest
+	1,  // 7: run.control.v1.Control.Status:output_type -> run.control.v1.StatusResponse
+	4,  // 8: run.control.v1.Control.Shutdown:output_type -> run.control.v1.ShutdownResponse
+	6,  // 9: run.control.v1.Control.RestartComponent:output_type -> run.control.v1.RestartComponentResponse
+	8,  // 10: run.control.v1.Control.Reload:output_type -> run.control.v1.ReloadResponse
+	7,  // [7:11] is the sub-list for method output_type
+	3,  // [3:7] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_grpccontrol_controlpb_control_proto_init() }
+func file_grpccontrol_controlpb_control_proto_init() {
+	if File_grpccontrol_controlpb_control_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_grpccontrol_controlpb_control_proto_rawDesc), len(file_grpccontrol_controlpb_control_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_grpccontrol_controlpb_control_proto_goTypes,
+		DependencyIndexes: file_grpccontrol_controlpb_control_proto_depIdxs,
+		MessageInfos:      file_grpccontrol_controlpb_control_proto_msgTypes,
+	}.Build()
+	File_grpccontrol_controlpb_control_proto = out.File
+	file_grpccontrol_controlpb_control_proto_goTypes = nil
+	file_grpccontrol_controlpb_control_proto_depIdxs = nil
+}
@@ -0,0 +1,5 @@
+// Package controlpb contains the generated messages and service stubs of the
+// run control API defined in control.proto.
+package controlpb
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative grpccontrol/controlpb/control.proto
@@ -0,0 +1,53 @@
+package grpccontrol_test
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/grpccontrol"
+)
+
+func ExampleServer_Register() {
+	g := run.NewGroup()
+	g.Add(func() error { return nil }, func(context.Context) error { return nil }, run.Named("worker"))
+	srv := grpccontrol.New("127.0.0.1:0")
+	srv.Register(g)
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		go func() {
+			ctx := context.Background()
+			c, err := grpccontrol.NewClient(srv.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			defer c.Close()
+
+			resp, _ := c.Status(ctx)
+			fmt.Println("health:", resp.GetHealth())
+			for _, comp := range resp.GetComponents() {
+				fmt.Println(comp.GetName(), comp.GetState())
+			}
+			fmt.Println("restart:", c.RestartComponent(ctx, "worker"))
+			fmt.Println("restart ghost:", status.Code(c.RestartComponent(ctx, "ghost")))
+			_ = c.Shutdown(ctx)
+		}()
+	})
+
+	if err := g.Wait(context.Background()); err != nil {
+		fmt.Println("wait error:", err)
+	}
+	// Output:
+	// health: ok
+	// worker running
+	// grpccontrol running
+	// restart: <nil>
+	// restart ghost: NotFound
+}
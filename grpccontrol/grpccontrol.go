@@ -0,0 +1,274 @@
+// Package grpccontrol exposes a run.Group through the gRPC control API
+// defined in controlpb, so fleet tooling can query instances and orchestrate
+// graceful restarts across many of them uniformly: a server component
+// serves the API, and Client calls it.
+package grpccontrol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/grpccontrol/controlpb"
+)
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithServerOptions returns an Option that passes opts, such as transport
+// credentials or interceptors, to the gRPC server.
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return func(s *Server) {
+		s.serverOpts = append(s.serverOpts, opts...)
+	}
+}
+
+// WithAuthorizer returns an Option that lets a decide whether each call may
+// run, given the call's context with its metadata and peer. Denied calls
+// fail with codes.PermissionDenied.
+//
+// Default is to allow every call.
+func WithAuthorizer(a run.Authorizer) Option {
+	return func(s *Server) {
+		s.auth = a
+	}
+}
+
+// Server serves the control API for a group on a TCP address.
+type Server struct {
+	addr       string
+	serverOpts []grpc.ServerOption
+	auth       run.Authorizer
+
+	mu     sync.Mutex
+	srv    *grpc.Server
+	ln     net.Listener
+	served chan struct{}
+}
+
+// New returns a Server that listens on addr, such as "127.0.0.1:7070", once
+// it is registered and started.
+func New(addr string, opts ...Option) *Server {
+	s := &Server{addr: addr}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Register adds the server to g as a component named "grpccontrol" that
+// controls g, and returns its handle. opts can override the name and add
+// further component options.
+func (s *Server) Register(g *run.Group, opts ...run.ComponentOption) *run.Handle {
+	s.srv = grpc.NewServer(s.serverOpts...)
+	controlpb.RegisterControlServer(s.srv, NewService(g, s.auth))
+	opts = append([]run.ComponentOption{run.Named("grpccontrol")}, opts...)
+	return g.Add(s.start, s.stop, opts...)
+}
+
+// Addr returns the address the server listens on, or nil before it has
+// started. It is useful with a port of 0.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ln == nil {
+		return nil
+	}
+	return s.ln.Addr()
+}
+
+// start listens and serves the API in the background.
+func (s *Server) start() error {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.ln = ln
+	s.served = make(chan struct{})
+	s.mu.Unlock()
+
+	go func() {
+		defer close(s.served)
+		_ = s.srv.Serve(ln)
+	}()
+	return nil
+}
+
+// stop lets calls in flight finish, or cancels them when ctx is done.
+func (s *Server) stop(ctx context.Context) error {
+	s.mu.Lock()
+	served := s.served
+	s.mu.Unlock()
+	if served == nil {
+		return nil
+	}
+
+	go s.srv.GracefulStop()
+	select {
+	case <-served:
+		return nil
+	case <-ctx.Done():
+		s.srv.Stop()
+		return ctx.Err()
+	}
+}
+
+// service implements controlpb.ControlServer for a group.
+type service struct {
+	controlpb.UnimplementedControlServer
+	g    *run.Group
+	auth run.Authorizer
+}
+
+// NewService returns the control API for g, to register on a gRPC server of
+// one's own with controlpb.RegisterControlServer. a, if not nil, authorizes
+// every call.
+func NewService(g *run.Group, a run.Authorizer) controlpb.ControlServer {
+	return &service{g: g, auth: a}
+}
+
+// authorize runs the authorizer for cmd.
+func (s *service) authorize(ctx context.Context, cmd run.Command) error {
+	if err := run.Authorize(ctx, s.auth, cmd); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
+
+// Status reports the group's health and the state of every component.
+func (s *service) Status(ctx context.Context, _ *controlpb.StatusRequest) (*controlpb.StatusResponse, error) {
+	if err := s.authorize(ctx, run.Command{Name: run.CommandStatus}); err != nil {
+		return nil, err
+	}
+	resp := &controlpb.StatusResponse{
+		Health: s.g.Health().String(),
+		Ready:  s.g.Ready(),
+	}
+	for _, c := range s.g.Status() {
+		pc := &controlpb.Component{
+			Name:     c.Name,
+			Labels:   c.Labels,
+			State:    c.State.String(),
+			Restarts: int32(c.Restarts),
+		}
+		if !c.Since.IsZero() {
+			pc.Since = timestamppb.New(c.Since)
+		}
+		if c.Err != nil {
+			pc.Error = c.Err.Error()
+		}
+		resp.Components = append(resp.Components, pc)
+	}
+	return resp, nil
+}
+
+// Shutdown stops the group in the background, so the call returns before
+// the server itself is stopped. The stop calls receive the values of the
+// request's context, such as its trace.
+func (s *service) Shutdown(ctx context.Context, _ *controlpb.ShutdownRequest) (*controlpb.ShutdownResponse, error) {
+	if err := s.authorize(ctx, run.Command{Name: run.CommandStop}); err != nil {
+		return nil, err
+	}
+	go func() {
+		_ = s.g.Stop(context.WithoutCancel(ctx))
+	}()
+	return &controlpb.ShutdownResponse{}, nil
+}
+
+// RestartComponent restarts a single running component.
+func (s *service) RestartComponent(ctx context.Context, req *controlpb.RestartComponentRequest) (*controlpb.RestartComponentResponse, error) {
+	if err := s.authorize(ctx, run.Command{Name: run.CommandRestart, Components: []string{req.GetName()}}); err != nil {
+		return nil, err
+	}
+	state, ok := s.g.States()[req.GetName()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "component %q: %v", req.GetName(), run.ErrUnknownComponent)
+	}
+	if state != run.StateRunning {
+		return nil, status.Errorf(codes.FailedPrecondition, "component %q is %s", req.GetName(), state)
+	}
+	if err := s.g.RollingRestart(ctx, run.Names(req.GetName()), run.RollingPolicy{}); err != nil {
+		return nil, toStatus(err)
+	}
+	return &controlpb.RestartComponentResponse{}, nil
+}
+
+// Reload reloads the group.
+func (s *service) Reload(ctx context.Context, _ *controlpb.ReloadRequest) (*controlpb.ReloadResponse, error) {
+	if err := s.authorize(ctx, run.Command{Name: run.CommandReload}); err != nil {
+		return nil, err
+	}
+	if err := s.g.Reload(ctx); err != nil {
+		return nil, toStatus(err)
+	}
+	return &controlpb.ReloadResponse{}, nil
+}
+
+// toStatus converts an error of the group into a gRPC status error.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, run.ErrUnknownComponent):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, run.ErrNotRunning):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// Client calls the control API of a remote group.
+type Client struct {
+	conn *grpc.ClientConn
+	api  controlpb.ControlClient
+}
+
+// NewClient returns a client for the control server at target. opts must
+// include transport credentials, such as insecure.NewCredentials for a
+// plaintext connection.
+func NewClient(target string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("grpccontrol: %w", err)
+	}
+	return &Client{conn: conn, api: controlpb.NewControlClient(conn)}, nil
+}
+
+// Status returns the health of the group and the state of every component.
+func (c *Client) Status(ctx context.Context) (*controlpb.StatusResponse, error) {
+	return c.api.Status(ctx, &controlpb.StatusRequest{})
+}
+
+// Shutdown asks the group to stop gracefully. It returns once shutdown has
+// begun.
+func (c *Client) Shutdown(ctx context.Context) error {
+	_, err := c.api.Shutdown(ctx, &controlpb.ShutdownRequest{})
+	return err
+}
+
+// RestartComponent restarts the named running component.
+func (c *Client) RestartComponent(ctx context.Context, name string) error {
+	_, err := c.api.RestartComponent(ctx, &controlpb.RestartComponentRequest{Name: name})
+	return err
+}
+
+// Reload reloads the group.
+func (c *Client) Reload(ctx context.Context) error {
+	_, err := c.api.Reload(ctx, &controlpb.ReloadRequest{})
+	return err
+}
+
+// Close closes the connection to the server.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,44 @@
+package grpchealth_test
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/grpchealth"
+)
+
+func ExampleBind() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := health.NewServer()
+	status := func() healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := srv.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "api"})
+		if err != nil {
+			return healthpb.HealthCheckResponse_UNKNOWN
+		}
+		return resp.GetStatus()
+	}
+
+	g := run.NewGroup()
+	grpchealth.Bind(g, srv, "api")
+	g.OnReadyChange(func(ready bool) {
+		fmt.Println("ready:", ready, status())
+		if ready {
+			cancel()
+		}
+	})
+
+	fmt.Println("before start:", status())
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// before start: NOT_SERVING
+	// ready: true SERVING
+	// ready: false NOT_SERVING
+}
@@ -0,0 +1,36 @@
+// Package grpchealth reports a run.Group's readiness through a gRPC health
+// server, so gRPC load balancers stop routing to an instance while it is
+// starting or shutting down.
+package grpchealth
+
+import (
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/not-for-prod/run"
+)
+
+// Bind keeps the serving status of services on srv in sync with g: they are
+// NOT_SERVING until every component has started, SERVING while the group is
+// running, and NOT_SERVING again from the moment shutdown begins.
+//
+// Without services, the status of the whole server (the empty service name)
+// is updated. Bind should be called before Group.Wait.
+func Bind(g *run.Group, srv *health.Server, services ...string) {
+	if len(services) == 0 {
+		services = []string{""}
+	}
+
+	set := func(ready bool) {
+		status := healthpb.HealthCheckResponse_NOT_SERVING
+		if ready {
+			status = healthpb.HealthCheckResponse_SERVING
+		}
+		for _, service := range services {
+			srv.SetServingStatus(service, status)
+		}
+	}
+
+	set(g.Ready())
+	g.OnReadyChange(set)
+}
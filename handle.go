@@ -0,0 +1,84 @@
+package run
+
+import "context"
+
+// Handle refers to a single component registered with a Group. It lets
+// application code coordinate with that component instead of only with the
+// whole group.
+type Handle struct {
+	g *Group
+	c *component
+}
+
+// Name returns the component's name.
+func (h *Handle) Name() string {
+	return h.c.name
+}
+
+// State returns the component's current lifecycle state.
+func (h *Handle) State() State {
+	return h.c.loadState()
+}
+
+// Started returns a channel that is closed once the component's start
+// function has returned successfully. It is never closed if the start fails
+// or is never called.
+func (h *Handle) Started() <-chan struct{} {
+	return h.c.started
+}
+
+// Done returns a channel that is closed once the component will not run
+// anymore: after its stop function has returned, or when the group finished
+// stopping without ever starting it.
+func (h *Handle) Done() <-chan struct{} {
+	return h.c.done
+}
+
+// Err returns the error of the component's start function or, if the start
+// succeeded, of its stop function. It returns nil while the component is
+// running or after a clean stop.
+func (h *Handle) Err() error {
+	h.g.mu.Lock()
+	defer h.g.mu.Unlock()
+
+	return h.c.err
+}
+
+// Context returns the context of the component's current run, as returned
+// by ComponentContext, for start functions added with Group.Add, which
+// receive no context. It is canceled before the component started.
+func (h *Handle) Context() context.Context {
+	return h.c.life.context()
+}
+
+// Stop stops this component now, leaving the rest of the group running. It is
+// safe to call more than once and concurrently with the group's own shutdown:
+// the stop function runs once, and later callers wait for it and receive the
+// same result. A component stopped before its start was called is never
+// started. If its start function is still running, Stop waits for it to
+// return before calling the stop function, so a component is never stopped
+// while it starts; if ctx is done first, Stop returns ctx.Err() and the
+// component is stopped once its start returned.
+func (h *Handle) Stop(ctx context.Context) error {
+	_, err := h.g.stopComponent(ctx, h.c)
+	return err
+}
+
+// Remove stops the component like Stop and removes it from the group: it no
+// longer appears in Status, States or Plan, and its name may be registered
+// again. It is removed even if its stop fails. Add and Remove take constant
+// time and rarely contend, so components may come and go at a high rate,
+// such as one per client connection.
+//
+// Components that depend on a removed component keep running. A component
+// removed before Wait is never started, and Wait fails with
+// ErrUnknownComponent if another component still depends on it.
+func (h *Handle) Remove(ctx context.Context) error {
+	_, err := h.g.stopComponent(ctx, h.c)
+	if h.g.registry.remove(h) {
+		h.g.mu.Lock()
+		delete(h.g.probes, h.c)
+		h.g.mu.Unlock()
+	}
+	return err
+}
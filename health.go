@@ -0,0 +1,91 @@
+package run
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Health is the aggregated health tier of a group.
+type Health int
+
+const (
+	// HealthOK means every component is running and the group is ready.
+	HealthOK Health = iota
+
+	// HealthDegraded means the group serves with reduced capacity: a
+	// non-critical component failed, or components are still warming up.
+	HealthDegraded
+
+	// HealthDown means the group does not serve: a critical component
+	// failed, or the group has not started yet or is shutting down.
+	HealthDown
+)
+
+// String returns "ok", "degraded" or "down".
+func (h Health) String() string {
+	switch h {
+	case HealthOK:
+		return "ok"
+	case HealthDegraded:
+		return "degraded"
+	case HealthDown:
+		return "down"
+	default:
+		return fmt.Sprintf("Health(%d)", int(h))
+	}
+}
+
+// Health returns the group's health tier. A component that failed with a
+// fatal error, such as a critical component, makes it HealthDown, as does
+// every phase other than warmup and running; a component that failed with a
+// degraded error, such as one registered with Critical(false), makes it
+// HealthDegraded, as does the warmup phase.
+func (g *Group) Health() Health {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.stopping || g.running == nil {
+		return HealthDown
+	}
+	health := HealthOK
+	for _, c := range g.registry.list() {
+		switch c.loadState() {
+		case StateFailed, StateTimedOut:
+			if SeverityOf(c.err) == SeverityFatal && !c.optional {
+				return HealthDown
+			}
+			health = HealthDegraded
+		}
+	}
+	if h := g.probeHealth(); h > health {
+		health = h
+	}
+	switch {
+	case health == HealthDown:
+		return HealthDown
+	case g.warming.Load():
+		return HealthDegraded
+	case !g.Ready():
+		return HealthDown
+	case g.Degraded():
+		return HealthDegraded
+	}
+	return health
+}
+
+// HealthHandler returns an http.Handler reporting the group's health tier
+// in the body, for load balancers that treat a degraded backend differently
+// from a failed one. It responds 200 OK for HealthOK and HealthDegraded,
+// and 503 Service Unavailable for HealthDown.
+func (g *Group) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+
+		health := g.Health()
+		if health == HealthDown {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, _ = w.Write([]byte(health.String() + "\n"))
+	})
+}
@@ -0,0 +1,150 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultHealthFileInterval is the default interval at which the file
+// written with WithHealthFile is refreshed.
+const DefaultHealthFileInterval = 5 * time.Second
+
+// HealthFileEnv is the environment variable naming the health file, for
+// both WithHealthFile and HealthcheckMain when they are given no path.
+const HealthFileEnv = "RUN_HEALTH_FILE"
+
+// ErrUnhealthy is returned by Healthcheck, wrapping the reason, when the
+// health file reports a group that is down, is stale or is missing.
+var ErrUnhealthy = errors.New("unhealthy")
+
+// healthFileStale is how many intervals a health file may go without being
+// refreshed before Healthcheck considers the process hung.
+const healthFileStale = 3
+
+// WithHealthFile returns an Option that writes the group's health tier to
+// the file at path while Wait runs, refreshing it every interval, and
+// removes it when Wait returns. A container healthcheck can read it with
+// HealthcheckMain without the service exposing a port. An empty path means
+// $RUN_HEALTH_FILE, or "run-health" in the temporary directory; an interval
+// of zero means DefaultHealthFileInterval.
+//
+// Default is no health file.
+func WithHealthFile(path string, interval time.Duration) Option {
+	if path == "" {
+		path = healthFilePath()
+	}
+	if interval <= 0 {
+		interval = DefaultHealthFileInterval
+	}
+	return optionFunc(func(o *options) {
+		o.healthFile = path
+		o.healthFileInterval = interval
+	})
+}
+
+// healthFilePath returns the health file named by $RUN_HEALTH_FILE, or the
+// default one.
+func healthFilePath() string {
+	if path := os.Getenv(HealthFileEnv); path != "" {
+		return path
+	}
+	return filepath.Join(os.TempDir(), "run-health")
+}
+
+// Healthcheck reads the health file at path, as written with
+// WithHealthFile, and returns nil if the group is up, or an error wrapping
+// ErrUnhealthy if the group is down, the file was not refreshed for three
+// intervals, because the process hangs or has exited, or it does not exist.
+func Healthcheck(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnhealthy, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnhealthy, err)
+	}
+
+	health, interval, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return fmt.Errorf("%w: malformed health file %s", ErrUnhealthy, path)
+	}
+	if age := time.Since(info.ModTime()); age > healthFileStale*d {
+		return fmt.Errorf("%w: health file not refreshed for %s", ErrUnhealthy, age.Round(time.Second))
+	}
+	if health == HealthDown.String() {
+		return fmt.Errorf("%w: group is %s", ErrUnhealthy, health)
+	}
+	return nil
+}
+
+// HealthcheckMain checks the health file, at $RUN_HEALTH_FILE or the
+// default path of WithHealthFile, and exits with status 0 if the group is
+// up and 1 otherwise, printing the reason to standard error. It is meant
+// for a sub-command of the service's own binary used as a Dockerfile
+// HEALTHCHECK:
+//
+//	HEALTHCHECK CMD ["/app", "healthcheck"]
+//
+// with
+//
+//	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+//		run.HealthcheckMain()
+//	}
+func HealthcheckMain() {
+	if err := Healthcheck(healthFilePath()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// writeHealthFile keeps the health file up to date until the returned
+// function is called, which removes it.
+func (g *Group) writeHealthFile() (stop func()) {
+	path, interval := g.opts.healthFile, g.opts.healthFileInterval
+	if path == "" {
+		return func() {}
+	}
+
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			data := []byte(g.Health().String() + " " + interval.String() + "\n")
+			if err := writeFile(path, data); err != nil {
+				g.logHealthFile(err)
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			g.logHealthFile(err)
+		}
+	}
+}
+
+// logHealthFile logs a failure to write or remove the health file.
+func (g *Group) logHealthFile(err error) {
+	msg := "health file not written"
+	g.recorder.add(Event{Message: msg, Err: err})
+	if l := g.opts.logger; l != nil {
+		l.LogAttrs(context.Background(), slog.LevelError, msg, slog.Any("error", err))
+	}
+}
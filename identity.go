@@ -0,0 +1,34 @@
+package run
+
+import "context"
+
+// ComponentInfo identifies the component a start or stop call belongs to.
+type ComponentInfo struct {
+	Name   string            // component name
+	Labels map[string]string // component labels, must not be modified
+	Tags   []string          // component tags, must not be modified
+}
+
+// componentKey is the context key under which the component is stored.
+type componentKey struct{}
+
+// ComponentFromContext returns the identity of the component whose start or
+// stop call received ctx. Shared stop helpers can use it to log and tag
+// metrics with the right component without extra closure parameters.
+func ComponentFromContext(ctx context.Context) (ComponentInfo, bool) {
+	c, ok := ctx.Value(componentKey{}).(*component)
+	if !ok {
+		return ComponentInfo{}, false
+	}
+	return c.info(), true
+}
+
+// withComponent returns a copy of ctx carrying c's identity.
+func withComponent(ctx context.Context, c *component) context.Context {
+	return context.WithValue(ctx, componentKey{}, c)
+}
+
+// info returns the component's identity.
+func (c *component) info() ComponentInfo {
+	return ComponentInfo{Name: c.name, Labels: c.labels, Tags: c.tags}
+}
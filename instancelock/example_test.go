@@ -0,0 +1,83 @@
+package instancelock_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/instancelock"
+)
+
+func ExampleAdd() {
+	dir, err := os.MkdirTemp("", "instancelock")
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "reindex.lock")
+
+	// Another run of the job still holds the lock.
+	running := instancelock.New(path)
+	if err := running.Lock(); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	defer running.Unlock()
+
+	g := run.NewGroup()
+	instancelock.Add(g, path)
+	g.Add(func() error {
+		fmt.Println("reindex started")
+		return nil
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("reindex"))
+
+	err = g.Wait(context.Background())
+
+	var locked *instancelock.LockedError
+	if errors.As(err, &locked) {
+		fmt.Println(errors.Is(err, instancelock.ErrLocked), locked.PID == os.Getpid())
+	}
+	// Output:
+	// true true
+}
+
+func ExampleAdd_stopClasses() {
+	dir, err := os.MkdirTemp("", "instancelock")
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "reindex.lock")
+
+	g := run.NewGroup(run.WithStopClasses("servers", "stores"))
+	// Released after everything else, in the class stopped last.
+	instancelock.Add(g, path, run.StopClass("stores"))
+	g.Add(func() error { return nil }, func(ctx context.Context) error {
+		fmt.Println("store stopping, locked:", errors.Is(instancelock.New(path).Lock(), instancelock.ErrLocked))
+		return nil
+	}, run.Named("store"), run.StopClass("stores"))
+	g.Add(func() error { return nil }, func(ctx context.Context) error {
+		fmt.Println("server stopping, locked:", errors.Is(instancelock.New(path).Lock(), instancelock.ErrLocked))
+		return nil
+	}, run.Named("server"), run.StopClass("servers"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// server stopping, locked: true
+	// store stopping, locked: true
+}
@@ -0,0 +1,10 @@
+module github.com/not-for-prod/run/instancelock
+
+go 1.24.3
+
+require (
+	github.com/not-for-prod/run v0.0.0-00010101000000-000000000000
+	golang.org/x/sys v0.33.0
+)
+
+replace github.com/not-for-prod/run => ../
@@ -0,0 +1,128 @@
+// Package instancelock ensures only one instance of a binary runs at a time
+// per lock file, for example per host or working directory, so that
+// overlapping cron runs fail fast instead of corrupting shared state.
+package instancelock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/not-for-prod/run"
+)
+
+// ErrLocked is returned, wrapped in a LockedError, when another instance
+// holds the lock.
+var ErrLocked = errors.New("another instance is running")
+
+// LockedError reports the instance holding a lock file.
+type LockedError struct {
+	Path string // lock file path
+	PID  int    // process ID of the running instance, zero if unknown
+}
+
+// Error implements the error interface.
+func (e *LockedError) Error() string {
+	if e.PID == 0 {
+		return fmt.Sprintf("%s: lock %s", ErrLocked, e.Path)
+	}
+	return fmt.Sprintf("%s: pid %d holds lock %s", ErrLocked, e.PID, e.Path)
+}
+
+// Unwrap returns ErrLocked so callers can match with errors.Is.
+func (e *LockedError) Unwrap() error {
+	return ErrLocked
+}
+
+// Lock is an exclusive lock on a file holding the owner's process ID. The
+// lock is tied to the open file, so the operating system releases it when the
+// process exits, even after a crash.
+type Lock struct {
+	path string
+	f    *os.File
+}
+
+// New returns an unlocked Lock on the file at path.
+func New(path string) *Lock {
+	return &Lock{path: path}
+}
+
+// Lock acquires the lock without waiting and records the current process ID
+// in the file. If another process holds it, Lock returns a LockedError
+// naming that process.
+func (l *Lock) Lock() error {
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		if errors.Is(err, errWouldBlock) {
+			return &LockedError{Path: l.path, PID: readPID(l.path)}
+		}
+		return fmt.Errorf("lock %s: %w", l.path, err)
+	}
+
+	if err := writePID(f); err != nil {
+		unlockFile(f)
+		f.Close()
+		return fmt.Errorf("lock %s: %w", l.path, err)
+	}
+	l.f = f
+	return nil
+}
+
+// Unlock releases the lock. The file is left in place, since removing it
+// would let a second instance lock a new file while a third still waits on
+// the old one.
+func (l *Lock) Unlock() error {
+	if l.f == nil {
+		return nil
+	}
+	err := errors.Join(unlockFile(l.f), l.f.Close())
+	l.f = nil
+	return err
+}
+
+// Add adds a component to g that holds the lock at path while the group
+// runs, and returns its handle. It has the highest priority, so it is
+// acquired before any other component starts and released after all of them
+// stopped; if another instance holds the lock, Wait fails fast with a
+// LockedError. Further component options, such as Named, may be passed in
+// opts.
+//
+// With run.WithStopClasses the stop order follows the classes instead, and
+// the lock, like any component without a class, would be released first.
+// Pass run.StopClass with the class stopped last in opts; within its class
+// the lock still stops after every other component.
+func Add(g *run.Group, path string, opts ...run.ComponentOption) *run.Handle {
+	l := New(path)
+	opts = append([]run.ComponentOption{run.Named("instancelock"), run.Priority(math.MaxInt)}, opts...)
+	return g.Add(l.Lock, func(context.Context) error {
+		return l.Unlock()
+	}, opts...)
+}
+
+// writePID replaces the contents of f with the current process ID.
+func writePID(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return err
+}
+
+// readPID returns the process ID recorded at path, or zero.
+func readPID(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
+}
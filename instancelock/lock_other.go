@@ -0,0 +1,21 @@
+//go:build !unix && !windows
+
+package instancelock
+
+import (
+	"errors"
+	"os"
+)
+
+// errWouldBlock is returned by lockFile when another process holds the lock.
+var errWouldBlock = errors.New("would block")
+
+// lockFile reports that file locking is not supported on this platform.
+func lockFile(*os.File) error {
+	return errors.ErrUnsupported
+}
+
+// unlockFile reports that file locking is not supported on this platform.
+func unlockFile(*os.File) error {
+	return errors.ErrUnsupported
+}
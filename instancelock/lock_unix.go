@@ -0,0 +1,21 @@
+//go:build unix
+
+package instancelock
+
+import (
+	"os"
+	"syscall"
+)
+
+// errWouldBlock is returned by lockFile when another process holds the lock.
+var errWouldBlock = syscall.EWOULDBLOCK
+
+// lockFile places an exclusive advisory lock on f without blocking.
+func lockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
+
+// unlockFile releases the lock on f.
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
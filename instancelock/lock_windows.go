@@ -0,0 +1,30 @@
+//go:build windows
+
+package instancelock
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// errWouldBlock is returned by lockFile when another process holds the lock.
+var errWouldBlock = windows.ERROR_LOCK_VIOLATION
+
+// lockRange returns the overlapped structure for the locked byte range. It
+// lies beyond the recorded process ID, which therefore stays readable to
+// other processes.
+func lockRange() *windows.Overlapped {
+	return &windows.Overlapped{OffsetHigh: 0x7fffffff}
+}
+
+// lockFile places an exclusive lock on f without blocking.
+func lockFile(f *os.File) error {
+	return windows.LockFileEx(windows.Handle(f.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, lockRange())
+}
+
+// unlockFile releases the lock on f.
+func unlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, lockRange())
+}
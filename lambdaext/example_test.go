@@ -0,0 +1,55 @@
+package lambdaext_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/lambdaext"
+)
+
+func ExampleRun() {
+	// api emulates the Lambda Extensions API: one invocation, then shutdown.
+	events := []lambdaext.Event{
+		{EventType: lambdaext.Invoke, RequestID: "req-1"},
+		{EventType: lambdaext.Shutdown, ShutdownReason: "spindown"},
+	}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/register") {
+			w.Header().Set("Lambda-Extension-Identifier", "ext-1")
+			return
+		}
+		event := events[0]
+		events = events[1:]
+		_ = json.NewEncoder(w).Encode(event)
+	}))
+	defer api.Close()
+
+	g := run.NewGroup(lambdaext.Options()...)
+	g.Add(func() error {
+		fmt.Println("telemetry exporter started")
+		return nil
+	}, func(ctx context.Context) error {
+		fmt.Println("telemetry exporter flushed")
+		return nil
+	})
+
+	err := lambdaext.Run(context.Background(), g,
+		lambdaext.WithName("telemetry"),
+		lambdaext.WithRuntimeAPI(strings.TrimPrefix(api.URL, "http://")),
+		lambdaext.WithInvokeHook(func(e lambdaext.Event) {
+			fmt.Println("invoke:", e.RequestID)
+		}),
+	)
+	if err != nil {
+		fmt.Println("error:", err)
+	}
+	// Output:
+	// telemetry exporter started
+	// invoke: req-1
+	// telemetry exporter flushed
+}
@@ -0,0 +1,243 @@
+// Package lambdaext runs a run.Group as an AWS Lambda extension, so the same
+// wiring code serves both long-running services and Lambda functions:
+// components start at cold start, keep running across invocations and stop
+// during the SHUTDOWN phase.
+//
+// See https://docs.aws.amazon.com/lambda/latest/dg/runtimes-extensions-api.html.
+package lambdaext
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/not-for-prod/run"
+)
+
+// Event types delivered by the Extensions API.
+const (
+	Invoke   = "INVOKE"
+	Shutdown = "SHUTDOWN"
+)
+
+// ShutdownBudget is the time Lambda allows extensions during the SHUTDOWN
+// phase.
+const ShutdownBudget = 2 * time.Second
+
+// StopTimeout is the stop timeout used by Options. It leaves part of the
+// shutdown budget for the extension to exit.
+const StopTimeout = 1800 * time.Millisecond
+
+// ForceStopTimeout is the force stop timeout used by Options. Together with
+// StopTimeout it stays within the shutdown budget.
+const ForceStopTimeout = 100 * time.Millisecond
+
+// Options returns the group options for Lambda: stop and force stop timeouts
+// that fit the shutdown budget. Options given later to run.NewGroup override
+// these.
+func Options() []run.Option {
+	return []run.Option{
+		run.WithStopTimeout(StopTimeout),
+		run.WithForceStopTimeout(ForceStopTimeout),
+	}
+}
+
+// Event is an event received from the Extensions API.
+type Event struct {
+	EventType          string `json:"eventType"`
+	DeadlineMs         int64  `json:"deadlineMs"`
+	RequestID          string `json:"requestId"`
+	InvokedFunctionArn string `json:"invokedFunctionArn"`
+	ShutdownReason     string `json:"shutdownReason"`
+}
+
+// Deadline returns the event's deadline.
+func (e Event) Deadline() time.Time {
+	return time.UnixMilli(e.DeadlineMs)
+}
+
+// Option configures Run.
+type Option func(*config)
+
+// config holds the settings of Run.
+type config struct {
+	name       string
+	runtimeAPI string
+	client     *http.Client
+	onInvoke   func(Event)
+}
+
+// WithName returns an Option that sets the extension name, which for
+// external extensions must match the file name in /opt/extensions.
+//
+// Default is the base name of the executable.
+func WithName(name string) Option {
+	return func(c *config) {
+		c.name = name
+	}
+}
+
+// WithRuntimeAPI returns an Option that sets the host and port of the
+// Extensions API.
+//
+// Default is $AWS_LAMBDA_RUNTIME_API.
+func WithRuntimeAPI(addr string) Option {
+	return func(c *config) {
+		c.runtimeAPI = addr
+	}
+}
+
+// WithInvokeHook returns an Option that calls fn for every INVOKE event, for
+// example to flush buffered telemetry.
+func WithInvokeHook(fn func(Event)) Option {
+	return func(c *config) {
+		c.onInvoke = fn
+	}
+}
+
+// Run registers the process as an extension, starts g and, once every
+// component has started, reports initialization as complete by waiting for
+// events. When the SHUTDOWN event arrives, or ctx is canceled, g is stopped
+// and Run returns the result of g.Wait. If g fails to start, Run returns its
+// error right away, which makes Lambda fail the initialization.
+func Run(ctx context.Context, g *run.Group, opts ...Option) error {
+	c := &config{
+		runtimeAPI: os.Getenv("AWS_LAMBDA_RUNTIME_API"),
+		client:     &http.Client{},
+	}
+	if exe, err := os.Executable(); err == nil {
+		c.name = filepath.Base(exe)
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.runtimeAPI == "" {
+		return errors.New("lambdaext: AWS_LAMBDA_RUNTIME_API is not set")
+	}
+
+	id, err := c.register(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ready := make(chan struct{})
+	var once sync.Once
+	g.OnReadyChange(func(r bool) {
+		if r {
+			once.Do(func() { close(ready) })
+		}
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- g.Wait(ctx)
+	}()
+
+	// Asking for the first event tells Lambda that initialization is
+	// complete, so wait for every component to start.
+	select {
+	case err := <-done:
+		return err
+	case <-ready:
+	}
+
+	events := make(chan error, 1)
+	go func() {
+		events <- c.poll(ctx, id)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case err := <-events:
+		cancel()
+		return errors.Join(err, <-done)
+	}
+}
+
+// register registers the extension for INVOKE and SHUTDOWN events and
+// returns its identifier.
+func (c *config) register(ctx context.Context) (string, error) {
+	body, err := json.Marshal(map[string][]string{"events": {Invoke, Shutdown}})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url("register"), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Lambda-Extension-Name", c.name)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("lambdaext: register: %w", err)
+	}
+	resp.Body.Close()
+	return resp.Header.Get("Lambda-Extension-Identifier"), nil
+}
+
+// poll receives events until SHUTDOWN arrives, returning nil, or until
+// receiving fails.
+func (c *config) poll(ctx context.Context, id string) error {
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url("event/next"), nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Lambda-Extension-Identifier", id)
+
+		resp, err := c.do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("lambdaext: next event: %w", err)
+		}
+		var event Event
+		err = json.NewDecoder(resp.Body).Decode(&event)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("lambdaext: next event: %w", err)
+		}
+
+		switch event.EventType {
+		case Invoke:
+			if c.onInvoke != nil {
+				c.onInvoke(event)
+			}
+		case Shutdown:
+			return nil
+		}
+	}
+}
+
+// url returns the Extensions API URL of path.
+func (c *config) url(path string) string {
+	return "http://" + c.runtimeAPI + "/2020-01-01/extension/" + path
+}
+
+// do sends req and fails on non-2xx responses.
+func (c *config) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return resp, nil
+}
@@ -0,0 +1,188 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrLeadershipLost is returned by Wait when a leader added with Lead and
+// ExitOnLeadershipLoss loses its leadership.
+var ErrLeadershipLost = errors.New("leadership lost")
+
+// Elector is a leader election backed by an external system such as etcd,
+// Consul or Kubernetes leases.
+type Elector interface {
+	// Campaign blocks until this instance is elected leader or ctx is done.
+	// The returned channel is closed when leadership is lost.
+	Campaign(ctx context.Context) (lost <-chan struct{}, err error)
+
+	// Resign gives up leadership held by this instance.
+	Resign(ctx context.Context) error
+}
+
+// LeaderOption configures a leader added with Lead.
+type LeaderOption interface {
+	applyLeader(*leader)
+}
+
+// leaderOptionFunc is a helper type to implement the LeaderOption interface
+// with functions.
+type leaderOptionFunc func(*leader)
+
+// applyLeader executes the function to modify the leader.
+func (f leaderOptionFunc) applyLeader(l *leader) {
+	f(l)
+}
+
+// LeaderNamed returns a LeaderOption that names the leader component.
+//
+// Default is "leader".
+func LeaderNamed(name string) LeaderOption {
+	return leaderOptionFunc(func(l *leader) {
+		l.name = name
+	})
+}
+
+// ExitOnLeadershipLoss returns a LeaderOption that stops the whole group when
+// leadership is lost, making Wait return ErrLeadershipLost, instead of
+// campaigning again.
+func ExitOnLeadershipLoss() LeaderOption {
+	return leaderOptionFunc(func(l *leader) {
+		l.exit = true
+	})
+}
+
+// leader runs a term group while this instance holds leadership.
+type leader struct {
+	g       *Group
+	elector Elector
+	term    func() *Group
+	name    string
+	exit    bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu      sync.Mutex
+	leading bool  // whether Resign is owed on stop
+	err     error // error that ended the campaign loop
+}
+
+// Lead adds a component to g that campaigns for leadership through e once it
+// starts, and returns its handle. While this instance is leader it runs the
+// group returned by term, whose components therefore start only after
+// leadership is acquired; they are stopped when leadership is lost or g
+// stops. term is called once per leadership term, since a group runs once.
+//
+// The leader component starts immediately, so passive instances become
+// ready without leadership. After losing leadership it campaigns again
+// unless ExitOnLeadershipLoss is given. If a term fails to start or
+// campaigning fails, the whole group stops with that error. Its stop ends
+// the current term and resigns.
+func Lead(g *Group, e Elector, term func() *Group, opts ...LeaderOption) *Handle {
+	l := &leader{g: g, elector: e, term: term, name: "leader"}
+	for _, opt := range opts {
+		opt.applyLeader(l)
+	}
+
+	return g.Add(l.start, l.stop, Named(l.name))
+}
+
+// start launches the campaign loop.
+func (l *leader) start() error {
+	var ctx context.Context
+	ctx, l.cancel = context.WithCancel(context.Background())
+	l.done = make(chan struct{})
+	go l.run(ctx)
+	return nil
+}
+
+// stop ends the campaign loop and the current term, then resigns.
+func (l *leader) stop(ctx context.Context) error {
+	l.cancel()
+	select {
+	case <-l.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	err, leading := l.err, l.leading
+	l.mu.Unlock()
+
+	if leading {
+		err = errors.Join(err, l.elector.Resign(ctx))
+	}
+	return err
+}
+
+// run campaigns for leadership and runs a term for every won election until
+// ctx is done or the loop fails.
+func (l *leader) run(ctx context.Context) {
+	defer close(l.done)
+
+	for {
+		lost, err := l.elector.Campaign(ctx)
+		if ctx.Err() != nil {
+			if err == nil {
+				l.setLeading(true)
+			}
+			return
+		}
+		if err != nil {
+			l.fail(err)
+			return
+		}
+		l.setLeading(true)
+
+		termCtx, cancel := context.WithCancel(ctx)
+		errc := make(chan error, 1)
+		go func() { errc <- l.term().Wait(termCtx) }()
+
+		select {
+		case <-ctx.Done():
+			cancel()
+			l.setErr(<-errc)
+			return
+
+		case err := <-errc:
+			// The term ended on its own, so it failed to start.
+			cancel()
+			l.fail(err)
+			return
+
+		case <-lost:
+			cancel()
+			err := <-errc
+			l.setLeading(false)
+			if l.exit {
+				l.fail(errors.Join(ErrLeadershipLost, err))
+				return
+			}
+			l.setErr(err)
+		}
+	}
+}
+
+// setLeading records whether this instance holds leadership.
+func (l *leader) setLeading(leading bool) {
+	l.mu.Lock()
+	l.leading = leading
+	l.mu.Unlock()
+}
+
+// setErr records err as the loop's error unless it is nil.
+func (l *leader) setErr(err error) {
+	if err == nil {
+		return
+	}
+	l.mu.Lock()
+	l.err = err
+	l.mu.Unlock()
+}
+
+// fail stops the whole group with err, which Wait then returns.
+func (l *leader) fail(err error) {
+	l.g.exitGroup(err)
+}
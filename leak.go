@@ -0,0 +1,128 @@
+package run
+
+import (
+	"context"
+	"math"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// leakSettle bounds how long the leak check waits for goroutines and file
+// descriptors to be released after the stop phase.
+const leakSettle = time.Second
+
+// LeakError reports resources still held after the stop phase by the leak
+// check enabled with WithLeakCheck.
+type LeakError struct {
+	Goroutines int      // goroutines started since Wait began and still running
+	FDs        int      // file descriptors opened since Wait began and still open, zero where unknown
+	Listeners  []string // addresses of listeners passed to TrackListener and not closed
+}
+
+// Error lists the leaked resources.
+func (e *LeakError) Error() string {
+	var leaks []string
+	if e.Goroutines > 0 {
+		leaks = append(leaks, strconv.Itoa(e.Goroutines)+" goroutines")
+	}
+	if e.FDs > 0 {
+		leaks = append(leaks, strconv.Itoa(e.FDs)+" file descriptors")
+	}
+	for _, addr := range e.Listeners {
+		leaks = append(leaks, "listener "+addr)
+	}
+	return "leaked " + strings.Join(leaks, ", ")
+}
+
+// leakBaseline counts the resources held when Wait begins.
+type leakBaseline struct {
+	goroutines int
+	fds        int // -1 where unknown
+}
+
+// snapshotLeaks counts the resources the process holds.
+func snapshotLeaks() leakBaseline {
+	return leakBaseline{goroutines: runtime.NumGoroutine(), fds: openFDs()}
+}
+
+// openFDs returns the number of open file descriptors of the process, or -1
+// on platforms without /proc.
+func openFDs() int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+	return len(entries)
+}
+
+// checkLeaks compares the resources held after the stop phase to the
+// baseline taken when Wait began, giving goroutines and descriptors up to
+// leakSettle to be released.
+func (g *Group) checkLeaks(ctx context.Context) error {
+	g.mu.Lock()
+	base := g.leakBaseline
+	listeners := g.listeners
+	g.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, leakSettle)
+	defer cancel()
+
+	// Keep the fewest resources seen while settling, so goroutines that
+	// only exist for a moment, such as the runtime's while it runs a
+	// finalizer, are not reported.
+	leaks := LeakError{Goroutines: math.MaxInt, FDs: math.MaxInt}
+	for {
+		now := snapshotLeaks()
+		leaks.Goroutines = min(leaks.Goroutines, max(now.goroutines-base.goroutines, 0))
+		if base.fds >= 0 && now.fds >= 0 {
+			leaks.FDs = min(leaks.FDs, max(now.fds-base.fds, 0))
+		} else {
+			leaks.FDs = 0
+		}
+		if leaks.Goroutines == 0 && leaks.FDs == 0 || !sleep(ctx, 10*time.Millisecond) {
+			break
+		}
+	}
+
+	for _, l := range listeners {
+		if !l.closed.Load() {
+			leaks.Listeners = append(leaks.Listeners, l.Addr().String())
+		}
+	}
+
+	if leaks.Goroutines == 0 && leaks.FDs == 0 && len(leaks.Listeners) == 0 {
+		return nil
+	}
+	return &leaks
+}
+
+// trackedListener records whether a listener passed to TrackListener was
+// closed.
+type trackedListener struct {
+	net.Listener
+	closed atomic.Bool
+}
+
+// Close closes the listener.
+func (l *trackedListener) Close() error {
+	l.closed.Store(true)
+	return l.Listener.Close()
+}
+
+// TrackListener returns l wrapped so that the leak check enabled with
+// WithLeakCheck reports it if it is still open after the stop phase. The
+// returned listener no longer has l's concrete type.
+func (g *Group) TrackListener(l net.Listener) net.Listener {
+	t := &trackedListener{Listener: l}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.listeners = append(g.listeners, t)
+	return t
+}
@@ -0,0 +1,100 @@
+package run
+
+import (
+	"context"
+	"sync"
+)
+
+// lifetime is a context that lives as long as a run of the group or of a
+// component and is canceled when the run ends.
+type lifetime struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// begin starts a new run derived from parent, ending the previous one.
+func (l *lifetime) begin(parent context.Context) {
+	ctx, cancel := context.WithCancel(parent)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cancel != nil {
+		l.cancel()
+	}
+	l.ctx, l.cancel = ctx, cancel
+}
+
+// end cancels the context of the current run.
+func (l *lifetime) end() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cancel != nil {
+		l.cancel()
+	}
+}
+
+// replace ends the current run and continues with next's.
+func (l *lifetime) replace(next *lifetime) {
+	next.mu.Lock()
+	ctx, cancel := next.ctx, next.cancel
+	next.mu.Unlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cancel != nil {
+		l.cancel()
+	}
+	l.ctx, l.cancel = ctx, cancel
+}
+
+// context returns the context of the current run, or a canceled context if
+// no run began yet.
+func (l *lifetime) context() context.Context {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.ctx == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		return ctx
+	}
+	return l.ctx
+}
+
+// lifetimeKey is the context key under which the run of a component
+// instance other than the current one is stored.
+type lifetimeKey struct{}
+
+// withLifetime returns a copy of ctx for which ComponentContext returns the
+// context of l.
+func withLifetime(ctx context.Context, l *lifetime) context.Context {
+	return context.WithValue(ctx, lifetimeKey{}, l)
+}
+
+// ComponentContext returns a context for the work of the component whose
+// start, stop or run function received ctx, derived from the context passed
+// to Wait and carrying the component's identity. Unlike the start context it
+// outlives the start call: it is canceled as soon as the group begins to
+// shut down, or when the component itself is stopped, paused or restarted,
+// so background goroutines spawned by a start function need no context or
+// stop channel of their own. After a restart or resume, ComponentContext
+// returns a fresh context. Outside of a component call, it returns ctx.
+func ComponentContext(ctx context.Context) context.Context {
+	if l, ok := ctx.Value(lifetimeKey{}).(*lifetime); ok {
+		return l.context()
+	}
+	c, ok := ctx.Value(componentKey{}).(*component)
+	if !ok {
+		return ctx
+	}
+	return c.life.context()
+}
+
+// beginLife starts a new run of c, derived from the group's run.
+func (g *Group) beginLife(c *component) {
+	c.life.begin(withComponent(g.life.context(), c))
+}
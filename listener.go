@@ -0,0 +1,249 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+var (
+	// ErrUnknownListener is returned by Group.Listener for a name that was
+	// not declared with WithListener.
+	ErrUnknownListener = errors.New("unknown listener")
+
+	// ErrNotListening is returned by Group.Listener for a declared listener
+	// before Wait bound it or after the group closed it.
+	ErrNotListening = errors.New("listener not bound")
+
+	// ErrDuplicateListener is returned when two listeners declared with
+	// WithListener share the same name.
+	ErrDuplicateListener = errors.New("duplicate listener name")
+
+	// ErrAddressInUse is matched by an AddressError with a conflict whose
+	// address is taken.
+	ErrAddressInUse = errors.New("address in use")
+)
+
+// AddressConflict is a declared address that could not be bound.
+type AddressConflict struct {
+	Name    string // name given to WithListener or WithPortCheck
+	Network string // network, such as "tcp" or "unix"
+	Address string // address as declared
+	Err     error  // why binding failed
+}
+
+// AddressError is returned by Wait and CheckPorts when addresses declared
+// with WithListener or WithPortCheck cannot be bound, listing every
+// conflict rather than only the first. It matches the errors of the
+// conflicts, such as syscall.EADDRINUSE, and ErrAddressInUse if any of them
+// failed because its address is taken rather than, say, being malformed.
+type AddressError struct {
+	Conflicts []AddressConflict
+}
+
+// Error lists the conflicts.
+func (e *AddressError) Error() string {
+	conflicts := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		conflicts[i] = fmt.Sprintf("%q (%s %s): %v", c.Name, c.Network, c.Address, c.Err)
+	}
+	return "cannot bind: " + strings.Join(conflicts, "; ")
+}
+
+// Is reports whether target is ErrAddressInUse and the address of a conflict
+// is taken.
+func (e *AddressError) Is(target error) bool {
+	if target != ErrAddressInUse {
+		return false
+	}
+	for _, c := range e.Conflicts {
+		if errors.Is(c.Err, syscall.EADDRINUSE) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unwrap returns the errors of the conflicts.
+func (e *AddressError) Unwrap() []error {
+	errs := make([]error, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		errs[i] = c.Err
+	}
+	return errs
+}
+
+// listenerSpec is an address declared with WithListener or WithPortCheck.
+type listenerSpec struct {
+	name    string
+	network string
+	address string
+}
+
+// WithListener returns an Option that declares a listener the group binds
+// with net.Listen(network, address) before any component starts, and hands
+// to components by name through Group.Listener. If any declared listener
+// cannot be bound, such as because its port is taken, Wait fails with an
+// AddressError listing every conflict without starting anything. The group
+// closes every listener exactly once, after all components stopped; closing
+// it earlier, such as with http.Server.Shutdown, is harmless.
+//
+// WithListener may be given more than once for different names; Wait fails
+// with ErrDuplicateListener, binding nothing, if a name is declared twice.
+func WithListener(name, network, address string) Option {
+	return optionFunc(func(o *options) {
+		o.listeners = append(o.listeners, listenerSpec{name: name, network: network, address: address})
+	})
+}
+
+// WithPortCheck returns an Option that declares an address a component
+// binds itself, such as a server that cannot be handed a listener: before
+// any component starts, Wait binds it and closes it again, and fails with an
+// AddressError listing every conflict, together with those of WithListener,
+// without starting anything. This reports all taken ports at once instead
+// of failing on the component that binds one after others already started.
+//
+// WithPortCheck may be given more than once for different names.
+func WithPortCheck(name, network, address string) Option {
+	return optionFunc(func(o *options) {
+		o.portChecks = append(o.portChecks, listenerSpec{name: name, network: network, address: address})
+	})
+}
+
+// managedListener is a listener bound by the group. Closing it more than
+// once closes the underlying listener only once.
+type managedListener struct {
+	net.Listener
+	once sync.Once
+	err  error
+}
+
+// Close closes the listener the first time and returns the first result
+// every time.
+func (l *managedListener) Close() error {
+	l.once.Do(func() {
+		l.err = l.Listener.Close()
+	})
+	return l.err
+}
+
+// Listener returns the listener declared with WithListener under name, as
+// bound by Wait, so that start functions can serve on it. It returns an
+// error wrapping ErrUnknownListener for an undeclared name and wrapping
+// ErrNotListening before Wait bound the listener or after it was closed.
+func (g *Group) Listener(name string) (net.Listener, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if l, ok := g.bound[name]; ok {
+		return l, nil
+	}
+	for _, spec := range g.opts.listeners {
+		if spec.name == name {
+			return nil, fmt.Errorf("listener %q: %w", name, ErrNotListening)
+		}
+	}
+	return nil, fmt.Errorf("listener %q: %w", name, ErrUnknownListener)
+}
+
+// CheckPorts verifies that every address declared with WithListener or
+// WithPortCheck can be bound, binding and closing each, and returns an
+// AddressError listing every conflict. Wait does the same before starting
+// anything; CheckPorts lets a preflight step, such as a --check flag, report
+// conflicts without running the group. Listeners the group currently holds
+// are not checked.
+func (g *Group) CheckPorts(ctx context.Context) error {
+	_, err := g.bind(ctx, false)
+	return err
+}
+
+// listen binds the listeners declared with WithListener and checks the
+// addresses declared with WithPortCheck. If any cannot be bound, those
+// already bound are closed again and an AddressError is returned.
+func (g *Group) listen(ctx context.Context) error {
+	bound, err := g.bind(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	g.bound = bound
+	g.mu.Unlock()
+	return nil
+}
+
+// bind binds every declared address, keeping the listeners declared with
+// WithListener open if keep is set and closing everything else again.
+func (g *Group) bind(ctx context.Context, keep bool) (map[string]*managedListener, error) {
+	names := make(map[string]bool, len(g.opts.listeners))
+	for _, spec := range g.opts.listeners {
+		if names[spec.name] {
+			return nil, fmt.Errorf("listener %q: %w", spec.name, ErrDuplicateListener)
+		}
+		names[spec.name] = true
+	}
+
+	g.mu.Lock()
+	held := g.bound
+	g.mu.Unlock()
+
+	var (
+		lc        net.ListenConfig
+		bound     = make(map[string]*managedListener)
+		conflicts []AddressConflict
+	)
+	try := func(spec listenerSpec, listener bool) {
+		if _, ok := held[spec.name]; ok && listener {
+			return
+		}
+		l, err := lc.Listen(ctx, spec.network, spec.address)
+		if err != nil {
+			conflicts = append(conflicts, AddressConflict{Name: spec.name, Network: spec.network, Address: spec.address, Err: err})
+			return
+		}
+		if listener && keep {
+			bound[spec.name] = &managedListener{Listener: l}
+			return
+		}
+		_ = l.Close()
+	}
+	for _, spec := range g.opts.listeners {
+		try(spec, true)
+	}
+	for _, spec := range g.opts.portChecks {
+		try(spec, false)
+	}
+
+	if len(conflicts) > 0 {
+		for _, l := range bound {
+			_ = l.Close()
+		}
+		return nil, &AddressError{Conflicts: conflicts}
+	}
+	return bound, nil
+}
+
+// closeListeners closes the listeners bound by listen and returns their
+// errors, except for listeners components already closed.
+func (g *Group) closeListeners() error {
+	g.mu.Lock()
+	bound := g.bound
+	g.bound = nil
+	g.mu.Unlock()
+
+	var errs []error
+	for _, spec := range g.opts.listeners {
+		l, ok := bound[spec.name]
+		if !ok {
+			continue
+		}
+		if err := l.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, fmt.Errorf("listener %q: %w", spec.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
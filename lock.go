@@ -0,0 +1,49 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Locker is a distributed lock backed by an external system, such as a
+// database advisory lock or a lease, that guarantees only one instance of a
+// singleton worker runs at a time.
+type Locker interface {
+	// Lock blocks until the lock is acquired or ctx is done.
+	Lock(ctx context.Context) error
+
+	// Unlock releases the lock.
+	Unlock(ctx context.Context) error
+}
+
+// lock acquires the configured lock within the start context. A lock that
+// cannot be acquired in time fails with ErrStartContextDeadlineExceeded.
+func (g *Group) lock(ctx, startCtx context.Context) error {
+	if g.opts.locker == nil {
+		return nil
+	}
+
+	if err := g.opts.locker.Lock(startCtx); err != nil {
+		err = fmt.Errorf("acquire lock: %w", err)
+		if ctx.Err() == nil && startCtx.Err() != nil {
+			return errors.Join(ErrStartContextDeadlineExceeded, err)
+		}
+		return err
+	}
+	g.logGroup("group lock acquired")
+	return nil
+}
+
+// unlock releases the configured lock within the stop context.
+func (g *Group) unlock(ctx context.Context) error {
+	if g.opts.locker == nil {
+		return nil
+	}
+
+	if err := g.opts.locker.Unlock(ctx); err != nil {
+		return fmt.Errorf("release lock: %w", err)
+	}
+	g.logGroup("group lock released")
+	return nil
+}
@@ -0,0 +1,125 @@
+package run
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Logger returns the logger installed with WithLogger, or nil, so that
+// components such as the proc package's processes can log through it too.
+func (g *Group) Logger() *slog.Logger {
+	return g.opts.logger
+}
+
+// logCall logs the result of a start or stop call of c that began at began.
+func (g *Group) logCall(c *component, phase Phase, began time.Time, err error) {
+	l := g.opts.logger
+	if l == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("component", c.name),
+		slog.String("phase", string(phase)),
+		slog.Duration("duration", time.Since(began)),
+	}
+
+	level, msg := slog.LevelInfo, "component "+string(phase)+" succeeded"
+	switch {
+	case c.loadState() == StateTimedOut:
+		level, msg = slog.LevelError, "component "+string(phase)+" returned after deadline"
+	case err != nil:
+		level, msg = slog.LevelError, "component "+string(phase)+" failed"
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	if len(c.labels) > 0 {
+		labels := make([]any, 0, len(c.labels))
+		for _, k := range slices.Sorted(maps.Keys(c.labels)) {
+			labels = append(labels, slog.String(k, c.labels[k]))
+		}
+		attrs = append(attrs, slog.Group("labels", labels...))
+	}
+
+	l.LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+// logGroup logs a group-level lifecycle message and adds it to the flight
+// recorder.
+func (g *Group) logGroup(msg string, attrs ...slog.Attr) {
+	g.recorder.add(Event{Message: msg})
+	if l := g.opts.logger; l != nil {
+		l.LogAttrs(context.Background(), slog.LevelInfo, msg, attrs...)
+	}
+}
+
+// stateLog logs the state changes of components as they happen.
+type stateLog struct {
+	logger *slog.Logger
+	level  slog.Level
+}
+
+// newStateLog returns a state log writing to l at level, or nil if either is
+// nil.
+func newStateLog(l *slog.Logger, level *slog.Level) *stateLog {
+	if l == nil || level == nil {
+		return nil
+	}
+	return &stateLog{logger: l, level: *level}
+}
+
+// log logs that c moved from one state to another after elapsed in the
+// previous one.
+func (s *stateLog) log(c *component, from, to State, elapsed time.Duration) {
+	ctx := context.Background()
+	if !s.logger.Enabled(ctx, s.level) {
+		return
+	}
+
+	phase, outcome := transitionPhase(from, to)
+	attrs := []slog.Attr{
+		slog.String("component", c.name),
+		slog.String("phase", string(phase)),
+		slog.String("state", to.String()),
+		slog.String("previous", from.String()),
+		slog.Duration("elapsed", elapsed),
+	}
+	if outcome != "" {
+		attrs = append(attrs, slog.String("outcome", outcome))
+	}
+	s.logger.LogAttrs(ctx, s.level, "component "+to.String(), attrs...)
+}
+
+// transitionPhase returns the phase a state change belongs to and, if it
+// settles the component, the outcome of the phase.
+func transitionPhase(from, to State) (Phase, string) {
+	var outcome string
+	switch to {
+	case StateRunning, StateStopped:
+		outcome = OutcomeOK
+	case StateFailed:
+		outcome = OutcomeError
+	case StateTimedOut:
+		outcome = OutcomeTimeout
+	}
+
+	switch {
+	case to == StateStarting, from == StateStarting:
+		return PhaseStart, outcome
+	case from == StateRegistered && to == StateFailed:
+		// Skipped because a dependency failed.
+		return PhaseStart, outcome
+	case to == StateStopping, from == StateStopping, from == StateRegistered:
+		return PhaseStop, outcome
+	case to == StatePaused:
+		return PhasePause, ""
+	case from == StatePaused:
+		return PhaseResume, ""
+	default:
+		return PhaseRun, outcome
+	}
+}
@@ -0,0 +1,117 @@
+package run
+
+import (
+	"maps"
+	"time"
+)
+
+// Metric names reported to Metrics.
+const (
+	// MetricCalls counts start, stop and other component calls, labeled with component, phase and outcome.
+	MetricCalls = "run_component_calls_total"
+
+	// MetricDuration observes the duration of component calls in
+	// seconds, labeled with component, phase and outcome.
+	MetricDuration = "run_component_duration_seconds"
+
+	// MetricReady is 1 while the group is ready and 0 otherwise.
+	MetricReady = "run_group_ready"
+)
+
+// Label names attached to component metrics, in addition to the component's
+// own labels. They take precedence over component labels with the same name.
+const (
+	LabelComponent = "component" // component name
+	LabelPhase     = "phase"     // Phase of the call
+	LabelOutcome   = "outcome"   // one of the Outcome constants
+)
+
+// Outcome values of the LabelOutcome label.
+const (
+	OutcomeOK      = "ok"      // the call returned nil
+	OutcomeError   = "error"   // the call returned an error
+	OutcomeTimeout = "timeout" // the call returned after its deadline had expired
+)
+
+// Phase identifies the lifecycle step a call or error belongs to.
+type Phase string
+
+const (
+	PhaseStart     Phase = "start"      // a component's start function
+	PhaseWarmup    Phase = "warmup"     // a component's Warmer
+	PhaseStop      Phase = "stop"       // a component's stop function
+	PhaseDrain     Phase = "drain"      // a component's Drainer
+	PhaseForceStop Phase = "force_stop" // a component's force stop function
+	PhaseRun       Phase = "run"        // a run function returning while the group runs
+	PhaseRotate    Phase = "rotate"     // a component's Rotator
+	PhaseReload    Phase = "reload"     // a component's Reloader
+	PhasePause     Phase = "pause"      // pausing a component with Group.Pause
+	PhaseResume    Phase = "resume"     // resuming a component with Group.Resume
+	PhaseSwap      Phase = "swap"       // starting a replacement with Group.Swap
+	PhaseProbe     Phase = "probe"      // a component's health probe
+)
+
+// Metrics receives the group's lifecycle measurements. It is deliberately
+// small so that any metrics library can be adapted to it; adapters live
+// outside this package. Implementations must be safe for concurrent use.
+type Metrics interface {
+	// Count adds delta to the counter identified by name and labels.
+	Count(name string, delta float64, labels map[string]string)
+
+	// Gauge sets the gauge identified by name and labels to value.
+	Gauge(name string, value float64, labels map[string]string)
+
+	// Observe records value in the histogram identified by name and labels.
+	Observe(name string, value float64, labels map[string]string)
+}
+
+// record reports the result of a start or stop call of c that began at
+// began to the trace, the configured logger and metrics, and failures to
+// failed.
+func (g *Group) record(c *component, phase Phase, began time.Time, err error) {
+	d := time.Since(began)
+	if a := g.opts.adaptive; a != nil {
+		a.observe(c, phase, d)
+	}
+	g.observeStats(c, phase, d)
+	g.trace(c, phase, began, err)
+	g.logCall(c, phase, began, err)
+	if err != nil {
+		g.failed(c, phase, err)
+	}
+
+	m := g.opts.metrics
+	if m == nil {
+		return
+	}
+
+	outcome := OutcomeOK
+	switch {
+	case c.loadState() == StateTimedOut:
+		outcome = OutcomeTimeout
+	case err != nil:
+		outcome = OutcomeError
+	}
+
+	labels := make(map[string]string, len(c.labels)+3)
+	maps.Copy(labels, c.labels)
+	labels[LabelComponent] = c.name
+	labels[LabelPhase] = string(phase)
+	labels[LabelOutcome] = outcome
+	m.Count(MetricCalls, 1, labels)
+	m.Observe(MetricDuration, time.Since(began).Seconds(), labels)
+}
+
+// recordReady reports the group's readiness.
+func (g *Group) recordReady(ready bool) {
+	m := g.opts.metrics
+	if m == nil {
+		return
+	}
+
+	var v float64
+	if ready {
+		v = 1
+	}
+	m.Gauge(MetricReady, v, nil)
+}
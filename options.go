@@ -1,22 +1,96 @@
 package run
 
-import "time"
+import (
+	"log/slog"
+	"time"
+)
 
 // DefaultTimeout is the default duration used for both starting and stopping
 // an application. It can be customized using the WithStartTimeout and
 // WithStopTimeout options.
 const DefaultTimeout = 15 * time.Second
 
+// DefaultConcurrency is the default number of start or stop functions that
+// may run at the same time. It can be customized using the WithConcurrency
+// option.
+const DefaultConcurrency = 64
+
 // options holds configurable parameters for the Group's behavior.
 type options struct {
-	startTimeout time.Duration // maximum allowed time for start functions to complete
-	stopTimeout  time.Duration // maximum allowed time for stop functions to complete
+	startTimeout     time.Duration // maximum allowed time for start functions to complete
+	stopTimeout      time.Duration // maximum allowed time for stop functions to complete
+	drainTimeout     time.Duration // maximum allowed time for deregistering and draining
+	forceStopTimeout time.Duration // maximum allowed time for force stop functions to complete
+	concurrency      int           // maximum number of start or stop functions running at once
+
+	startWaveTimeout time.Duration // maximum time for a single start wave, zero for none
+	stopWaveTimeout  time.Duration // maximum time for a single stop wave, zero for none
+	rotateTimeout    time.Duration // maximum time for rotating a secret
+	reloadTimeout    time.Duration // maximum time for reloading
+	verifyTimeout    time.Duration // maximum time for a single verify call
+	warmupTimeout    time.Duration // maximum time for the warmup phase
+
+	stopClasses []string // stop classes in stop order, nil to mirror the start order
+
+	progress func(Progress) // called as components finish starting, nil for none
+	metrics  Metrics        // receives lifecycle measurements, nil for none
+	logger   *slog.Logger   // receives lifecycle logs, nil for none
+	stateLog *slog.Level    // level of state change logs, nil for none
+	selector Selector       // chooses the components to run, nil for all
+	disabled Selector       // chooses the components to skip, nil for none
+	gate     Gate           // decides whether components may start, nil to allow all
+	locker   Locker         // held while components run, nil for none
+
+	registrars   []Registrar // announce the instance while it runs, in registration order
+	recorderSize int         // number of events kept by the flight recorder, zero to disable
+	errorsBuffer int         // capacity of the Errors channel
+	errorLimit   int         // maximum number of errors joined per phase, zero for no limit
+	firstError   bool        // Wait returns only the first error
+	leakCheck    bool        // check for leaked resources after the stop phase
+
+	listeners  []listenerSpec // bound before components start, in declaration order
+	portChecks []listenerSpec // checked for conflicts before components start
+
+	transform func(component string, err error) error                 // applied to every call error, nil for none
+	classify  func(component string, phase Phase, err error) Severity // assigns error severities, nil for none
+
+	onError func(component string, phase Phase, err error) // called for every failed call, nil for none
+
+	restartLimit int           // restarts allowed per restartPer across the group, zero for no limit
+	restartPer   time.Duration // period restartLimit applies to
+
+	quorums       []quorumRule  // sets of redundant components declared with WithQuorum
+	timeoutPolicy TimeoutPolicy // per-component start and stop timeouts, nil for none
+	adaptive      *adaptive     // learns stop timeouts from call durations, nil for none
+	stats         StatsStore    // persists lifecycle statistics, nil for none
+
+	budgetAlert     func(BudgetAlert) // called as phases use up their timeouts, nil for none
+	budgetFractions []float64         // fractions of the timeouts at which budgetAlert is called
+
+	alert          func(Alert) // called for failures repeating across runs, nil for none
+	alertThreshold int         // consecutive runs with a failure before alert is called
+
+	healthPolicy *HealthPolicy // polls health probes while the group runs, nil for none
+
+	healthFile         string        // file the health tier is written to, empty for none
+	healthFileInterval time.Duration // how often healthFile is rewritten
 }
 
 // defaultOptions provides the default timeout values used by NewGroup.
 var defaultOptions = options{
 	startTimeout: DefaultTimeout,
 	stopTimeout:  DefaultTimeout,
+
+	drainTimeout:     DefaultDrainTimeout,
+	forceStopTimeout: DefaultForceStopTimeout,
+	concurrency:      DefaultConcurrency,
+
+	rotateTimeout: DefaultTimeout,
+	reloadTimeout: DefaultTimeout,
+	verifyTimeout: DefaultVerifyTimeout,
+	warmupTimeout: DefaultTimeout,
+	recorderSize:  DefaultRecorderSize,
+	errorsBuffer:  DefaultErrorsBuffer,
 }
 
 // Option is a functional option that modifies Group's internal options.
@@ -34,7 +108,8 @@ func (f optionFunc) apply(o *options) {
 
 // WithStartTimeout returns an Option that sets the timeout duration for
 // starting components. This timeout controls how long Wait() will wait for
-// all Start functions to complete before timing out.
+// all Start functions to complete before timing out. An earlier deadline of
+// the context passed to Wait takes precedence.
 //
 // Default is DefaultTimeout (15 seconds).
 func WithStartTimeout(v time.Duration) Option {
@@ -53,3 +128,362 @@ func WithStopTimeout(v time.Duration) Option {
 		o.stopTimeout = v
 	})
 }
+
+// WithForceStopTimeout returns an Option that sets the timeout of the
+// forceful stop phase, which follows the graceful stop phase bounded by the
+// stop timeout and calls the force stop functions registered with ForceStop
+// of components that did not stop cleanly. The two budgets are independent:
+// a graceful stop that hangs until the stop timeout does not shorten the
+// forceful one.
+//
+// Default is DefaultForceStopTimeout (5 seconds).
+func WithForceStopTimeout(v time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.forceStopTimeout = v
+	})
+}
+
+// WithConcurrency returns an Option that limits how many start or stop
+// functions run at the same time. Components are executed by a bounded pool
+// of worker goroutines instead of one goroutine per component, which keeps
+// memory and scheduler overhead flat for groups with thousands of components.
+// Phases start no goroutines beyond these workers, which also keeps short
+// lived groups, such as those of job binaries, cheap.
+//
+// Values less than one are treated as one.
+//
+// Default is DefaultConcurrency (64).
+func WithConcurrency(n int) Option {
+	return optionFunc(func(o *options) {
+		o.concurrency = max(n, 1)
+	})
+}
+
+// WithStartWaveTimeout returns an Option that bounds each start wave — the
+// set of components whose dependencies have started — in addition to the
+// overall start timeout. A wave gets the smaller of this timeout and the
+// remaining start budget, so a slow early wave cannot silently consume the
+// time meant for later ones. A wave that exceeds it fails the start phase
+// with an error wrapping ErrStartContextDeadlineExceeded that names the
+// components still starting.
+//
+// Default is zero, meaning waves are bounded only by the start timeout.
+func WithStartWaveTimeout(v time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.startWaveTimeout = v
+	})
+}
+
+// WithStopWaveTimeout returns an Option that bounds each stop wave in
+// addition to the overall stop timeout. A wave that exceeds it is abandoned
+// with an error wrapping ErrStopContextDeadlineExceeded, and stopping moves
+// on to the next wave with the remaining budget.
+//
+// Default is zero, meaning waves are bounded only by the stop timeout.
+func WithStopWaveTimeout(v time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.stopWaveTimeout = v
+	})
+}
+
+// WithStopClasses returns an Option that declares stop classes, stopped
+// sequentially in the given order with all components of a class stopping
+// concurrently — for example "listeners", "workers", "stores", "telemetry".
+// Components are assigned to a class with the StopClass component option.
+// Within a class, dependencies are still honored: the class stops in waves,
+// the start waves of its components reversed, so a component never stops
+// before the components of its class that depend on it.
+//
+// This decouples the stop order from the start order when the two do not
+// simply mirror each other. Components without a class belong to the empty
+// class "", which is stopped first unless it is listed explicitly.
+//
+// Default is no classes: stop mirrors the start waves in reverse.
+func WithStopClasses(classes ...string) Option {
+	return optionFunc(func(o *options) {
+		o.stopClasses = classes
+	})
+}
+
+// WithProgress returns an Option that installs a callback invoked every time
+// a component finishes starting or warming up, with the number of components
+// done so far and the ones still being waited on. It is meant for progress logs of
+// slow-booting services and for spinners in development tooling.
+//
+// Calls are serialized but run on the goroutines starting components, so the
+// callback should return quickly.
+func WithProgress(fn func(Progress)) Option {
+	return optionFunc(func(o *options) {
+		o.progress = fn
+	})
+}
+
+// WithMetrics returns an Option that reports lifecycle measurements to m:
+// a MetricCalls counter and a MetricDuration histogram for every start and
+// stop call, and the MetricReady gauge.
+//
+// Default is no metrics.
+func WithMetrics(m Metrics) Option {
+	return optionFunc(func(o *options) {
+		o.metrics = m
+	})
+}
+
+// WithLogger returns an Option that logs the outcome of every start and stop
+// call to l, with the component name, phase, duration and error as
+// attributes, as well as group readiness and the beginning of shutdown.
+// Failures and calls that returned after their deadline are logged at error
+// level, everything else at info level.
+//
+// Default is no logging.
+func WithLogger(l *slog.Logger) Option {
+	return optionFunc(func(o *options) {
+		o.logger = l
+	})
+}
+
+// WithTransitionLog returns an Option that also logs every state change of
+// every component to the logger set with WithLogger as it happens, so that
+// tailing the logs during a deploy shows where startup and shutdown spend
+// their time. Each entry is logged at level with the message "component "
+// followed by the new state, such as "component stopping", and carries the
+// same attributes: component, phase, state, the previous state, elapsed,
+// the time spent in the previous state, and, when the component settles in
+// a state such as running or failed, the outcome as one of the Outcome
+// constants.
+//
+// Default is logging only the outcome of calls.
+func WithTransitionLog(level slog.Level) Option {
+	return optionFunc(func(o *options) {
+		o.stateLog = &level
+	})
+}
+
+// WithSelector returns an Option that runs only the components matched by
+// sel, together with every component they depend on. Components that are not
+// needed are skipped as if disabled and are listed as such by Plan. This lets
+// one binary with one wiring function run in different roles:
+//
+//	run.NewGroup(run.WithSelector(run.AnyOf(run.Tagged(role), run.Untagged())))
+//
+// Default is to run all components.
+func WithSelector(sel Selector) Option {
+	return optionFunc(func(o *options) {
+		o.selector = sel
+	})
+}
+
+// WithDisabled returns an Option that skips the components matched by sel,
+// as if they had been registered with Enabled(false), together with every
+// component that depends on them.
+//
+// Default is to skip nothing.
+func WithDisabled(sel Selector) Option {
+	return optionFunc(func(o *options) {
+		o.disabled = sel
+	})
+}
+
+// WithGate returns an Option that consults gate for every component before
+// the start phase. Denied components are skipped as if disabled, together
+// with every component that depends on them, and are listed as such by Plan.
+// Group.Reload consults the gate again, stops running components it now
+// denies and starts skipped components it now allows.
+//
+// Default is to allow every component.
+func WithGate(gate Gate) Option {
+	return optionFunc(func(o *options) {
+		o.gate = gate
+	})
+}
+
+// WithLock returns an Option that acquires l before the first component
+// starts and releases it as the final step of shutdown, after every stop
+// function has returned, so only one instance of a singleton worker runs at
+// a time. Acquiring the lock counts towards the start timeout.
+//
+// Default is no lock.
+func WithLock(l Locker) Option {
+	return optionFunc(func(o *options) {
+		o.locker = l
+	})
+}
+
+// WithReloadTimeout returns an Option that sets how long Group.Reload waits
+// for components to be stopped or started by the gate and for reloaders to
+// return.
+//
+// Default is DefaultTimeout (15 seconds).
+func WithReloadTimeout(v time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.reloadTimeout = v
+	})
+}
+
+// WithWarmupTimeout returns an Option that sets how long the warmup phase,
+// in which the warmers registered with Warms run, may take. It is
+// independent of the start timeout. A warmup that exceeds it fails like a
+// start that timed out, with an error wrapping
+// ErrWarmupContextDeadlineExceeded.
+//
+// Default is DefaultTimeout (15 seconds).
+func WithWarmupTimeout(v time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.warmupTimeout = v
+	})
+}
+
+// WithVerifyTimeout returns an Option that sets how long each check
+// registered with Verify may take. It counts towards the start timeout.
+//
+// Default is DefaultVerifyTimeout (5 seconds).
+func WithVerifyTimeout(v time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.verifyTimeout = v
+	})
+}
+
+// WithRotateTimeout returns an Option that sets how long Group.Rotate waits
+// for rotators to switch to a rotated secret.
+//
+// Default is DefaultTimeout (15 seconds).
+func WithRotateTimeout(v time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.rotateTimeout = v
+	})
+}
+
+// WithRegistrar returns an Option that registers the instance with r once
+// every component has started, before the group reports ready, and
+// deregisters it as the very first step of shutdown, before any component
+// stops. A failed registration fails the start. The option may be given more
+// than once; registrars are deregistered in reverse order.
+//
+// Default is no registrars.
+func WithRegistrar(r Registrar) Option {
+	return optionFunc(func(o *options) {
+		o.registrars = append(o.registrars, r)
+	})
+}
+
+// WithRecorderSize returns an Option that sets how many recent lifecycle
+// events the flight recorder returned by Group.Recorder keeps. Zero or less
+// disables it.
+//
+// Default is DefaultRecorderSize (256).
+func WithRecorderSize(n int) Option {
+	return optionFunc(func(o *options) {
+		o.recorderSize = n
+	})
+}
+
+// WithErrorsBuffer returns an Option that sets the capacity of the channel
+// returned by Group.Errors. Errors are dropped from the channel while it is
+// full. Values less than zero are treated as zero.
+//
+// Default is DefaultErrorsBuffer (64).
+func WithErrorsBuffer(n int) Option {
+	return optionFunc(func(o *options) {
+		o.errorsBuffer = max(n, 0)
+	})
+}
+
+// WithOnError returns an Option that calls fn for every failed start, stop,
+// reload or rotate call as it happens, with the component name and phase,
+// so each failure can be logged or sent to an error tracker with full
+// context regardless of how the error returned by Wait is aggregated.
+//
+// fn runs synchronously on the goroutine that made the call, so it should
+// return quickly.
+func WithOnError(fn func(component string, phase Phase, err error)) Option {
+	return optionFunc(func(o *options) {
+		o.onError = fn
+	})
+}
+
+// WithErrorLimit returns an Option that caps the errors joined for each
+// phase at n. The remaining errors are summarized in a single
+// SuppressedError counting them by message, which keeps the error of a huge
+// group that fails the same way everywhere, for example because the network
+// is down, readable. Zero or less means no limit.
+//
+// Default is no limit.
+func WithErrorLimit(n int) Option {
+	return optionFunc(func(o *options) {
+		o.errorLimit = n
+	})
+}
+
+// WithFirstError returns an Option that makes Wait return only the first
+// start error to occur — the root cause, rather than a wall of cascading
+// failures — which suits command-line tools. All stops still run, and every
+// error stays available through Group.AllErrors. Without a start error, the
+// first error of the joined list is returned.
+//
+// Default is to return all errors joined.
+func WithFirstError() Option {
+	return optionFunc(func(o *options) {
+		o.firstError = true
+	})
+}
+
+// WithErrorTransformer returns an Option that passes every error returned by
+// a start, stop, reload or rotate call through fn before anything else sees
+// it, for example to wrap it with internal error codes or to redact secrets
+// from its message. Returning nil drops the error: the call is treated as
+// successful, so fn can ignore known-benign errors.
+//
+// Default is no transformation.
+func WithErrorTransformer(fn func(component string, err error) error) Option {
+	return optionFunc(func(o *options) {
+		o.transform = fn
+	})
+}
+
+// WithClassifier returns an Option that assigns a Severity to every start
+// and stop error, deciding whether it aborts the group, degrades it or is
+// only logged. Errors marked by components with Degraded or Ignorable can be
+// recognized with SeverityOf.
+//
+// A component that fails with a non-fatal severity stays failed, and its
+// dependents are not started and fail with ErrDependencyFailed and the same
+// severity, while the rest of the group keeps running.
+//
+// Default is to keep the severity components marked their errors with,
+// SeverityFatal otherwise.
+func WithClassifier(fn func(component string, phase Phase, err error) Severity) Option {
+	return optionFunc(func(o *options) {
+		o.classify = fn
+	})
+}
+
+// WithRestartLimit returns an Option that allows at most n restarts of
+// components supervised with Restart every per, across the whole group. A
+// restart beyond the limit waits, after its own backoff, until the limit
+// allows it, so an outage of a shared dependency does not make every
+// component restart in a synchronized storm against it as it recovers.
+// Restarts are spread evenly over the period, with bursts of up to n.
+//
+// Default is no limit.
+func WithRestartLimit(n int, per time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.restartLimit, o.restartPer = n, per
+	})
+}
+
+// WithLeakCheck returns an Option that audits the process for resources
+// leaked by components once the stop phase has completed: goroutines and,
+// on Linux, file descriptors added since Wait began, and listeners passed to
+// Group.TrackListener that are still open. Leaks are reported as a
+// LeakError through the same path as checks registered with
+// Group.StopCheck, so long-running test suites and canaries catch leaks
+// introduced by new components. The audit gives goroutines up to a second to
+// exit and assumes nothing else in the process starts or stops goroutines
+// meanwhile.
+//
+// Default is no audit.
+func WithLeakCheck() Option {
+	return optionFunc(func(o *options) {
+		o.leakCheck = true
+	})
+}
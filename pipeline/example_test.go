@@ -0,0 +1,45 @@
+package pipeline_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/pipeline"
+)
+
+func ExampleSink() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup()
+	lines := pipeline.Source(g, "reader", func(ctx context.Context, out chan<- string) error {
+		for _, line := range []string{"a", "b", "c"} {
+			out <- line
+		}
+		cancel()
+		<-ctx.Done()
+		return nil
+	})
+	upper := pipeline.Stage(g, "upper", lines, func(ctx context.Context, in <-chan string, out chan<- string) error {
+		for line := range in {
+			out <- strings.ToUpper(line)
+		}
+		return nil
+	}, pipeline.WithBuffer(8))
+	pipeline.Sink(g, "writer", upper, func(ctx context.Context, in <-chan string) error {
+		for line := range in {
+			fmt.Println(line)
+		}
+		fmt.Println("writer drained")
+		return nil
+	})
+
+	fmt.Println(g.Wait(ctx))
+	// Output:
+	// A
+	// B
+	// C
+	// writer drained
+	// <nil>
+}
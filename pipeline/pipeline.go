@@ -0,0 +1,158 @@
+// Package pipeline connects run.Group components into producer → consumer
+// stages linked by channels, with the shutdown order pipelines need: the
+// source stops first, every channel is closed exactly once by the stage
+// writing to it, and each downstream stage drains its input before it
+// returns.
+//
+// A pipeline is declared from its source to its sink:
+//
+//	events := pipeline.Source(g, "kafka", consume)
+//	enriched := pipeline.Stage(g, "enrich", events, enrich)
+//	pipeline.Sink(g, "store", enriched, store)
+//
+// Every stage is a run-style component as added with Group.Go. A stage is
+// registered with the group when its output is consumed, so every pipe
+// returned by Source or Stage must be passed to exactly one Stage or Sink: a
+// stage whose output is never consumed is not run, and one whose output is
+// consumed twice makes Wait fail with run.ErrDuplicateName.
+//
+// Stages must not be combined with the run.Restart component option, since
+// their output channel is closed when their function returns.
+package pipeline
+
+import (
+	"context"
+	"sync"
+
+	"github.com/not-for-prod/run"
+)
+
+// Option configures a stage.
+type Option func(*config)
+
+// config holds the settings of a stage.
+type config struct {
+	buffer int
+	opts   []run.ComponentOption
+}
+
+// WithBuffer returns an Option that sets the capacity of the stage's output
+// channel.
+//
+// Default is an unbuffered channel.
+func WithBuffer(n int) Option {
+	return func(c *config) {
+		c.buffer = max(n, 0)
+	}
+}
+
+// WithComponentOptions returns an Option that passes further component
+// options, such as run.Label or run.Critical, to the stage's component.
+func WithComponentOptions(opts ...run.ComponentOption) Option {
+	return func(c *config) {
+		c.opts = append(c.opts, opts...)
+	}
+}
+
+// Pipe is the output of a stage, to be consumed by exactly one Stage or
+// Sink.
+type Pipe[T any] struct {
+	ch       chan T
+	register func(consumer string) *run.Handle
+	handle   *run.Handle
+}
+
+// Handle returns the handle of the stage producing into p, or nil before p
+// has been consumed.
+func (p *Pipe[T]) Handle() *run.Handle {
+	return p.handle
+}
+
+// consume registers the stage producing into p so that it starts after and
+// stops before consumer, and returns p's channel.
+func (p *Pipe[T]) consume(consumer string) <-chan T {
+	p.handle = p.register(consumer)
+	return p.ch
+}
+
+// Source declares the first stage of a pipeline: produce writes to out until
+// its context is canceled when the group stops, after which out is closed.
+func Source[T any](g *run.Group, name string, produce func(ctx context.Context, out chan<- T) error, opts ...Option) *Pipe[T] {
+	c := newConfig(opts)
+	out := make(chan T, c.buffer)
+	return &Pipe[T]{ch: out, register: func(consumer string) *run.Handle {
+		return g.Go(func(ctx context.Context) error {
+			defer close(out)
+			return produce(ctx, out)
+		}, c.options(name, consumer)...)
+	}}
+}
+
+// Stage declares an intermediate stage: fn reads from in until it is
+// closed, writing to out, after which out is closed. fn's context is not
+// canceled when the group stops, so that it drains in; it is canceled only
+// if the stage has not returned within the stop timeout.
+func Stage[In, Out any](g *run.Group, name string, in *Pipe[In], fn func(ctx context.Context, in <-chan In, out chan<- Out) error, opts ...Option) *Pipe[Out] {
+	c := newConfig(opts)
+	src := in.consume(name)
+	out := make(chan Out, c.buffer)
+	return &Pipe[Out]{ch: out, register: func(consumer string) *run.Handle {
+		return drain(g, c.options(name, consumer), func(ctx context.Context) error {
+			defer close(out)
+			return fn(ctx, src, out)
+		})
+	}}
+}
+
+// Sink declares the last stage of a pipeline and returns its handle: fn
+// reads from in until it is closed. Like with Stage, fn's context is
+// canceled only if the sink has not returned within the stop timeout.
+func Sink[T any](g *run.Group, name string, in *Pipe[T], fn func(ctx context.Context, in <-chan T) error, opts ...Option) *run.Handle {
+	c := newConfig(opts)
+	src := in.consume(name)
+	return drain(g, c.options(name, ""), func(ctx context.Context) error {
+		return fn(ctx, src)
+	})
+}
+
+// drain adds a run-style component whose function is not canceled when the
+// component stops, only when it is forced to.
+func drain(g *run.Group, opts []run.ComponentOption, fn func(ctx context.Context) error) *run.Handle {
+	var (
+		mu     sync.Mutex
+		cancel context.CancelFunc = func() {}
+	)
+	opts = append(opts, run.ForceStop(func(context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		cancel()
+		return nil
+	}))
+	return g.Go(func(ctx context.Context) error {
+		ctx, stop := context.WithCancel(context.WithoutCancel(ctx))
+		defer stop()
+		mu.Lock()
+		cancel = stop
+		mu.Unlock()
+		return fn(ctx)
+	}, opts...)
+}
+
+// newConfig applies opts.
+func newConfig(opts []Option) config {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// options returns the component options of the stage name, which stops
+// before consumer, if any.
+func (c config) options(name, consumer string) []run.ComponentOption {
+	opts := []run.ComponentOption{run.Named(name)}
+	if consumer != "" {
+		opts = append(opts, run.DependsOn(consumer))
+	}
+	return append(opts, c.opts...)
+}
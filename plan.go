@@ -0,0 +1,109 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Plan describes how Wait would start and stop the group's components,
+// computed without running any of them.
+type Plan struct {
+	// Start lists the start waves in order. Steps within a wave start
+	// concurrently; a wave starts once the previous one has started.
+	Start [][]PlanStep
+
+	// Stop lists the stop waves in order, the reverse of Start.
+	Stop [][]PlanStep
+
+	// Disabled lists the components that will be skipped, in registration
+	// order.
+	Disabled []string
+
+	// StartTimeout and StopTimeout are the budgets of the whole start and
+	// stop phases.
+	StartTimeout time.Duration
+	StopTimeout  time.Duration
+}
+
+// PlanStep is a single component within a plan wave.
+type PlanStep struct {
+	Name    string        // component name
+	Timeout time.Duration // effective timeout of the component's start or stop call, the shorter of its own and the wave's
+}
+
+// Plan returns the execution plan of the group without starting anything.
+// It fails with the same errors as Wait when the components cannot be
+// ordered, such as a missing provider or a dependency cycle. A gate set with
+// WithGate is consulted with a background context.
+func (g *Group) Plan() (Plan, error) {
+	s, err := g.schedule(context.Background())
+	if err != nil {
+		return Plan{}, err
+	}
+
+	p := Plan{
+		StartTimeout: g.opts.startTimeout,
+		StopTimeout:  g.opts.stopTimeout,
+	}
+	p.Start = planWaves(s.waves, waveBudget(p.StartTimeout, g.opts.startWaveTimeout), g.startTimeout)
+	p.Stop = planWaves(s.stopWaves(func(*component) bool { return true }),
+		waveBudget(p.StopTimeout, g.opts.stopWaveTimeout), g.stopTimeout)
+	for _, c := range s.disabled {
+		p.Disabled = append(p.Disabled, c.name)
+	}
+	return p, nil
+}
+
+// planWaves converts component waves into plan steps, bounding each step by
+// the wave budget and by the component's own timeout, zero for none.
+func planWaves(waves [][]*component, budget time.Duration, timeout func(*component) time.Duration) [][]PlanStep {
+	steps := make([][]PlanStep, len(waves))
+	for i, wave := range waves {
+		steps[i] = make([]PlanStep, len(wave))
+		for j, c := range wave {
+			d := budget
+			if own := timeout(c); own > 0 {
+				d = min(d, own)
+			}
+			steps[i][j] = PlanStep{Name: c.name, Timeout: d}
+		}
+	}
+	return steps
+}
+
+// waveBudget returns the effective timeout of a wave within a phase.
+func waveBudget(phase, wave time.Duration) time.Duration {
+	if wave > 0 {
+		return min(phase, wave)
+	}
+	return phase
+}
+
+// String formats the plan for humans, one wave per line. Step timeouts are
+// shown only where they differ from the phase budget.
+func (p Plan) String() string {
+	var b strings.Builder
+
+	writeWaves := func(phase string, waves [][]PlanStep, timeout time.Duration) {
+		fmt.Fprintf(&b, "%s (timeout %s):\n", phase, timeout)
+		for i, wave := range waves {
+			names := make([]string, len(wave))
+			for j, step := range wave {
+				names[j] = step.Name
+				if step.Timeout != timeout {
+					names[j] += fmt.Sprintf(" (timeout %s)", step.Timeout)
+				}
+			}
+			fmt.Fprintf(&b, "  %d: %s\n", i+1, strings.Join(names, ", "))
+		}
+	}
+
+	writeWaves("start", p.Start, p.StartTimeout)
+	writeWaves("stop", p.Stop, p.StopTimeout)
+	if len(p.Disabled) > 0 {
+		fmt.Fprintf(&b, "disabled: %s\n", strings.Join(p.Disabled, ", "))
+	}
+	return b.String()
+}
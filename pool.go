@@ -0,0 +1,298 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// errPending marks batch tasks that have not returned yet.
+var errPending = errors.New("pending")
+
+// batch runs a fixed number of tasks on a bounded set of worker goroutines.
+//
+// Workers claim task indexes from a shared counter, so the number of
+// goroutines depends on the configured concurrency rather than on the number
+// of registered components. Each result is stored at its task index, which
+// avoids allocating a channel per phase, and the last worker to return
+// completes the batch, so no goroutine waits for the workers.
+type batch struct {
+	fn      func(i int) error // task executed for every index in [0, n)
+	n       int               // number of tasks
+	next    atomic.Int64      // next unclaimed task index
+	working atomic.Int64      // workers that have not returned yet
+	then    func()            // called once every task has returned, nil for none
+
+	mu        sync.Mutex
+	errs      []error // errs[i] holds the error returned by task i, or errPending
+	abandoned bool    // set once the caller stopped waiting; later results are dropped
+
+	done chan struct{} // closed when every task has returned
+}
+
+// execute starts n tasks on at most g.opts.concurrency workers and returns
+// immediately. The returned batch reports completion through its done channel
+// and by calling then, unless nil, from the last worker.
+//
+// The batch borrows the group's error buffer when it is large enough; callers
+// hand it back with release once the batch is finished.
+func (g *Group) execute(n int, fn func(i int) error, then func()) *batch {
+	b := g.newBatch(n, fn)
+	b.start(g.opts.concurrency, then)
+	return b
+}
+
+// newBatch returns a batch of n tasks that has not started yet.
+func (g *Group) newBatch(n int, fn func(i int) error) *batch {
+	g.mu.Lock()
+	errs := g.errs
+	g.errs = nil
+	g.mu.Unlock()
+	if cap(errs) < n {
+		errs = make([]error, n)
+	}
+
+	b := &batch{
+		fn:   fn,
+		n:    n,
+		errs: errs[:n],
+		done: make(chan struct{}),
+	}
+	for i := range b.errs {
+		b.errs[i] = errPending
+	}
+	return b
+}
+
+// start runs the batch's tasks on at most concurrency workers and calls then
+// once every task has returned.
+func (b *batch) start(concurrency int, then func()) {
+	b.then = then
+	workers := min(b.n, concurrency)
+	if workers == 0 {
+		b.finish()
+		return
+	}
+
+	b.working.Store(int64(workers))
+	for range workers {
+		go func() {
+			b.work()
+			if b.working.Add(-1) == 0 {
+				b.finish()
+			}
+		}()
+	}
+}
+
+// finish completes the batch.
+func (b *batch) finish() {
+	close(b.done)
+	if b.then != nil {
+		b.then()
+	}
+}
+
+// release returns the batch's error buffer to the group so the next phase can
+// reuse it. Buffers of batches with tasks still in flight are never reused.
+func (g *Group) release(b *batch) {
+	select {
+	case <-b.done:
+	default:
+		return
+	}
+
+	clear(b.errs)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if cap(b.errs) > cap(g.errs) {
+		g.errs = b.errs[:0]
+	}
+}
+
+// work claims and runs tasks until none are left.
+func (b *batch) work() {
+	for {
+		i := int(b.next.Add(1) - 1)
+		if i >= b.n {
+			return
+		}
+
+		err := b.fn(i)
+
+		b.mu.Lock()
+		if !b.abandoned {
+			b.errs[i] = err
+		}
+		b.mu.Unlock()
+	}
+}
+
+// errors returns the errors collected so far in task index order.
+//
+// After errors is called the batch is abandoned: tasks still in flight keep
+// running, but their results are discarded.
+func (b *batch) errors() []error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.abandoned = true
+
+	var errs []error
+	for _, err := range b.errs {
+		if err != nil && err != errPending {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// unfinished returns the indexes of tasks that have not returned yet.
+func (b *batch) unfinished() []int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var pending []int
+	for i, err := range b.errs {
+		if err == errPending {
+			pending = append(pending, i)
+		}
+	}
+	return pending
+}
+
+// phase runs waves of components one after another, each wave as a batch.
+type phase struct {
+	mu        sync.Mutex
+	errs      []error // errors of completed waves, in wave order
+	current   *batch  // wave in flight, nil between waves
+	abandoned bool    // set once the caller stopped waiting; no further waves start
+
+	done chan struct{} // closed when the phase finished or gave up
+}
+
+// phaseConfig controls how runWaves executes a phase.
+type phaseConfig struct {
+	failFast    bool          // end the phase after the first wave that returns errors
+	waveTimeout time.Duration // maximum duration of a single wave, zero for none
+	deadlineErr error         // error wrapped when a wave exceeds waveTimeout
+	inFlight    State         // state of components whose call is running
+}
+
+// runWaves executes fn for every component, wave by wave, and returns
+// immediately. No further waves are started once ctx is done.
+//
+// Each wave runs with a context bounded by cfg.waveTimeout, which is passed
+// to fn. A wave that exceeds it is abandoned and reported as an error naming
+// the components still running.
+//
+// The phase needs no goroutine of its own: the last worker of a wave starts
+// the next one, and a wave's deadline is watched with context.AfterFunc.
+func (g *Group) runWaves(ctx context.Context, waves [][]*component, cfg phaseConfig, fn func(ctx context.Context, c *component) error) *phase {
+	p := &phase{done: make(chan struct{})}
+	r := &waveRun{g: g, p: p, ctx: ctx, waves: waves, cfg: cfg, fn: fn}
+	r.wave(0)
+	return p
+}
+
+// waveRun is the progress of runWaves through its waves.
+type waveRun struct {
+	g     *Group
+	p     *phase
+	ctx   context.Context
+	waves [][]*component
+	cfg   phaseConfig
+	fn    func(ctx context.Context, c *component) error
+}
+
+// wave starts wave n, or finishes the phase if it is over.
+func (r *waveRun) wave(n int) {
+	if n == len(r.waves) {
+		close(r.p.done)
+		return
+	}
+	wave := r.waves[n]
+
+	waveCtx, cancel := r.ctx, context.CancelFunc(func() {})
+	if r.cfg.waveTimeout > 0 {
+		waveCtx, cancel = context.WithTimeout(r.ctx, r.cfg.waveTimeout)
+	}
+
+	r.p.mu.Lock()
+	if r.p.abandoned || r.ctx.Err() != nil {
+		r.p.mu.Unlock()
+		cancel()
+		close(r.p.done)
+		return
+	}
+	b := r.g.newBatch(len(wave), func(i int) error {
+		return r.fn(waveCtx, wave[i])
+	})
+	r.p.current = b
+	r.p.mu.Unlock()
+
+	// Whichever of the deadline and the last worker comes first ends the
+	// wave: stopping the deadline's function tells which one it was.
+	stopDeadline := context.AfterFunc(waveCtx, func() {
+		defer cancel()
+		if r.ctx.Err() != nil {
+			close(r.p.done)
+			return
+		}
+		var timedOut []string
+		for _, i := range b.unfinished() {
+			wave[i].transition(r.cfg.inFlight, StateTimedOut)
+			timedOut = append(timedOut, wave[i].name)
+		}
+		r.end(n, b, timedOut)
+	})
+	b.start(r.g.opts.concurrency, func() {
+		if stopDeadline() {
+			cancel()
+			r.end(n, b, nil)
+		}
+	})
+}
+
+// end collects the errors of wave n, run as b, together with an error
+// naming its timed out components, and moves on to the next wave.
+func (r *waveRun) end(n int, b *batch, timedOut []string) {
+	r.p.mu.Lock()
+	errs := b.errors()
+	if len(timedOut) > 0 {
+		errs = append(errs, fmt.Errorf("%w: wave %d: waiting on %s",
+			r.cfg.deadlineErr, n+1, strings.Join(timedOut, ", ")))
+	}
+	r.p.errs = append(r.p.errs, errs...)
+	r.p.current = nil
+	r.p.mu.Unlock()
+	r.g.release(b)
+
+	if r.cfg.failFast && len(errs) > 0 {
+		close(r.p.done)
+		return
+	}
+	r.wave(n + 1)
+}
+
+// errors returns the errors collected so far, including those of the wave in
+// flight, and abandons the phase.
+func (p *phase) errors() []error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.abandoned = true
+
+	errs := slices.Clone(p.errs)
+	if p.current != nil {
+		errs = append(errs, p.current.errors()...)
+	}
+	return errs
+}
@@ -0,0 +1,239 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrProbeFailed is returned, wrapping the probe's error, when a component
+// fails the health probe registered with Probe.
+var ErrProbeFailed = errors.New("probe failed")
+
+// ProbeAction is what the health poller does about a component whose probe
+// keeps failing or flaps.
+type ProbeAction int
+
+const (
+	// ProbeReport only reports the component in its ProbeResult and in the
+	// group's Health.
+	ProbeReport ProbeAction = iota
+
+	// ProbeRestart restarts the component like Group.RollingRestart.
+	ProbeRestart
+
+	// ProbeShutdown shuts the whole group down, and Wait returns an error
+	// wrapping ErrProbeFailed.
+	ProbeShutdown
+)
+
+// HealthPolicy configures the health poller enabled with
+// WithHealthPolling.
+type HealthPolicy struct {
+	// Interval is the time between two polls. Zero means 10 seconds.
+	Interval time.Duration
+
+	// Timeout bounds each probe call. Zero means the interval.
+	Timeout time.Duration
+
+	// FailureThreshold is the number of consecutive failures after which a
+	// component is considered failing. Zero means 3.
+	FailureThreshold int
+
+	// OnFailure is the action taken once a component is failing.
+	OnFailure ProbeAction
+
+	// FlapThreshold is the number of changes between passing and failing
+	// within FlapWindow after which a component is considered flapping.
+	// Zero disables flapping detection.
+	FlapThreshold int
+
+	// FlapWindow is the period FlapThreshold applies to. Zero means ten
+	// intervals.
+	FlapWindow time.Duration
+
+	// OnFlapping is the action taken once a component is flapping.
+	OnFlapping ProbeAction
+}
+
+// ProbeResult is the cached outcome of a component's health probe.
+type ProbeResult struct {
+	Err      error     // error of the latest probe, nil if it passed
+	Checked  time.Time // time of the latest probe
+	Failures int       // consecutive failed probes
+	Failing  bool      // Failures reached the failure threshold
+	Flapping bool      // the probe changed outcome too often recently
+}
+
+// probeState is the poller's record of a component's probe.
+type probeState struct {
+	result  ProbeResult
+	changes []time.Time // recent changes between passing and failing
+}
+
+// Probe returns a ComponentOption that registers check as the component's
+// health probe, evaluated periodically while the group runs by the poller
+// enabled with WithHealthPolling.
+func Probe(check func(ctx context.Context) error) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.probe = check
+	})
+}
+
+// WithHealthPolling returns an Option that evaluates the probes registered
+// with Probe every p.Interval while the group runs, from the moment it is
+// ready until shutdown begins. Results are cached, so Health and
+// HealthHandler stay cheap: a failing critical component makes the group
+// HealthDown, a failing non-critical or a flapping component makes it
+// HealthDegraded. Components that fail or flap are restarted or shut the
+// group down according to p.
+//
+// Default is no polling.
+func WithHealthPolling(p HealthPolicy) Option {
+	if p.Interval <= 0 {
+		p.Interval = 10 * time.Second
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = p.Interval
+	}
+	if p.FailureThreshold <= 0 {
+		p.FailureThreshold = 3
+	}
+	if p.FlapWindow <= 0 {
+		p.FlapWindow = 10 * p.Interval
+	}
+	return optionFunc(func(o *options) {
+		o.healthPolicy = &p
+	})
+}
+
+// ProbeResults returns the cached probe results of every component polled
+// so far, keyed by component name.
+func (g *Group) ProbeResults() map[string]ProbeResult {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	results := make(map[string]ProbeResult, len(g.probes))
+	for c, ps := range g.probes {
+		results[c.name] = ps.result
+	}
+	return results
+}
+
+// poll evaluates the probes of running components every interval until ctx
+// is canceled, then closes done.
+func (g *Group) poll(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	p := g.opts.healthPolicy
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		g.mu.Lock()
+		var probed []*component
+		for _, c := range g.registry.list() {
+			if c.probe != nil && c.loadState() == StateRunning {
+				probed = append(probed, c)
+			}
+		}
+		g.mu.Unlock()
+
+		b := g.execute(len(probed), func(i int) error {
+			g.probeOne(ctx, probed[i])
+			return nil
+		}, nil)
+		select {
+		case <-b.done:
+			g.release(b)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// probeOne calls c's probe, caches the result and takes the action the
+// policy prescribes.
+func (g *Group) probeOne(ctx context.Context, c *component) {
+	p := g.opts.healthPolicy
+
+	probeCtx, cancel := context.WithTimeout(withComponent(ctx, c), p.Timeout)
+	err := g.transform(c, c.probe(probeCtx))
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+	now := time.Now()
+
+	g.mu.Lock()
+	ps := g.probes[c]
+	if ps == nil {
+		ps = &probeState{}
+		g.probes[c] = ps
+	}
+	r := &ps.result
+	if (err != nil) != (r.Err != nil) && !r.Checked.IsZero() {
+		ps.changes = append(ps.changes, now)
+	}
+	for len(ps.changes) > 0 && now.Sub(ps.changes[0]) > p.FlapWindow {
+		ps.changes = ps.changes[1:]
+	}
+	r.Err, r.Checked = err, now
+	if err != nil {
+		r.Failures++
+	} else {
+		r.Failures = 0
+	}
+	failing := r.Failures >= p.FailureThreshold
+	flapping := p.FlapThreshold > 0 && len(ps.changes) >= p.FlapThreshold
+	newlyFailing, newlyFlapping := failing && !r.Failing, flapping && !r.Flapping
+	r.Failing, r.Flapping = failing, flapping
+	changes := len(ps.changes)
+	g.mu.Unlock()
+
+	switch {
+	case newlyFailing:
+		err = fmt.Errorf("%w: %w", ErrProbeFailed, err)
+		g.failed(c, PhaseProbe, err)
+		g.probeAction(ctx, c, p.OnFailure, err)
+	case newlyFlapping:
+		err = fmt.Errorf("%w: flapping (%d changes within %s)", ErrProbeFailed, changes, p.FlapWindow)
+		g.failed(c, PhaseProbe, err)
+		g.probeAction(ctx, c, p.OnFlapping, err)
+	}
+}
+
+// probeAction takes action about c, which failed its probe with err.
+func (g *Group) probeAction(ctx context.Context, c *component, action ProbeAction, err error) {
+	switch action {
+	case ProbeRestart:
+		if g.RollingRestart(ctx, Names(c.name), RollingPolicy{}) == nil {
+			g.mu.Lock()
+			delete(g.probes, c)
+			g.mu.Unlock()
+		}
+	case ProbeShutdown:
+		g.exitGroup(c.wrap(PhaseProbe, err))
+	}
+}
+
+// probeHealth returns the health tier the cached probe results imply. The
+// caller must hold g.mu.
+func (g *Group) probeHealth() Health {
+	health := HealthOK
+	for c, ps := range g.probes {
+		switch {
+		case ps.result.Failing && !c.optional:
+			return HealthDown
+		case ps.result.Failing, ps.result.Flapping:
+			health = HealthDegraded
+		}
+	}
+	return health
+}
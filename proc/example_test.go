@@ -0,0 +1,121 @@
+package proc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/proc"
+)
+
+func ExampleProcess_Register() {
+	g := run.NewGroup()
+
+	// The database starts first and is stopped last, with SIGTERM.
+	proc.New("sleep", []string{"60"}).Register(g, run.Named("db"))
+
+	// The worker keeps crashing: it is restarted twice, then the whole
+	// group shuts down.
+	proc.New("sh", []string{"-c", "exit 3"}).Register(g,
+		run.Named("worker"),
+		run.DependsOn("db"),
+		run.Restart(run.RestartPolicy{MaxRestarts: 2}),
+	)
+
+	err := g.Wait(context.Background())
+	fmt.Println(err)
+	fmt.Println("db:", g.States()["db"])
+	// Output:
+	// component "worker": restart limit exceeded (2 restarts): exit status 3
+	// db: stopped
+}
+
+func ExampleWithProcessGroup() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	g := run.NewGroup(run.WithStopTimeout(200 * time.Millisecond))
+
+	// The shell exits on SIGTERM, but the sleep it started ignores it and
+	// is still running at the stop timeout.
+	script := `(trap "" TERM; exec sleep 60) & wait`
+	proc.New("sh", []string{"-c", script}, proc.WithProcessGroup()).Register(g, run.Named("agent"))
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+		}
+	})
+
+	err := g.Wait(ctx)
+	var orphans *proc.OrphanError
+	if errors.As(err, &orphans) {
+		fmt.Println("orphans killed:", len(orphans.PIDs))
+	}
+	// Output:
+	// orphans killed: 1
+}
+
+func ExampleWithLogs() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	g := run.NewGroup()
+	script := `echo "listening on :9090"; echo '{"level":"error","msg":"disk full","free_mb":0}'`
+	proc.New("sh", []string{"-c", script}, proc.WithLogs(proc.LogOptions{Logger: logger, JSON: true})).
+		Register(g, run.Named("exporter"), run.Label("team", "infra"))
+
+	err := g.Wait(context.Background())
+	fmt.Println(errors.Is(err, run.ErrExited))
+	// Output:
+	// level=INFO msg="listening on :9090" stream=stdout component=exporter labels.team=infra
+	// level=ERROR msg="disk full" stream=stdout component=exporter labels.team=infra free_mb=0
+	// true
+}
+
+func ExampleWithExitPolicy() {
+	dir, _ := os.MkdirTemp("", "proc")
+	defer os.RemoveAll(dir)
+
+	// The sidecar asks to be retried twice, then reports a broken config.
+	script := `n=$(($(cat runs 2>/dev/null || echo 0) + 1)); echo $n > runs; [ $n -le 2 ] && exit 75; exit 78`
+	sidecar := proc.New("sh", []string{"-c", script}, proc.WithDir(dir), proc.WithExitPolicy(proc.ExitPolicy{
+		Codes:   map[int]proc.ExitAction{75: proc.ExitRestart},
+		Default: proc.ExitFail,
+		Restart: run.RestartPolicy{Backoff: 10 * time.Millisecond},
+	}))
+
+	g := run.NewGroup()
+	sidecar.Register(g, run.Named("sidecar"))
+
+	err := g.Wait(context.Background())
+	fmt.Println(err)
+	fmt.Println("restarts:", g.Status()[0].Restarts)
+	// Output:
+	// exit status 78
+	// restarts: 2
+}
+
+func ExampleExitPolicy_success() {
+	// A one-shot job that succeeds is done, not failed, even though every
+	// other exit fails it.
+	job := proc.New("true", nil, proc.WithExitPolicy(proc.ExitPolicy{Default: proc.ExitFail}))
+
+	g := run.NewGroup()
+	job.Register(g, run.Named("migrate"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	fmt.Println(g.Wait(ctx))
+	// Output:
+	// <nil>
+}
@@ -0,0 +1,129 @@
+package proc
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strconv"
+
+	"github.com/not-for-prod/run"
+)
+
+// ExitAction is what happens when a process exits on its own.
+type ExitAction int
+
+const (
+	// ExitFail fails the component, which shuts the group down unless the
+	// component was registered with run.Critical(false).
+	ExitFail ExitAction = iota
+
+	// ExitComplete treats the process as done with its work: the component
+	// stays up, without a process, until the group stops.
+	ExitComplete
+
+	// ExitRestart restarts the process according to the policy's Restart.
+	ExitRestart
+)
+
+// String returns the lower-case name of the action.
+func (a ExitAction) String() string {
+	switch a {
+	case ExitFail:
+		return "fail"
+	case ExitComplete:
+		return "complete"
+	case ExitRestart:
+		return "restart"
+	default:
+		return "unknown"
+	}
+}
+
+// ExitPolicy maps the exit codes of a process to actions, for sidecars that
+// signal "retry me" and "my config is broken" with different codes.
+type ExitPolicy struct {
+	// Codes maps exit codes to actions.
+	Codes map[int]ExitAction
+
+	// Default is the action for nonzero codes not in Codes, and for
+	// processes killed by a signal. Exit code 0 is ExitComplete unless
+	// Codes maps it.
+	Default ExitAction
+
+	// Restart is the restart policy applied for ExitRestart, with its
+	// backoff and restart limit. Its Always and Restartable fields are
+	// ignored.
+	Restart run.RestartPolicy
+}
+
+// WithExitPolicy returns an Option that decides by exit code what happens
+// when the process exits on its own, such as
+//
+//	proc.ExitPolicy{
+//		Codes:   map[int]proc.ExitAction{75: proc.ExitRestart},
+//		Default: proc.ExitFail,
+//		Restart: run.RestartPolicy{Backoff: time.Second, MaxBackoff: time.Minute},
+//	}
+//
+// Register then supervises the component with p.Restart, so it must not be
+// given a run.Restart option of its own. Exits that fail the component
+// return an *ExitError.
+func WithExitPolicy(p ExitPolicy) Option {
+	return func(proc *Process) {
+		proc.exits = &p
+	}
+}
+
+// ExitError is returned when a process exited on its own with a code its
+// exit policy restarts it or fails for.
+type ExitError struct {
+	Code   int        // exit code, -1 if the process was killed by a signal
+	Action ExitAction // action of the exit policy for the code
+}
+
+// Error returns the exit status.
+func (e *ExitError) Error() string {
+	if e.Code < 0 {
+		return "killed by signal"
+	}
+	return "exit status " + strconv.Itoa(e.Code)
+}
+
+// restartPolicy returns the restart policy implementing p.
+func (p *ExitPolicy) restartPolicy() run.RestartPolicy {
+	r := p.Restart
+	r.Always = false
+	r.Restartable = func(err error) bool {
+		var exit *ExitError
+		return errors.As(err, &exit) && exit.Action == ExitRestart
+	}
+	return r
+}
+
+// exited applies p to err, the result of a run of the process that exited
+// on its own, and returns the error the run function returns.
+func (p *ExitPolicy) exited(ctx context.Context, err error) error {
+	code := 0
+	var exit *exec.ExitError
+	switch {
+	case errors.As(err, &exit):
+		code = exit.ExitCode()
+	case err != nil && !errors.Is(err, exec.ErrWaitDelay):
+		// The process could not be started or waited for.
+		return err
+	}
+
+	action, ok := p.Codes[code]
+	switch {
+	case ok:
+	case code == 0:
+		action = ExitComplete
+	default:
+		action = p.Default
+	}
+	if action == ExitComplete {
+		<-ctx.Done()
+		return nil
+	}
+	return &ExitError{Code: code, Action: action}
+}
@@ -0,0 +1,207 @@
+package proc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/not-for-prod/run"
+)
+
+// DefaultLogBuffer is the default number of output lines buffered per
+// stream before backpressure applies.
+const DefaultLogBuffer = 1024
+
+// maxLine is the length after which an output line is split.
+const maxLine = 64 << 10
+
+// LogOptions configures how a process's output is logged, see WithLogs.
+type LogOptions struct {
+	// Logger is the logger lines are logged to. Nil means the group's
+	// logger, or slog.Default if the group has none.
+	Logger *slog.Logger
+
+	// JSON passes lines that are JSON objects through as structured
+	// records: their "msg" or "message" becomes the message, their "level"
+	// the level, and their other fields attributes.
+	JSON bool
+
+	// Buffer is the number of lines buffered per stream. Zero means
+	// DefaultLogBuffer.
+	Buffer int
+
+	// Drop drops lines while the buffer is full instead of blocking the
+	// process's writes, and logs how many were dropped once it drains.
+	Drop bool
+}
+
+// WithLogs returns an Option that logs each line the process writes to
+// standard output, at info level, and standard error, at warn level,
+// through o.Logger with the component's name and labels and the stream as
+// attributes. Lines
+// longer than 64 KiB are split. By default a process that writes faster
+// than the logger keeps up is blocked once o.Buffer lines are pending.
+//
+// It replaces WithOutput.
+func WithLogs(o LogOptions) Option {
+	if o.Buffer <= 0 {
+		o.Buffer = DefaultLogBuffer
+	}
+	return func(p *Process) {
+		p.logs = &o
+	}
+}
+
+// lineLogger is an io.Writer logging each line written to it.
+type lineLogger struct {
+	o     *LogOptions
+	l     *slog.Logger
+	level slog.Level
+	attrs []slog.Attr
+	lines chan string
+	done  chan struct{} // closed when every line was logged
+	buf   []byte        // partial line
+	mu    sync.Mutex    // guards drops
+	drops int           // lines dropped since the last report
+}
+
+// newLineLogger returns a lineLogger for the stream of the component in
+// ctx, logging in the background until it is closed.
+func newLineLogger(ctx context.Context, l *slog.Logger, o *LogOptions, stream string, level slog.Level) *lineLogger {
+	attrs := []slog.Attr{slog.String("stream", stream)}
+	if info, ok := run.ComponentFromContext(ctx); ok {
+		attrs = append(attrs, slog.String("component", info.Name))
+		if len(info.Labels) > 0 {
+			labels := make([]any, 0, len(info.Labels))
+			for _, k := range slices.Sorted(maps.Keys(info.Labels)) {
+				labels = append(labels, slog.String(k, info.Labels[k]))
+			}
+			attrs = append(attrs, slog.Group("labels", labels...))
+		}
+	}
+	w := &lineLogger{
+		o:     o,
+		l:     l,
+		level: level,
+		attrs: attrs,
+		lines: make(chan string, o.Buffer),
+		done:  make(chan struct{}),
+	}
+	go w.log()
+	return w
+}
+
+// Write splits p into lines and queues them.
+func (w *lineLogger) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.buf = append(w.buf, p...)
+			for len(w.buf) >= maxLine {
+				w.queue(string(w.buf[:maxLine]))
+				w.buf = w.buf[maxLine:]
+			}
+			break
+		}
+		w.buf = append(w.buf, p[:i]...)
+		w.queue(string(w.buf))
+		w.buf = w.buf[:0]
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// queue queues a line, blocking while the buffer is full unless lines are
+// dropped.
+func (w *lineLogger) queue(line string) {
+	line = strings.TrimSuffix(line, "\r")
+	if !w.o.Drop {
+		w.lines <- line
+		return
+	}
+	select {
+	case w.lines <- line:
+	default:
+		w.mu.Lock()
+		w.drops++
+		w.mu.Unlock()
+	}
+}
+
+// Close queues the last partial line and waits until every line is logged.
+// The process must no longer write.
+func (w *lineLogger) Close() error {
+	if len(w.buf) > 0 {
+		w.queue(string(w.buf))
+		w.buf = nil
+	}
+	close(w.lines)
+	<-w.done
+	return nil
+}
+
+// log logs the queued lines.
+func (w *lineLogger) log() {
+	defer close(w.done)
+	for line := range w.lines {
+		w.record(line)
+		w.reportDrops()
+	}
+	w.reportDrops()
+}
+
+// reportDrops logs how many lines were dropped since the last report.
+func (w *lineLogger) reportDrops() {
+	w.mu.Lock()
+	drops := w.drops
+	w.drops = 0
+	w.mu.Unlock()
+	if drops > 0 {
+		attrs := append(slices.Clip(w.attrs), slog.Int("lines", drops))
+		w.l.LogAttrs(context.Background(), slog.LevelWarn, "process output dropped", attrs...)
+	}
+}
+
+// record logs a single line.
+func (w *lineLogger) record(line string) {
+	level, msg, attrs := w.level, line, w.attrs
+	if w.o.JSON && strings.HasPrefix(line, "{") {
+		var fields map[string]any
+		if json.Unmarshal([]byte(line), &fields) == nil {
+			level, msg, attrs = w.structured(fields)
+		}
+	}
+	w.l.LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+// structured returns the level, message and attributes of a JSON line.
+func (w *lineLogger) structured(fields map[string]any) (slog.Level, string, []slog.Attr) {
+	level, msg := w.level, ""
+	for _, key := range []string{"msg", "message"} {
+		if s, ok := fields[key].(string); ok {
+			msg = s
+			delete(fields, key)
+			break
+		}
+	}
+	if s, ok := fields["level"].(string); ok {
+		if err := level.UnmarshalText([]byte(s)); err == nil {
+			delete(fields, "level")
+		} else {
+			level = w.level
+		}
+	}
+	delete(fields, "time")
+
+	attrs := slices.Clip(w.attrs)
+	for _, k := range slices.Sorted(maps.Keys(fields)) {
+		attrs = append(attrs, slog.Any(k, fields[k]))
+	}
+	return level, msg, attrs
+}
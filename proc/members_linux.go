@@ -0,0 +1,49 @@
+//go:build linux
+
+package proc
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// groupMembers returns the processes of the process group pgid that have
+// not exited, ignoring zombies waiting to be reaped.
+func groupMembers(pgid int) []int {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	var pids []int
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile("/proc/" + e.Name() + "/stat")
+		if err != nil {
+			continue
+		}
+		// The fields after the parenthesized command name are the state,
+		// the parent PID and the process group.
+		i := strings.LastIndexByte(string(stat), ')')
+		if i < 0 {
+			continue
+		}
+		fields := strings.Fields(string(stat[i+1:]))
+		if len(fields) < 3 || fields[0] == "Z" || fields[0] == "X" {
+			continue
+		}
+		if pgrp, err := strconv.Atoi(fields[2]); err == nil && pgrp == pgid {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// groupAlive reports whether any process of the process group pgid has not
+// exited.
+func groupAlive(pgid int) bool {
+	return len(groupMembers(pgid)) > 0
+}
@@ -0,0 +1,17 @@
+//go:build unix && !linux
+
+package proc
+
+import "syscall"
+
+// groupMembers returns nil: the processes of a process group cannot be
+// listed on this platform.
+func groupMembers(int) []int {
+	return nil
+}
+
+// groupAlive reports whether any process of the process group pgid has not
+// been reaped.
+func groupAlive(pgid int) bool {
+	return syscall.Kill(-pgid, 0) == nil
+}
@@ -0,0 +1,34 @@
+//go:build !unix
+
+package proc
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// processGroups reports whether process groups are supported.
+const processGroups = false
+
+// setProcessGroup does nothing: process groups are not supported on this
+// platform.
+func setProcessGroup(*exec.Cmd) {}
+
+// signalGroup reports that process groups are not supported on this
+// platform.
+func signalGroup(int, os.Signal) error {
+	return errors.ErrUnsupported
+}
+
+// groupMembers returns nil: process groups are not supported on this
+// platform.
+func groupMembers(int) []int {
+	return nil
+}
+
+// groupAlive reports false: process groups are not supported on this
+// platform.
+func groupAlive(int) bool {
+	return false
+}
@@ -0,0 +1,333 @@
+// Package proc runs child processes as components of a run.Group, so one Go
+// binary can supervise several programs like a minimal init for a pod or VM.
+//
+// Each Process is a run-style component: it is started when the group starts
+// it and signaled to exit when the group stops it, and an unexpected exit
+// fails the component like the return of a function added with Group.Go.
+// The group's own component options supervise the children: run.Restart
+// gives a child its restart policy, run.DependsOn orders startup (and the
+// reverse shutdown), run.Verify waits for a child to be ready before its
+// dependents start, and run.Critical(false) keeps the others running when
+// one fails.
+package proc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/not-for-prod/run"
+)
+
+// orphanPoll is how often a stopped process's group is checked for
+// processes that have not exited, orphanPolls times after SIGKILL.
+const (
+	orphanPoll  = 10 * time.Millisecond
+	orphanPolls = 10
+)
+
+// outputDelay bounds how long output is still copied once the process has
+// exited.
+const outputDelay = time.Second
+
+// Option configures a Process.
+type Option func(*Process)
+
+// WithDir returns an Option that sets the working directory of the process.
+//
+// Default is the working directory of the calling process.
+func WithDir(dir string) Option {
+	return func(p *Process) {
+		p.dir = dir
+	}
+}
+
+// WithEnv returns an Option that sets the environment of the process, as
+// "key=value" strings.
+//
+// Default is the environment of the calling process.
+func WithEnv(env ...string) Option {
+	return func(p *Process) {
+		p.env = env
+	}
+}
+
+// WithStopSignal returns an Option that sets the signal sent to ask the
+// process to exit when its component is stopped.
+//
+// Default is SIGTERM.
+func WithStopSignal(sig os.Signal) Option {
+	return func(p *Process) {
+		p.stopSignal = sig
+	}
+}
+
+// WithOutput returns an Option that sets where the standard output and
+// standard error of the process are written.
+//
+// Default is the standard output and standard error of the calling process.
+func WithOutput(stdout, stderr io.Writer) Option {
+	return func(p *Process) {
+		p.stdout, p.stderr = stdout, stderr
+	}
+}
+
+// WithProcessGroup returns an Option that runs the process in a process
+// group of its own, on unix, so the stop signal and the final SIGKILL reach
+// the processes it started too. When the process exits unexpectedly, the
+// processes it leaves behind in its group are killed before it is
+// restarted or fails. When it is stopped, its component waits for the whole
+// group to exit; processes still running when it is killed are reported in
+// the stop error as an *OrphanError.
+func WithProcessGroup() Option {
+	return func(p *Process) {
+		p.pgroup = processGroups
+	}
+}
+
+// OrphanError is returned when the processes a stopped process started,
+// in its process group, were still running when it was killed.
+type OrphanError struct {
+	PGID int   // process group
+	PIDs []int // processes still running, empty where they cannot be listed
+}
+
+// Error lists the orphaned processes.
+func (e *OrphanError) Error() string {
+	msg := "process group " + strconv.Itoa(e.PGID) + " left orphans"
+	if len(e.PIDs) == 0 {
+		return msg
+	}
+	pids := make([]string, len(e.PIDs))
+	for i, pid := range e.PIDs {
+		pids[i] = strconv.Itoa(pid)
+	}
+	return msg + ": " + strings.Join(pids, ", ")
+}
+
+// Process is a child process run as a component.
+type Process struct {
+	path       string
+	args       []string
+	dir        string
+	env        []string
+	stopSignal os.Signal
+	stdout     io.Writer
+	stderr     io.Writer
+	pgroup     bool
+	logs       *LogOptions
+	exits      *ExitPolicy
+	logger     *slog.Logger
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd     // the running process, nil between runs
+	exited bool          // whether cmd has exited and only its group is awaited
+	killed chan struct{} // closed by kill
+}
+
+// New returns a Process running the program at path, looked up in $PATH if
+// it contains no separator, with args.
+func New(path string, args []string, opts ...Option) *Process {
+	p := &Process{
+		path:       path,
+		args:       args,
+		stopSignal: syscall.SIGTERM,
+		stdout:     os.Stdout,
+		stderr:     os.Stderr,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Register adds the process to g as a run-style component named after the
+// program, and returns its handle. The process is killed in the forceful
+// stop phase if it has not exited by the stop timeout. opts can override
+// the name and add further component options, such as run.Restart.
+func (p *Process) Register(g *run.Group, opts ...run.ComponentOption) *run.Handle {
+	p.logger = g.Logger()
+	defaults := []run.ComponentOption{run.Named(filepath.Base(p.path)), run.ForceStop(p.kill)}
+	if p.exits != nil {
+		defaults = append(defaults, run.Restart(p.exits.restartPolicy()))
+	}
+	return g.Go(p.run, append(defaults, opts...)...)
+}
+
+// PID returns the process ID of the running process, or 0 when it is not
+// running.
+func (p *Process) PID() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd == nil {
+		return 0
+	}
+	return p.cmd.Process.Pid
+}
+
+// run runs the process once, applying the exit policy, if any, when it
+// exits on its own.
+func (p *Process) run(ctx context.Context) error {
+	err := p.runOnce(ctx)
+	if p.exits == nil || ctx.Err() != nil {
+		return err
+	}
+	return p.exits.exited(ctx, err)
+}
+
+// runOnce starts the process and waits for it to exit. When ctx is canceled
+// it sends the stop signal and waits for the process, and with
+// WithProcessGroup the rest of its group, to exit.
+func (p *Process) runOnce(ctx context.Context) error {
+	cmd := exec.Command(p.path, p.args...)
+	cmd.Dir, cmd.Env = p.dir, p.env
+	cmd.Stdout, cmd.Stderr = p.stdout, p.stderr
+	// Processes the child leaves behind must not keep Wait copying their
+	// output forever.
+	cmd.WaitDelay = outputDelay
+	if p.logs != nil {
+		l := p.logs.Logger
+		if l == nil {
+			l = p.logger
+		}
+		if l == nil {
+			l = slog.Default()
+		}
+		stdout := newLineLogger(ctx, l, p.logs, "stdout", slog.LevelInfo)
+		stderr := newLineLogger(ctx, l, p.logs, "stderr", slog.LevelWarn)
+		defer stdout.Close()
+		defer stderr.Close()
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+	}
+	if p.pgroup {
+		setProcessGroup(cmd)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	killed := make(chan struct{})
+	p.mu.Lock()
+	p.cmd, p.exited, p.killed = cmd, false, killed
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.cmd = nil
+		p.mu.Unlock()
+	}()
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-exited:
+		if p.pgroup {
+			_ = signalGroup(cmd.Process.Pid, syscall.SIGKILL)
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	if err := p.signal(cmd, p.stopSignal); err != nil {
+		return err
+	}
+	err := <-exited
+	if p.pgroup {
+		p.mu.Lock()
+		p.exited = true
+		p.mu.Unlock()
+		awaitGroup(cmd.Process.Pid, killed)
+	}
+	if p.stoppedBy(cmd.ProcessState) {
+		return nil
+	}
+	return err
+}
+
+// signal sends sig to the process, or to its process group.
+func (p *Process) signal(cmd *exec.Cmd, sig os.Signal) error {
+	if p.pgroup {
+		return signalGroup(cmd.Process.Pid, sig)
+	}
+	if err := cmd.Process.Signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	return nil
+}
+
+// awaitGroup waits until every process of the process group pgid has
+// exited, or until killed is closed.
+func awaitGroup(pgid int, killed <-chan struct{}) {
+	ticker := time.NewTicker(orphanPoll)
+	defer ticker.Stop()
+	for groupAlive(pgid) {
+		select {
+		case <-killed:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// stoppedBy reports whether the process exited cleanly after being asked
+// to stop: with status 0 or killed by the stop signal or SIGKILL.
+func (p *Process) stoppedBy(state *os.ProcessState) bool {
+	if state.Success() {
+		return true
+	}
+	ws, ok := state.Sys().(syscall.WaitStatus)
+	return ok && ws.Signaled() && (ws.Signal() == p.stopSignal || ws.Signal() == syscall.SIGKILL)
+}
+
+// kill kills the running process, if any, or with WithProcessGroup its
+// whole process group. It returns an *OrphanError if the process had exited
+// but left processes running in its group.
+func (p *Process) kill(ctx context.Context) error {
+	p.mu.Lock()
+	cmd, exited, killed := p.cmd, p.exited, p.killed
+	p.mu.Unlock()
+	if cmd == nil {
+		return nil
+	}
+	if !p.pgroup {
+		if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			return err
+		}
+		return nil
+	}
+
+	pgid := cmd.Process.Pid
+	alive, orphans := groupAlive(pgid), groupMembers(pgid)
+	err := signalGroup(pgid, syscall.SIGKILL)
+	p.mu.Lock()
+	select {
+	case <-killed:
+	default:
+		close(killed)
+	}
+	p.mu.Unlock()
+	if err != nil || !exited || !alive {
+		return err
+	}
+
+	// Give the orphans a moment to exit, so that any surviving SIGKILL are
+	// named instead.
+	for i := 0; i < orphanPolls && groupAlive(pgid) && ctx.Err() == nil; i++ {
+		time.Sleep(orphanPoll)
+	}
+	if survivors := groupMembers(pgid); len(survivors) > 0 {
+		orphans = survivors
+	}
+	return &OrphanError{PGID: pgid, PIDs: orphans}
+}
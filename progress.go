@@ -0,0 +1,58 @@
+package run
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Progress is a snapshot of the start or warmup phase, reported through the
+// callback installed with WithProgress.
+type Progress struct {
+	Phase   Phase    // PhaseStart or PhaseWarmup
+	Started int      // number of components that have started successfully, or finished warming up
+	Total   int      // number of components to start or warm up
+	Waiting []string // components whose start function or warmer is still running, in registration order
+}
+
+// String formats the progress as "7/23 started, waiting on: kafka-consumer,
+// db-pool", or "2/3 warmed up, waiting on: catalog-cache" during warmup.
+func (p Progress) String() string {
+	done := "started"
+	if p.Phase == PhaseWarmup {
+		done = "warmed up"
+	}
+	s := fmt.Sprintf("%d/%d %s", p.Started, p.Total, done)
+	if len(p.Waiting) > 0 {
+		s += ", waiting on: " + strings.Join(p.Waiting, ", ")
+	}
+	return s
+}
+
+// reportProgress invokes the progress callback, if any, with the current
+// state of the start phase. Calls are serialized.
+func (g *Group) reportProgress(s *schedule) {
+	if g.opts.progress == nil {
+		return
+	}
+
+	g.progressMu.Lock()
+	defer g.progressMu.Unlock()
+
+	p := Progress{Phase: PhaseStart}
+	for _, wave := range s.waves {
+		p.Total += len(wave)
+	}
+
+	g.mu.Lock()
+	for _, c := range g.registry.list() {
+		switch c.loadState() {
+		case StateRunning:
+			p.Started++
+		case StateStarting:
+			p.Waiting = append(p.Waiting, c.name)
+		}
+	}
+	g.mu.Unlock()
+
+	g.opts.progress(p)
+}
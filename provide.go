@@ -0,0 +1,130 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNotProvided is returned by Resolve when the provider has not started yet or failed.
+var ErrNotProvided = errors.New("value not provided")
+
+// Provider constructs a value during the start phase and returns the Stop
+// function that releases it. A nil Stop is allowed when nothing needs to be
+// released.
+//
+// The context is the start phase context: it carries the start timeout and
+// must not be retained after the provider returns. Background work started
+// by the provider can use ComponentContext instead.
+type Provider[T any] func(ctx context.Context) (T, Stop, error)
+
+// Future is the eventual result of a component registered with Provide.
+type Future[T any] struct {
+	handle *Handle
+	done   chan struct{} // closed once the provider has returned
+	value  T
+	stop   Stop
+	err    error
+}
+
+// Provide registers a component whose start phase constructs a value of type
+// T, such as a database handle, and returns a Future that resolves to it.
+//
+// The value is also published in the group's registry: components declaring
+// Requires[T] (or RequiresNamed[T] with this component's name) are started
+// after the provider and can fetch it with Resolve. The Future can be awaited
+// by other components' start functions or by code running alongside
+// Group.Wait.
+//
+// The Stop returned by the provider is called during shutdown like any other
+// stop function; it is skipped when the provider failed.
+func Provide[T any](g *Group, provide Provider[T], opts ...ComponentOption) *Future[T] {
+	f := &Future[T]{done: make(chan struct{})}
+
+	typ := reflect.TypeFor[T]()
+	f.handle = g.add(&component{
+		name:     typ.String(),
+		provides: typ,
+		value: func() (any, bool) {
+			select {
+			case <-f.done:
+				return f.value, f.err == nil
+			default:
+				return nil, false
+			}
+		},
+		start: func(ctx context.Context) error {
+			f.value, f.stop, f.err = provide(ctx)
+			close(f.done)
+			return f.err
+		},
+		stop: func(ctx context.Context) error {
+			// The provider may still be running if the start phase timed out;
+			// give it until the stop deadline to hand over its Stop.
+			select {
+			case <-f.done:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			if f.err != nil || f.stop == nil {
+				return nil
+			}
+			return f.stop(ctx)
+		},
+	}, opts)
+
+	return f
+}
+
+// Handle returns the handle of the providing component.
+func (f *Future[T]) Handle() *Handle {
+	return f.handle
+}
+
+// Done returns a channel that is closed once the provider has returned.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the provider has returned or ctx is done, and returns the
+// constructed value or the provider's error.
+func (f *Future[T]) Wait(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Resolve returns the value of type T published by the group's provider of
+// T. It is meant to be called from the start function of a component that
+// declared Requires[T], at which point the provider has already started.
+func Resolve[T any](g *Group) (T, error) {
+	return resolve[T](g, dependency{typ: reflect.TypeFor[T]()})
+}
+
+// ResolveNamed is like Resolve but selects the provider of T by its component
+// name, matching RequiresNamed.
+func ResolveNamed[T any](g *Group, name string) (T, error) {
+	return resolve[T](g, dependency{typ: reflect.TypeFor[T](), name: name})
+}
+
+// resolve looks up the provider of d and returns its published value.
+func resolve[T any](g *Group, d dependency) (T, error) {
+	var zero T
+
+	p, err := g.provider(g.registry.list(), d)
+	if err != nil {
+		return zero, err
+	}
+
+	v, ok := p.value()
+	if !ok {
+		return zero, fmt.Errorf("%s: %w", d, ErrNotProvided)
+	}
+	return v.(T), nil
+}
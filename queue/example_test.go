@@ -0,0 +1,64 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/queue"
+)
+
+func ExampleQueue_Register() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	q := queue.New(func(ctx context.Context, id int) error {
+		fmt.Println("reindexed", id)
+		return nil
+	}, queue.WithSize(16))
+
+	g := run.NewGroup()
+	q.Register(g, run.Named("reindex"))
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		for id := range 3 {
+			_ = q.Push(ctx, id)
+		}
+		// Queued items are drained before the group stops.
+		cancel()
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("wait error:", err)
+	}
+	fmt.Println(errors.Is(q.Push(context.Background(), 3), queue.ErrStopped))
+	// Output:
+	// reindexed 0
+	// reindexed 1
+	// reindexed 2
+	// true
+}
+
+func ExampleWithPersist() {
+	q := queue.New(func(ctx context.Context, job string) error {
+		return nil
+	}, queue.WithSize(2), queue.WithOverflow(queue.Reject), queue.WithPersist(func(ctx context.Context, jobs []string) error {
+		// Write the jobs to durable storage for the next instance.
+		fmt.Println("persisted", jobs)
+		return nil
+	}))
+
+	// The workers are not running, so the jobs stay queued.
+	fmt.Println(q.Push(context.Background(), "invoice-1"))
+	fmt.Println(q.Push(context.Background(), "invoice-2"))
+	fmt.Println(q.Push(context.Background(), "invoice-3"))
+	fmt.Println(q.Stop(context.Background()))
+	// Output:
+	// <nil>
+	// <nil>
+	// queue full
+	// persisted [invoice-1 invoice-2]
+	// <nil>
+}
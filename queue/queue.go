@@ -0,0 +1,314 @@
+// Package queue provides an in-memory work queue as a run.Group component:
+// items pushed to a bounded queue are handled by a pool of workers, with a
+// configurable overflow policy and a configurable fate for queued items when
+// the component stops — drained, dropped or persisted — so deploys do not
+// silently lose work.
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/not-for-prod/run"
+)
+
+// Defaults used by New.
+const (
+	DefaultSize    = 1024
+	DefaultWorkers = 1
+)
+
+var (
+	// ErrFull is returned by Push when the queue is full and the overflow
+	// policy is Reject.
+	ErrFull = errors.New("queue full")
+
+	// ErrStopped is returned by Push once the queue is stopping.
+	ErrStopped = errors.New("queue stopped")
+)
+
+// Overflow decides what Push does when the queue is full.
+type Overflow int
+
+const (
+	// Block waits until there is room or the context passed to Push is done.
+	Block Overflow = iota
+
+	// Reject returns ErrFull.
+	Reject
+
+	// DropOldest drops the oldest queued item to make room.
+	DropOldest
+)
+
+// StopMode decides what happens to queued items when the queue stops.
+type StopMode int
+
+const (
+	// Drain handles every queued item before stopping, within the stop
+	// timeout.
+	Drain StopMode = iota
+
+	// Drop drops queued items; only items being handled are waited for.
+	Drop
+
+	// Persist passes queued items to the callback set with WithPersist,
+	// for example to write them to durable storage for the next instance.
+	Persist
+)
+
+// Option configures a Queue.
+type Option func(*config)
+
+// config holds the settings shared by all queues.
+type config struct {
+	size     int
+	workers  int
+	overflow Overflow
+	stopMode StopMode
+	persist  any // func(ctx context.Context, items []T) error
+	onError  func(error)
+}
+
+// WithSize returns an Option that sets how many items the queue holds.
+// Values less than one are treated as one.
+//
+// Default is DefaultSize.
+func WithSize(n int) Option {
+	return func(c *config) {
+		c.size = max(n, 1)
+	}
+}
+
+// WithWorkers returns an Option that sets how many items are handled at the
+// same time. Values less than one are treated as one.
+//
+// Default is DefaultWorkers.
+func WithWorkers(n int) Option {
+	return func(c *config) {
+		c.workers = max(n, 1)
+	}
+}
+
+// WithOverflow returns an Option that sets what Push does when the queue is
+// full.
+//
+// Default is Block.
+func WithOverflow(o Overflow) Option {
+	return func(c *config) {
+		c.overflow = o
+	}
+}
+
+// WithStopMode returns an Option that sets what happens to queued items when
+// the queue stops.
+//
+// Default is Drain.
+func WithStopMode(m StopMode) Option {
+	return func(c *config) {
+		c.stopMode = m
+	}
+}
+
+// WithPersist returns an Option that selects the Persist stop mode and
+// passes the items still queued at stop to fn, within the stop timeout. T
+// must be the queue's item type.
+func WithPersist[T any](fn func(ctx context.Context, items []T) error) Option {
+	return func(c *config) {
+		c.stopMode = Persist
+		c.persist = fn
+	}
+}
+
+// WithErrorHandler returns an Option that passes errors returned by the
+// handler to fn. By default they are dropped.
+func WithErrorHandler(fn func(error)) Option {
+	return func(c *config) {
+		c.onError = fn
+	}
+}
+
+// Queue is a bounded in-memory queue of items of type T handled by a pool of
+// workers.
+type Queue[T any] struct {
+	config
+	handle  func(ctx context.Context, item T) error
+	persist func(ctx context.Context, items []T) error
+
+	items    chan T
+	stopping chan struct{} // closed when Stop begins
+	dropped  atomic.Int64
+
+	mu     sync.RWMutex // held for reading by Push, for writing by Stop
+	closed bool
+
+	cancel   context.CancelFunc // cancels the workers' context
+	running  sync.WaitGroup     // counts running workers
+	stopOnce sync.Once
+}
+
+// New returns a queue whose items are handled by handle, configured by opts.
+// Workers run while the queue's component runs; see Register.
+func New[T any](handle func(ctx context.Context, item T) error, opts ...Option) *Queue[T] {
+	q := &Queue[T]{
+		config:   config{size: DefaultSize, workers: DefaultWorkers},
+		handle:   handle,
+		stopping: make(chan struct{}),
+		cancel:   func() {},
+	}
+	for _, opt := range opts {
+		opt(&q.config)
+	}
+	q.items = make(chan T, q.size)
+	if fn, ok := q.config.persist.(func(ctx context.Context, items []T) error); ok {
+		q.persist = fn
+	}
+	return q
+}
+
+// Push queues item. When the queue is full it blocks, fails with ErrFull or
+// drops the oldest item, according to the overflow policy. Once the queue is
+// stopping, Push returns ErrStopped.
+func (q *Queue[T]) Push(ctx context.Context, item T) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrStopped
+	}
+	for {
+		select {
+		case q.items <- item:
+			return nil
+		default:
+		}
+
+		switch q.overflow {
+		case Reject:
+			return ErrFull
+		case DropOldest:
+			select {
+			case <-q.items:
+				q.dropped.Add(1)
+			default:
+			}
+		default:
+			select {
+			case q.items <- item:
+				return nil
+			case <-q.stopping:
+				return ErrStopped
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// Len returns the number of queued items.
+func (q *Queue[T]) Len() int {
+	return len(q.items)
+}
+
+// Dropped returns the number of items dropped by the DropOldest overflow
+// policy or by the Drop stop mode.
+func (q *Queue[T]) Dropped() int64 {
+	return q.dropped.Load()
+}
+
+// Start starts the workers. The context of the handler is canceled when Stop
+// gives up waiting for it.
+func (q *Queue[T]) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+	for range q.workers {
+		q.running.Add(1)
+		go q.work(ctx)
+	}
+	return nil
+}
+
+// work handles items until the queue stops, draining the queue first in the
+// Drain stop mode.
+func (q *Queue[T]) work(ctx context.Context) {
+	defer q.running.Done()
+
+	for {
+		select {
+		case item := <-q.items:
+			q.call(ctx, item)
+			continue
+		case <-q.stopping:
+		}
+
+		if q.stopMode != Drain {
+			return
+		}
+		for {
+			select {
+			case item := <-q.items:
+				q.call(ctx, item)
+			default:
+				return
+			}
+		}
+	}
+}
+
+// call handles a single item.
+func (q *Queue[T]) call(ctx context.Context, item T) {
+	if err := q.handle(ctx, item); err != nil && q.onError != nil {
+		q.onError(err)
+	}
+}
+
+// Stop rejects further pushes and stops the workers, draining, dropping or
+// persisting the queued items according to the stop mode. If ctx is done
+// before the workers return, their context is canceled and Stop returns an
+// error counting the items left in the queue.
+func (q *Queue[T]) Stop(ctx context.Context) error {
+	q.stopOnce.Do(func() {
+		close(q.stopping)
+		q.mu.Lock()
+		q.closed = true
+		q.mu.Unlock()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		q.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		q.cancel()
+		return fmt.Errorf("stop queue: %w (%d items left)", ctx.Err(), len(q.items))
+	}
+	q.cancel()
+
+	var items []T
+	for len(q.items) > 0 {
+		items = append(items, <-q.items)
+	}
+	switch {
+	case len(items) == 0:
+		return nil
+	case q.stopMode == Persist && q.persist != nil:
+		return q.persist(ctx, items)
+	default:
+		q.dropped.Add(int64(len(items)))
+		return nil
+	}
+}
+
+// Register adds a component to g that runs the workers of q and stops them
+// when the group stops, and returns its handle. Further component options,
+// such as Named, may be passed in opts.
+func (q *Queue[T]) Register(g *run.Group, opts ...run.ComponentOption) *run.Handle {
+	opts = append([]run.ComponentOption{run.Named("queue")}, opts...)
+	return g.Add(q.Start, q.Stop, opts...)
+}
@@ -0,0 +1,195 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrQuorum is returned when fewer components of a quorum set with
+// WithQuorum are running than it requires.
+var ErrQuorum = errors.New("quorum not reached")
+
+// quorumRetry is the retry policy of quorum members that failed to start
+// and have no RestartPolicy of their own.
+var quorumRetry = RestartPolicy{Backoff: time.Second, MaxBackoff: 30 * time.Second}
+
+// quorumRule is a quorum declared with WithQuorum.
+type quorumRule struct {
+	k   int
+	sel Selector
+}
+
+// quorum is a quorum rule applied to the enabled components of a run.
+type quorum struct {
+	k       int
+	members []*component
+}
+
+// WithQuorum returns an Option that treats the components matched by sel as
+// redundant replicas, such as N connectors to the same upstream, of which k
+// must run: a member failing to start or, for run-style components,
+// returning while the group runs only marks the group degraded as long as k
+// of the members can still run, and shuts the group down with an error
+// wrapping ErrQuorum once they cannot. Members that failed to start keep
+// retrying in the background, with the backoff of their RestartPolicy or
+// from 1s up to 30s, until they start or the group shuts down; components
+// depending on them are skipped meanwhile. The start phase succeeds once the
+// start calls returned and at least k members are running.
+//
+// WithQuorum may be given more than once for different sets.
+func WithQuorum(k int, sel Selector) Option {
+	return optionFunc(func(o *options) {
+		o.quorums = append(o.quorums, quorumRule{k: k, sel: sel})
+	})
+}
+
+// quorums applies the configured quorum rules to the components of waves.
+func (g *Group) quorums(waves [][]*component) map[*component]*quorum {
+	if len(g.opts.quorums) == 0 {
+		return nil
+	}
+	quorums := make(map[*component]*quorum)
+	for _, rule := range g.opts.quorums {
+		q := &quorum{k: rule.k}
+		for _, wave := range waves {
+			for _, c := range wave {
+				if rule.sel(c.info()) {
+					q.members = append(q.members, c)
+					quorums[c] = q
+				}
+			}
+		}
+	}
+	return quorums
+}
+
+// quorumOf returns the quorum c belongs to, or nil.
+func (g *Group) quorumOf(c *component) *quorum {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.running == nil {
+		return nil
+	}
+	return g.running.quorums[c]
+}
+
+// alive returns how many members other than c are running or may still
+// start.
+func (q *quorum) alive(c *component) int {
+	n := 0
+	for _, m := range q.members {
+		if m == c {
+			continue
+		}
+		switch m.loadState() {
+		case StateRegistered, StateStarting, StateRunning, StateRestarting, StatePaused:
+			n++
+		}
+	}
+	return n
+}
+
+// running returns how many members are running.
+func (q *quorum) running() int {
+	n := 0
+	for _, m := range q.members {
+		if m.loadState() == StateRunning {
+			n++
+		}
+	}
+	return n
+}
+
+// err returns the error of a quorum with n members running.
+func (q *quorum) err(n int) error {
+	return fmt.Errorf("%w: %d of %d running, %d required", ErrQuorum, n, len(q.members), q.k)
+}
+
+// classifyQuorum degrades the fatal start or run error of a quorum member
+// while the rest of its quorum can still be reached, and marks it as a lost
+// quorum otherwise.
+func (g *Group) classifyQuorum(c *component, phase Phase, err error) error {
+	if (phase != PhaseStart && phase != PhaseRun) || SeverityOf(err) != SeverityFatal {
+		return err
+	}
+	q := g.quorumOf(c)
+	if q == nil {
+		return err
+	}
+	if n := q.alive(c); n < q.k {
+		return fmt.Errorf("%w: %w", q.err(n), err)
+	}
+	return withSeverity(err, SeverityDegraded)
+}
+
+// checkQuorums returns an error for every quorum of s with too few members
+// running after the start phase.
+func (g *Group) checkQuorums(s *schedule) error {
+	var errs []error
+	seen := make(map[*quorum]bool)
+	for _, wave := range s.waves {
+		for _, c := range wave {
+			q := s.quorums[c]
+			if q == nil || seen[q] {
+				continue
+			}
+			seen[q] = true
+			if n := q.running(); n < q.k {
+				errs = append(errs, q.err(n))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// retryStart starts c, a quorum member that failed to start, again until it
+// starts or the group shuts down.
+func (g *Group) retryStart(c *component) {
+	ctx := g.life.context()
+	p := c.restart
+	if p == nil {
+		p = &quorumRetry
+	}
+	for n := 0; ; n++ {
+		if !sleep(ctx, p.delay(n)) || g.startAgain(c) {
+			return
+		}
+	}
+}
+
+// startAgain makes one attempt to start c after it failed to start, and
+// reports whether no further attempt is needed.
+func (g *Group) startAgain(c *component) bool {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+
+	g.mu.Lock()
+	stopping := g.stopping || c.stopping
+	g.mu.Unlock()
+	if stopping || !c.transition(StateFailed, StateStarting) {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(g.life.context(), g.opts.startTimeout)
+	defer cancel()
+
+	g.beginLife(c)
+	began := time.Now()
+	if err := g.transform(c, g.callStart(withComponent(ctx, c), c, c.start)); err != nil {
+		c.life.end()
+		c.transition(StateStarting, StateFailed)
+		g.record(c, PhaseStart, began, withSeverity(err, SeverityDegraded))
+		return false
+	}
+	c.transition(StateStarting, StateRunning)
+	g.record(c, PhaseStart, began, nil)
+
+	g.mu.Lock()
+	c.err = nil
+	g.mu.Unlock()
+	close(c.started)
+	return true
+}
@@ -0,0 +1,66 @@
+package run
+
+import (
+	"net/http"
+	"slices"
+)
+
+// Ready reports whether every enabled component has started and shutdown
+// has not begun yet.
+func (g *Group) Ready() bool {
+	return g.ready.Load()
+}
+
+// OnReadyChange registers fn to be called whenever Ready changes: with true
+// once every component has started and with false when shutdown begins.
+// Callbacks run synchronously in registration order, so they should return
+// quickly.
+func (g *Group) OnReadyChange(fn func(ready bool)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.readyHooks = append(g.readyHooks, fn)
+}
+
+// setReady updates the readiness flag and notifies OnReadyChange callbacks
+// when it changes.
+func (g *Group) setReady(ready bool) {
+	if g.ready.Swap(ready) == ready {
+		return
+	}
+	g.recordReady(ready)
+	if ready {
+		g.logGroup("group ready")
+	}
+
+	g.mu.Lock()
+	hooks := slices.Clone(g.readyHooks)
+	g.mu.Unlock()
+
+	for _, fn := range hooks {
+		fn(ready)
+	}
+}
+
+// ReadyHandler returns an http.Handler suitable for a Kubernetes readiness
+// probe. It responds 200 OK once every component has started, and 503
+// Service Unavailable before that and from the moment shutdown begins, so
+// load balancers stop routing traffic before components are stopped. A
+// degraded group is still ready and reports "degraded" in the body.
+func (g *Group) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+
+		if !g.Ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("not ready\n"))
+			return
+		}
+		if g.Degraded() {
+			_, _ = w.Write([]byte("degraded\n"))
+			return
+		}
+		_, _ = w.Write([]byte("ready\n"))
+	})
+}
@@ -0,0 +1,106 @@
+package run
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRecorderSize is the default number of events kept by the flight
+// recorder. It can be customized using the WithRecorderSize option.
+const DefaultRecorderSize = 256
+
+// Event is a lifecycle event kept by the flight recorder.
+type Event struct {
+	Time      time.Time // when the event happened
+	Component string    // component name, empty for group events
+	Message   string    // what happened, such as "starting -> running" or "group stopping"
+	Err       error     // error of failed calls, nil otherwise
+}
+
+// String formats the event as a single line for crash reports.
+func (e Event) String() string {
+	var b strings.Builder
+	b.WriteString(e.Time.Format("15:04:05.000"))
+	b.WriteByte(' ')
+	if e.Component != "" {
+		b.WriteString(e.Component)
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Message)
+	if e.Err != nil {
+		b.WriteString(": ")
+		b.WriteString(e.Err.Error())
+	}
+	return b.String()
+}
+
+// Recorder is a flight recorder keeping the most recent lifecycle events of
+// a group in a fixed-size ring buffer: state changes, failed calls and group
+// events such as readiness, reloads and shutdown. It costs no I/O, so a
+// crash report can include what led up to a failed shutdown even without
+// log shipping. A nil Recorder records nothing.
+type Recorder struct {
+	mu     sync.Mutex
+	events []Event // ring buffer
+	next   int     // index the next event is written to
+	full   bool    // whether the buffer has wrapped around
+}
+
+// newRecorder returns a Recorder keeping size events, or nil if size is not
+// positive.
+func newRecorder(size int) *Recorder {
+	if size <= 0 {
+		return nil
+	}
+	return &Recorder{events: make([]Event, size)}
+}
+
+// Events returns the recorded events, oldest first.
+func (r *Recorder) Events() []Event {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]Event(nil), r.events[:r.next]...)
+	}
+	events := make([]Event, 0, len(r.events))
+	events = append(events, r.events[r.next:]...)
+	return append(events, r.events[:r.next]...)
+}
+
+// Dump returns the recorded events, oldest first, one per line.
+func (r *Recorder) Dump() string {
+	var b strings.Builder
+	for _, e := range r.Events() {
+		b.WriteString(e.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// add records e, overwriting the oldest event when the buffer is full.
+func (r *Recorder) add(e Event) {
+	if r == nil {
+		return
+	}
+	e.Time = time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events[r.next] = e
+	if r.next++; r.next == len(r.events) {
+		r.next, r.full = 0, true
+	}
+}
+
+// Recorder returns the group's flight recorder, or nil if it was disabled
+// with WithRecorderSize(0).
+func (g *Group) Recorder() *Recorder {
+	return g.recorder
+}
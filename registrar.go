@@ -0,0 +1,56 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Registrar announces the instance to a service discovery system such as
+// Consul or a load balancer's target group.
+type Registrar interface {
+	// Register makes the instance discoverable.
+	Register(ctx context.Context) error
+
+	// Deregister removes the instance from discovery.
+	Deregister(ctx context.Context) error
+}
+
+// register calls every registrar in order once all components have started.
+// On failure, registrars that succeeded are left for deregister.
+func (g *Group) register(ctx context.Context) error {
+	for _, r := range g.opts.registrars {
+		if err := r.Register(ctx); err != nil {
+			return fmt.Errorf("register: %w", err)
+		}
+		g.mu.Lock()
+		g.registered++
+		g.mu.Unlock()
+	}
+	if len(g.opts.registrars) > 0 {
+		g.logGroup("group registered")
+	}
+	return nil
+}
+
+// deregister calls Deregister on every registered registrar in reverse
+// order.
+func (g *Group) deregister(ctx context.Context) error {
+	g.mu.Lock()
+	registered := g.opts.registrars[:g.registered]
+	g.registered = 0
+	g.mu.Unlock()
+	if len(registered) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, r := range slices.Backward(registered) {
+		if err := r.Deregister(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("deregister: %w", err))
+		}
+	}
+	g.logGroup("group deregistered")
+	return errors.Join(errs...)
+}
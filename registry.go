@@ -0,0 +1,120 @@
+package run
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// registryShards is the number of shards of a group's registry.
+const registryShards = 32
+
+// registry holds the components registered with a group in a map keyed by
+// handle, sharded so that concurrent calls to Add and Handle.Remove rarely
+// contend on the same lock, and both take constant time. The list in
+// registration order, which the start waves and the reports are built from,
+// is assembled from the shards on demand and cached until the next change.
+type registry struct {
+	shards  [registryShards]registryShard
+	seq     atomic.Uint64 // sequence number of the last registration
+	size    atomic.Int64  // registered components
+	version atomic.Uint64 // incremented on every change
+
+	mu      sync.Mutex   // guards ordered and cached
+	ordered []*component // components in registration order as of cached
+	cached  uint64       // version ordered was built at, zero for none
+}
+
+// registryShard holds the components whose sequence number maps to it.
+type registryShard struct {
+	mu         sync.Mutex
+	components map[*Handle]*component
+}
+
+// shard returns the shard of the component with sequence number seq.
+func (r *registry) shard(seq uint64) *registryShard {
+	return &r.shards[seq%registryShards]
+}
+
+// reserve returns the first of n consecutive sequence numbers, so that
+// components registered together stay together in registration order.
+func (r *registry) reserve(n int) uint64 {
+	return r.seq.Add(uint64(n)) - uint64(n) + 1
+}
+
+// add registers c, whose sequence number is set, under h.
+func (r *registry) add(h *Handle, c *component) {
+	sh := r.shard(c.seq)
+	sh.mu.Lock()
+	if sh.components == nil {
+		sh.components = make(map[*Handle]*component)
+	}
+	sh.components[h] = c
+	sh.mu.Unlock()
+	r.size.Add(1)
+	r.version.Add(1)
+}
+
+// remove deregisters the component registered under h and reports whether
+// it was registered.
+func (r *registry) remove(h *Handle) bool {
+	sh := r.shard(h.c.seq)
+	sh.mu.Lock()
+	_, ok := sh.components[h]
+	delete(sh.components, h)
+	sh.mu.Unlock()
+	if ok {
+		r.size.Add(-1)
+		r.version.Add(1)
+	}
+	return ok
+}
+
+// len returns the number of registered components.
+func (r *registry) len() int {
+	return int(r.size.Load())
+}
+
+// grow sizes the shards that hold no components yet for n components in
+// total.
+func (r *registry) grow(n int) {
+	per := n/registryShards + 1
+	for i := range r.shards {
+		sh := &r.shards[i]
+		sh.mu.Lock()
+		if sh.components == nil {
+			sh.components = make(map[*Handle]*component, per)
+		}
+		sh.mu.Unlock()
+	}
+}
+
+// list returns the registered components in registration order. The slice
+// is shared between callers until the next change and must not be modified.
+func (r *registry) list() []*component {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// A change that lands while the shards are read leaves the version ahead
+	// of the one recorded, so the next call builds the list again.
+	v := r.version.Load()
+	if r.cached == v {
+		return r.ordered
+	}
+
+	var cs []*component
+	for i := range r.shards {
+		sh := &r.shards[i]
+		sh.mu.Lock()
+		for _, c := range sh.components {
+			cs = append(cs, c)
+		}
+		sh.mu.Unlock()
+	}
+	slices.SortFunc(cs, func(a, b *component) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+	r.ordered, r.cached = slices.Clip(cs), v
+	return r.ordered
+}
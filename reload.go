@@ -0,0 +1,225 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// ErrReloadContextDeadlineExceeded is returned when reloading exceeds the configured timeout.
+var ErrReloadContextDeadlineExceeded = errors.New("reload context deadline exceeded")
+
+// Reloader is implemented by components that can apply new configuration
+// without restarting, such as re-reading a config file or rotating
+// certificates. Register it with the Reloads component option.
+type Reloader interface {
+	// Reload applies the current configuration.
+	Reload(ctx context.Context) error
+}
+
+// ReloaderFunc adapts an ordinary function to the Reloader interface.
+type ReloaderFunc func(ctx context.Context) error
+
+// Reload calls f(ctx).
+func (f ReloaderFunc) Reload(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Reload reloads the running group, typically on SIGHUP, on a config file
+// change or from an admin endpoint, within the reload timeout.
+//
+// First the gate set with WithGate is consulted again, so a feature flag
+// can roll components out and back without a deploy. Running components it
+// now denies are stopped, together with their running dependents. Components
+// that never started because it denied them, and that it now allows, are
+// started in dependency order, each once its dependencies are running; their
+// start errors are returned whatever their severity. A component the gate
+// stopped stays stopped for the rest of the run, as its Done channel is
+// already closed.
+//
+// Then the reloaders of all running components registered with Reloads are
+// called concurrently on the worker pool. Errors are collected like stop
+// errors, each as a ComponentError, and returned joined; reloaders and
+// starts still running when the timeout expires are reported in an error
+// wrapping ErrReloadContextDeadlineExceeded.
+func (g *Group) Reload(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, g.opts.reloadTimeout)
+	defer cancel()
+
+	g.logGroup("group reloading")
+
+	var errs []error
+	if err := g.regate(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	g.mu.Lock()
+	var reloaders []*component
+	for _, c := range g.registry.list() {
+		if c.reloader != nil && c.loadState() == StateRunning {
+			reloaders = append(reloaders, c)
+		}
+	}
+	g.mu.Unlock()
+
+	err := g.callAll(ctx, reloaders, g.opts.reloadTimeout, PhaseReload, ErrReloadContextDeadlineExceeded,
+		func(ctx context.Context, c *component) error {
+			return c.reloader.Reload(ctx)
+		}, nil)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// regate consults the gate again: running components it now denies are
+// stopped, and gated components it now allows are started.
+func (g *Group) regate(ctx context.Context) error {
+	g.mu.Lock()
+	s, stopping := g.running, g.stopping
+	g.mu.Unlock()
+	if g.opts.gate == nil || s == nil || stopping {
+		return nil
+	}
+
+	denied := g.denied(ctx)
+	return errors.Join(g.deny(ctx, s, denied), g.allow(ctx, s, denied))
+}
+
+// deny stops the running components in denied, together with their running
+// dependents, in stop order.
+func (g *Group) deny(ctx context.Context, s *schedule, denied map[*component]bool) error {
+	if len(denied) == 0 {
+		return nil
+	}
+
+	g.mu.Lock()
+	late := g.late
+	g.mu.Unlock()
+
+	// Waves are in dependency order, and components started by Reload only
+	// depend on components that started before them, so a single pass
+	// propagates denial to dependents.
+	off := make(map[*component]bool)
+	for _, wave := range slices.Concat(s.waves, late) {
+		for _, c := range wave {
+			if denied[c] {
+				off[c] = true
+				continue
+			}
+			for _, p := range s.deps[c] {
+				if off[p] {
+					off[c] = true
+					break
+				}
+			}
+		}
+	}
+
+	g.mu.Lock()
+	waves := g.stopOrder(s, func(c *component) bool {
+		return off[c] && c.attempted && !c.stopping
+	})
+	g.mu.Unlock()
+	if len(waves) == 0 {
+		return nil
+	}
+
+	stopped := g.runWaves(ctx, waves, phaseConfig{
+		deadlineErr: ErrReloadContextDeadlineExceeded,
+		inFlight:    StateStopping,
+	}, func(ctx context.Context, c *component) error {
+		_, err := g.stopComponent(ctx, c)
+		return err
+	})
+
+	select {
+	case <-stopped.done:
+	case <-ctx.Done():
+	}
+	return errors.Join(g.collate(stopped.errors())...)
+}
+
+// allow starts the gated components that are not in denied and never
+// started, in dependency order, once their dependencies are running.
+// Unlike during Wait, every start error is returned, not only fatal ones.
+func (g *Group) allow(ctx context.Context, s *schedule, denied map[*component]bool) error {
+	g.mu.Lock()
+	level := make(map[*component]int)
+	var waves [][]*component
+	for _, c := range s.gated {
+		if denied[c] || c.attempted || c.loadState() != StateRegistered || g.startedLate(c) {
+			continue
+		}
+		n, ready := 0, true
+		for _, p := range s.deps[c] {
+			if l, ok := level[p]; ok {
+				n = max(n, l+1)
+			} else if p.loadState() != StateRunning {
+				ready = false
+				break
+			}
+		}
+		if !ready {
+			continue
+		}
+		level[c] = n
+		if n == len(waves) {
+			waves = append(waves, nil)
+		}
+		waves[n] = append(waves[n], c)
+	}
+	// Record the waves before starting them, so that shutdown stops them.
+	g.late = append(g.late, waves...)
+	g.mu.Unlock()
+	if len(waves) == 0 {
+		return nil
+	}
+
+	started := g.runWaves(ctx, waves, phaseConfig{
+		deadlineErr: ErrReloadContextDeadlineExceeded,
+		inFlight:    StateStarting,
+	}, func(ctx context.Context, c *component) error {
+		if err := g.startComponent(ctx, c); err != nil {
+			return err
+		}
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return c.err
+	})
+
+	select {
+	case <-started.done:
+	case <-ctx.Done():
+		return errors.Join(append(g.collate(started.errors()), ErrReloadContextDeadlineExceeded)...)
+	}
+	return errors.Join(g.collate(started.errors())...)
+}
+
+// startedLate reports whether Reload already started c. The caller must hold
+// g.mu.
+func (g *Group) startedLate(c *component) bool {
+	return slices.ContainsFunc(g.late, func(wave []*component) bool {
+		return slices.Contains(wave, c)
+	})
+}
+
+// stopOrder returns the waves in which the components of the run stop,
+// keeping only those for which include reports true: the components started
+// by Reload first, the latest first, then the stop waves of s. The caller
+// must hold g.mu.
+func (g *Group) stopOrder(s *schedule, include func(*component) bool) [][]*component {
+	var waves [][]*component
+	for _, wave := range slices.Backward(g.late) {
+		var stoppable []*component
+		for _, c := range slices.Backward(wave) {
+			if include(c) {
+				stoppable = append(stoppable, c)
+			}
+		}
+		if len(stoppable) > 0 {
+			waves = append(waves, stoppable)
+		}
+	}
+	return append(waves, s.stopWaves(include)...)
+}
@@ -0,0 +1,184 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRestartLimit is returned when a run-style component exceeded the
+// MaxRestarts of its RestartPolicy.
+var ErrRestartLimit = errors.New("restart limit exceeded")
+
+// RestartPolicy decides whether and when a run-style component added with
+// Go is restarted after its run function returned while the group is
+// running, instead of failing.
+type RestartPolicy struct {
+	// Always also restarts run functions that return nil. By default only
+	// run functions that return an error are restarted.
+	Always bool
+
+	// Backoff is the delay before the first restart. It doubles with every
+	// further restart, up to MaxBackoff. Zero restarts immediately.
+	Backoff time.Duration
+
+	// MaxBackoff caps the delay between restarts. Zero means no cap.
+	MaxBackoff time.Duration
+
+	// MaxRestarts is how many restarts are allowed within Window. When the
+	// run function returns once more, the component fails with an error
+	// wrapping ErrRestartLimit that names it, and the group shuts down
+	// gracefully even if the component is not critical. Zero means no limit.
+	MaxRestarts int
+
+	// Window is the period MaxRestarts applies to, counting back from each
+	// restart. Zero counts every restart since the component started.
+	Window time.Duration
+
+	// Restartable reports whether an error the run function returned is
+	// worth a restart. Errors it reports false for fail the component as
+	// without a policy. Nil restarts every error.
+	Restartable func(err error) bool
+}
+
+// delay returns the delay before restart n, counting from zero.
+func (p *RestartPolicy) delay(n int) time.Duration {
+	d := p.Backoff
+	for range n {
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+		d *= 2
+	}
+	if p.MaxBackoff > 0 {
+		d = min(d, p.MaxBackoff)
+	}
+	return d
+}
+
+// Restart returns a ComponentOption that supervises a run-style component
+// added with Go: when its run function returns unexpectedly it is restarted
+// according to p rather than failing the component. Each error that causes a
+// restart is reported as a PhaseRun error through the logger, metrics,
+// WithOnError and Group.Errors, and the component is in StateRestarting while
+// it waits.
+func Restart(p RestartPolicy) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.restart = &p
+	})
+}
+
+// restarts is the restart history of a run-style component since it started.
+type restarts struct {
+	count  int         // restarts so far
+	recent []time.Time // times of the restarts within the policy's window
+}
+
+// add records a restart at now and returns the number of restarts within
+// window, or in total for a zero window.
+func (r *restarts) add(now time.Time, window time.Duration) int {
+	r.count++
+	if window <= 0 {
+		return r.count
+	}
+	i := 0
+	for i < len(r.recent) && now.Sub(r.recent[i]) >= window {
+		i++
+	}
+	r.recent = append(r.recent[i:], now)
+	return len(r.recent)
+}
+
+// restart reports that c's run function, which began at began, returned err
+// and waits to restart it, recording the restart in r. It reports false when
+// the component must not be restarted, or ctx was canceled while waiting,
+// together with the error the component fails with.
+func (g *Group) restart(ctx context.Context, c *component, r *restarts, began time.Time, err error) (bool, error) {
+	p := c.restart
+	if p == nil || (err == nil && !p.Always) || (err != nil && p.Restartable != nil && !p.Restartable(err)) || ctx.Err() != nil {
+		return false, err
+	}
+	n := r.count
+	if recent := r.add(time.Now(), p.Window); p.MaxRestarts > 0 && recent > p.MaxRestarts {
+		if err == nil {
+			err = ErrExited
+		}
+		if p.Window > 0 {
+			return false, fmt.Errorf("component %q: %w (%d restarts within %s): %w", c.name, ErrRestartLimit, p.MaxRestarts, p.Window, err)
+		}
+		return false, fmt.Errorf("component %q: %w (%d restarts): %w", c.name, ErrRestartLimit, p.MaxRestarts, err)
+	}
+	if !c.transition(StateRunning, StateRestarting) {
+		return false, err
+	}
+	c.life.end()
+	if err := g.transform(c, err); err != nil {
+		g.record(c, PhaseRun, began, err)
+	}
+
+	if !sleep(ctx, p.delay(n)) || !sleep(ctx, g.restartLimit.reserve(time.Now())) {
+		return false, err
+	}
+	if !c.transition(StateRestarting, StateRunning) {
+		return false, err
+	}
+	g.beginLife(c)
+	c.restarts.Add(1)
+	g.countRestart(c)
+	return true, err
+}
+
+// sleep waits for d and reports false if ctx was canceled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// tokenBucket limits the rate of restarts across a group. It is a
+// generic cell rate algorithm: tokens are handed out one interval apart,
+// with up to burst of them available at once.
+type tokenBucket struct {
+	interval time.Duration
+	burst    int
+
+	mu   sync.Mutex
+	next time.Time // when the bucket would be empty again
+}
+
+// newTokenBucket returns a bucket allowing n restarts per period, or nil for
+// no limit.
+func newTokenBucket(n int, per time.Duration) *tokenBucket {
+	if n <= 0 || per <= 0 {
+		return nil
+	}
+	return &tokenBucket{interval: per / time.Duration(n), burst: n}
+}
+
+// reserve takes a token at now and returns how long to wait until it may be
+// used. A nil bucket never waits.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.next.Before(now) {
+		b.next = now
+	}
+	wait := b.next.Sub(now) - time.Duration(b.burst-1)*b.interval
+	b.next = b.next.Add(b.interval)
+	return max(wait, 0)
+}
@@ -0,0 +1,162 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrRollingRestartAborted is returned, wrapping the cause, when a rolling
+// restart stops before every matched component was restarted.
+var ErrRollingRestartAborted = errors.New("rolling restart aborted")
+
+// RollingPolicy controls how Group.RollingRestart cycles components. The
+// zero value restarts one component at a time without pausing.
+type RollingPolicy struct {
+	// Batch is the number of components restarted concurrently in a step,
+	// at least 1.
+	Batch int
+
+	// Interval is the time to wait after a successful step before the next
+	// one begins, for the restarted components to take load.
+	Interval time.Duration
+
+	// Check verifies the group's health after each step, such as by
+	// querying the service's own health endpoint; nil for none. A failed
+	// check aborts the rollout.
+	Check func(ctx context.Context) error
+}
+
+// RollingRestart restarts the running components matched by sel in steps of
+// p.Batch components, in start order, while the rest of the group keeps
+// serving: each component is stopped and started again, and verified by its
+// check registered with Verify. After each step the group's health is
+// verified with p.Check and the next step begins after p.Interval, so workers
+// can be cycled without downtime.
+//
+// The first failed restart or health check aborts the rollout with an error
+// wrapping ErrRollingRestartAborted that names the components not restarted
+// yet. A component that fails to stop or start again is left failed, and the
+// failure is classified like the unexpected return of a component added with
+// Group.Go: a fatal error shuts the whole group down, while a degraded one
+// only marks the group degraded.
+func (g *Group) RollingRestart(ctx context.Context, sel Selector, p RollingPolicy) error {
+	g.mu.Lock()
+	var matched []*component
+	if s := g.running; s != nil && !g.stopping {
+		for _, wave := range s.waves {
+			for _, c := range wave {
+				if !c.stopping && c.loadState() == StateRunning && sel(c.info()) {
+					matched = append(matched, c)
+				}
+			}
+		}
+	}
+	g.mu.Unlock()
+	if len(matched) == 0 {
+		return nil
+	}
+
+	g.logGroup("group restarting subset")
+
+	batch := max(p.Batch, 1)
+	for i := 0; i < len(matched); i += batch {
+		if i > 0 && !sleep(ctx, p.Interval) {
+			return rollingAborted(matched[i:], ctx.Err())
+		}
+
+		step := matched[i:min(i+batch, len(matched))]
+		restarted := g.runWaves(ctx, [][]*component{step}, phaseConfig{
+			deadlineErr: ErrStopContextDeadlineExceeded,
+			inFlight:    StateRestarting,
+		}, g.restartComponent)
+		select {
+		case <-restarted.done:
+		case <-ctx.Done():
+			timeOut([][]*component{step}, StateRestarting)
+			return rollingAborted(matched[i:], ctx.Err())
+		}
+		if errs := restarted.errors(); len(errs) > 0 {
+			return rollingAborted(matched[i+len(step):], errors.Join(g.collate(errs)...))
+		}
+
+		if p.Check != nil {
+			if err := p.Check(ctx); err != nil {
+				return rollingAborted(matched[i+len(step):], fmt.Errorf("%w: %w", ErrVerifyFailed, err))
+			}
+		}
+	}
+	return nil
+}
+
+// restartComponent stops c and starts it again, unless the group is
+// shutting down.
+func (g *Group) restartComponent(ctx context.Context, c *component) error {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+
+	g.mu.Lock()
+	stopping := g.stopping || c.stopping
+	g.mu.Unlock()
+	if stopping || !c.transition(StateRunning, StateRestarting) {
+		return c.wrap(PhaseStop, ErrNotRunning)
+	}
+
+	c.life.end()
+	began := time.Now()
+	err := g.classify(c, PhaseStop, g.transform(c, g.callStop(withComponent(ctx, c), c, c.stop)))
+	if err != nil {
+		return g.restartFailed(c, PhaseStop, began, err)
+	}
+	g.record(c, PhaseStop, began, nil)
+
+	g.beginLife(c)
+	began = time.Now()
+	err = g.classify(c, PhaseStart, g.transform(c, g.callStart(withComponent(ctx, c), c, c.start)))
+	if err != nil {
+		return g.restartFailed(c, PhaseStart, began, err)
+	}
+	c.transition(StateRestarting, StateRunning)
+	c.restarts.Add(1)
+	g.record(c, PhaseStart, began, nil)
+	return nil
+}
+
+// restartFailed leaves c failed after its call in phase, which began at
+// began, failed with err during a rolling restart, and reacts to err's
+// severity like to a run-style component's unexpected return.
+func (g *Group) restartFailed(c *component, phase Phase, began time.Time, err error) error {
+	c.life.end()
+	c.transition(StateRestarting, StateFailed)
+
+	severity := SeverityOf(err)
+	if severity == SeverityDegraded {
+		g.degrade()
+	}
+	g.record(c, phase, began, err)
+	err = c.wrap(phase, err)
+	g.mu.Lock()
+	if c.err == nil {
+		c.err = err
+	}
+	g.mu.Unlock()
+	if severity == SeverityFatal {
+		g.exitGroup(err)
+	}
+	return err
+}
+
+// rollingAborted returns the error of a rolling restart that left pending
+// components unrestarted.
+func rollingAborted(pending []*component, err error) error {
+	if len(pending) == 0 {
+		return fmt.Errorf("%w: %w", ErrRollingRestartAborted, err)
+	}
+	names := make([]string, len(pending))
+	for i, c := range pending {
+		names[i] = c.name
+	}
+	return fmt.Errorf("%w: %s not restarted: %w", ErrRollingRestartAborted, strings.Join(names, ", "), err)
+}
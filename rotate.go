@@ -0,0 +1,91 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrRotateContextDeadlineExceeded is returned when rotating a secret exceeds the configured timeout.
+var ErrRotateContextDeadlineExceeded = errors.New("rotate context deadline exceeded")
+
+// Rotator is implemented by components that hold credentials, such as a
+// database pool re-authenticating with a new password. Register it with the
+// Rotates component option.
+type Rotator interface {
+	// Rotate switches to the current value of the named secret. Rotators
+	// should ignore secrets they do not use.
+	Rotate(ctx context.Context, secret string) error
+}
+
+// RotatorFunc adapts an ordinary function to the Rotator interface.
+type RotatorFunc func(ctx context.Context, secret string) error
+
+// Rotate calls f(ctx, secret).
+func (f RotatorFunc) Rotate(ctx context.Context, secret string) error {
+	return f(ctx, secret)
+}
+
+// Rotate asks every running component registered with Rotates to switch to
+// the current value of secret. Rotators run concurrently on the worker pool
+// within the rotate timeout. A trigger can be anything from an admin
+// endpoint to a configwatch component watching a mounted secret.
+//
+// Failures are partial: every rotator runs, and the errors of those that
+// failed are returned joined, each as a ComponentError in PhaseRotate.
+// Rotators still running when the timeout expires are reported in an error
+// wrapping ErrRotateContextDeadlineExceeded.
+func (g *Group) Rotate(ctx context.Context, secret string) error {
+	g.mu.Lock()
+	var rotators []*component
+	for _, c := range g.registry.list() {
+		if c.rotator != nil && c.loadState() == StateRunning {
+			rotators = append(rotators, c)
+		}
+	}
+	g.mu.Unlock()
+
+	return g.callAll(ctx, rotators, g.opts.rotateTimeout, PhaseRotate, ErrRotateContextDeadlineExceeded,
+		func(ctx context.Context, c *component) error {
+			return c.rotator.Rotate(ctx, secret)
+		}, nil)
+}
+
+// callAll calls fn for every component concurrently on the worker pool,
+// bounded by timeout, and returns the joined errors. The error of each call
+// is transformed, passed through then unless then is nil, recorded and
+// wrapped for phase. Calls that have not returned when the timeout expires
+// are abandoned and reported in an error wrapping deadlineErr.
+func (g *Group) callAll(ctx context.Context, cs []*component, timeout time.Duration, phase Phase, deadlineErr error, fn func(ctx context.Context, c *component) error, then func(c *component, err error) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	b := g.execute(len(cs), func(i int) error {
+		c := cs[i]
+		began := time.Now()
+		err := g.transform(c, fn(withComponent(ctx, c), c))
+		if then != nil {
+			err = then(c, err)
+		}
+		g.record(c, phase, began, err)
+		return c.wrap(phase, err)
+	}, nil)
+
+	var pending []string
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		for _, i := range b.unfinished() {
+			pending = append(pending, cs[i].name)
+		}
+	}
+
+	errs := g.collate(b.errors())
+	g.release(b)
+	if len(pending) > 0 {
+		errs = append(errs, fmt.Errorf("%w: waiting on %s", deadlineErr, strings.Join(pending, ", ")))
+	}
+	return errors.Join(errs...)
+}
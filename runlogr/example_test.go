@@ -0,0 +1,34 @@
+package runlogr_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/go-logr/logr/funcr"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/runlogr"
+)
+
+func ExampleWithLogger() {
+	// Drop durations from the output so that it is stable.
+	duration := regexp.MustCompile(` "duration"=\S+`)
+	logger := funcr.New(func(prefix, args string) {
+		fmt.Println(prefix, duration.ReplaceAllString(args, ""))
+	}, funcr.Options{})
+
+	g := run.NewGroup(runlogr.WithLogger(logger.WithName("lifecycle")))
+	g.Add(func() error {
+		return errors.New("connection refused")
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("db"))
+
+	_ = g.Wait(context.Background())
+	// Output:
+	// lifecycle "msg"="component start failed" "error"="connection refused" "component"="db" "phase"="start"
+	// lifecycle "level"=0 "msg"="group stopping"
+	// lifecycle "level"=0 "msg"="component stop succeeded" "component"="db" "phase"="stop"
+}
@@ -0,0 +1,10 @@
+module github.com/not-for-prod/run/runlogr
+
+go 1.24.3
+
+require (
+	github.com/go-logr/logr v1.4.3
+	github.com/not-for-prod/run v0.0.0-00010101000000-000000000000
+)
+
+replace github.com/not-for-prod/run => ../
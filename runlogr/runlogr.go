@@ -0,0 +1,87 @@
+// Package runlogr sends a run.Group's structured lifecycle logs to a logr
+// logger, the logging interface of controller-runtime and other Kubernetes
+// libraries.
+package runlogr
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/go-logr/logr"
+
+	"github.com/not-for-prod/run"
+)
+
+// WithLogger returns a run.Option that logs the group's lifecycle to l, with
+// the same component, phase, duration and error fields as run.WithLogger.
+func WithLogger(l logr.Logger) run.Option {
+	return run.WithLogger(slog.New(NewHandler(l)))
+}
+
+// Handler is a slog.Handler that writes records to a logr.Logger.
+//
+// Error-level records are passed to Logger.Error with the record's "error"
+// attribute as the error; other records go to Logger.Info, with debug
+// records at verbosity 1.
+type Handler struct {
+	l logr.Logger
+}
+
+var _ slog.Handler = Handler{}
+
+// NewHandler returns a slog.Handler writing to l.
+func NewHandler(l logr.Logger) Handler {
+	return Handler{l: l}
+}
+
+// Enabled implements slog.Handler.
+func (h Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelError || h.logger(level).Enabled()
+}
+
+// Handle implements slog.Handler.
+func (h Handler) Handle(_ context.Context, r slog.Record) error {
+	var err error
+	kvs := make([]any, 0, 2*r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		if e, ok := a.Value.Any().(error); ok && a.Key == "error" && r.Level >= slog.LevelError {
+			err = e
+			return true
+		}
+		kvs = append(kvs, a.Key, a.Value.Resolve().Any())
+		return true
+	})
+
+	if r.Level >= slog.LevelError {
+		h.l.Error(err, r.Message, kvs...)
+		return nil
+	}
+	h.logger(r.Level).Info(r.Message, kvs...)
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	kvs := make([]any, 0, 2*len(attrs))
+	for _, a := range attrs {
+		kvs = append(kvs, a.Key, a.Value.Resolve().Any())
+	}
+	return Handler{l: h.l.WithValues(kvs...)}
+}
+
+// WithGroup implements slog.Handler. logr has no attribute groups, so the
+// group name is appended to the logger name instead.
+func (h Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return Handler{l: h.l.WithName(name)}
+}
+
+// logger returns the logr verbosity level matching a non-error slog level.
+func (h Handler) logger(level slog.Level) logr.Logger {
+	if level < slog.LevelInfo {
+		return h.l.V(1)
+	}
+	return h.l
+}
@@ -0,0 +1,55 @@
+package runzap_test
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/runzap"
+)
+
+func ExampleWithLogger() {
+	// Drop durations from the output so that it is stable.
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg", LevelKey: "level", EncodeLevel: zapcore.LowercaseLevelEncoder})
+	core := zapcore.NewCore(enc, zapcore.AddSync(os.Stdout), zapcore.InfoLevel)
+	logger := zap.New(&withoutDuration{core})
+
+	g := run.NewGroup(runzap.WithLogger(logger))
+	g.Add(func() error {
+		return errors.New("connection refused")
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("db"))
+
+	_ = g.Wait(context.Background())
+	// Output:
+	// {"level":"error","msg":"component start failed","component":"db","phase":"start","error":"connection refused"}
+	// {"level":"info","msg":"group stopping"}
+	// {"level":"info","msg":"component stop succeeded","component":"db","phase":"stop"}
+}
+
+// withoutDuration is a zapcore.Core that drops the duration field.
+type withoutDuration struct {
+	zapcore.Core
+}
+
+func (c *withoutDuration) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *withoutDuration) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	kept := fields[:0:0]
+	for _, f := range fields {
+		if f.Key != "duration" {
+			kept = append(kept, f)
+		}
+	}
+	return c.Core.Write(ent, kept)
+}
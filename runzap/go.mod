@@ -0,0 +1,12 @@
+module github.com/not-for-prod/run/runzap
+
+go 1.24.3
+
+require (
+	github.com/not-for-prod/run v0.0.0-00010101000000-000000000000
+	go.uber.org/zap v1.27.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
+
+replace github.com/not-for-prod/run => ../
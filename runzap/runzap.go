@@ -0,0 +1,143 @@
+// Package runzap sends a run.Group's structured lifecycle logs to a zap
+// logger.
+package runzap
+
+import (
+	"context"
+	"log/slog"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/not-for-prod/run"
+)
+
+// WithLogger returns a run.Option that logs the group's lifecycle to l, with
+// the same component, phase, duration and error fields as run.WithLogger.
+func WithLogger(l *zap.Logger) run.Option {
+	return run.WithLogger(slog.New(NewHandler(l.Core())))
+}
+
+// Handler is a slog.Handler that writes records to a zapcore.Core.
+type Handler struct {
+	core   zapcore.Core
+	fields []zapcore.Field // fields added with WithAttrs and WithGroup
+}
+
+var _ slog.Handler = (*Handler)(nil)
+
+// NewHandler returns a slog.Handler writing to core.
+func NewHandler(core zapcore.Core) *Handler {
+	return &Handler{core: core}
+}
+
+// Enabled implements slog.Handler.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(zapLevel(level))
+}
+
+// Handle implements slog.Handler.
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	ent := zapcore.Entry{
+		Level:   zapLevel(r.Level),
+		Time:    r.Time,
+		Message: r.Message,
+	}
+
+	ce := h.core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+
+	fields := make([]zapcore.Field, 0, len(h.fields)+r.NumAttrs())
+	fields = append(fields, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, a)
+		return true
+	})
+
+	ce.Write(fields...)
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := append([]zapcore.Field(nil), h.fields...)
+	for _, a := range attrs {
+		fields = appendAttr(fields, a)
+	}
+	return &Handler{core: h.core, fields: fields}
+}
+
+// WithGroup implements slog.Handler.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	fields := append([]zapcore.Field(nil), h.fields...)
+	return &Handler{core: h.core, fields: append(fields, zap.Namespace(name))}
+}
+
+// appendAttr converts a slog attribute to zap fields.
+func appendAttr(fields []zapcore.Field, a slog.Attr) []zapcore.Field {
+	v := a.Value.Resolve()
+	if a.Key == "" && v.Kind() != slog.KindGroup {
+		return fields
+	}
+
+	switch v.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(a.Key, v.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(a.Key, v.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(a.Key, v.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(a.Key, v.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(a.Key, v.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(a.Key, v.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(a.Key, v.Time()))
+	case slog.KindGroup:
+		var group []zapcore.Field
+		for _, ga := range v.Group() {
+			group = appendAttr(group, ga)
+		}
+		if a.Key == "" {
+			return append(fields, group...)
+		}
+		return append(fields, zap.Object(a.Key, objectFields(group)))
+	default:
+		if err, ok := v.Any().(error); ok {
+			return append(fields, zap.NamedError(a.Key, err))
+		}
+		return append(fields, zap.Any(a.Key, v.Any()))
+	}
+}
+
+// objectFields encodes a list of fields as a nested zap object.
+type objectFields []zapcore.Field
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.
+func (fs objectFields) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range fs {
+		f.AddTo(enc)
+	}
+	return nil
+}
+
+// zapLevel maps a slog level to the closest zap level.
+func zapLevel(l slog.Level) zapcore.Level {
+	switch {
+	case l >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case l >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case l >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
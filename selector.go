@@ -0,0 +1,54 @@
+package run
+
+import "slices"
+
+// Selector reports whether a component is selected. Selectors choose the
+// components a group runs with WithSelector.
+type Selector func(ComponentInfo) bool
+
+// Tagged returns a Selector matching components that carry at least one of
+// the given tags.
+func Tagged(tags ...string) Selector {
+	return func(info ComponentInfo) bool {
+		for _, tag := range info.Tags {
+			if slices.Contains(tags, tag) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Untagged returns a Selector matching components without tags, such as
+// shared infrastructure used by every role.
+func Untagged() Selector {
+	return func(info ComponentInfo) bool {
+		return len(info.Tags) == 0
+	}
+}
+
+// Names returns a Selector matching components by name.
+func Names(names ...string) Selector {
+	return func(info ComponentInfo) bool {
+		return slices.Contains(names, info.Name)
+	}
+}
+
+// AnyOf returns a Selector matching components matched by any of sels.
+func AnyOf(sels ...Selector) Selector {
+	return func(info ComponentInfo) bool {
+		for _, sel := range sels {
+			if sel(info) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a Selector matching components not matched by sel.
+func Not(sel Selector) Selector {
+	return func(info ComponentInfo) bool {
+		return !sel(info)
+	}
+}
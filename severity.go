@@ -0,0 +1,135 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDependencyFailed is returned for components that were not started
+// because a dependency failed with a non-fatal severity.
+var ErrDependencyFailed = errors.New("dependency failed")
+
+// Severity classifies a component error and decides how the group reacts to
+// it.
+type Severity int
+
+const (
+	// SeverityFatal errors abort the start phase and shut the group down.
+	// It is the severity of every unclassified error.
+	SeverityFatal Severity = iota
+
+	// SeverityDegraded errors leave the component failed while the rest of
+	// the group keeps running, and mark the group degraded.
+	SeverityDegraded
+
+	// SeverityIgnorable errors are reported and logged only; they neither
+	// abort the group nor mark it degraded, and are left out of the error
+	// returned by Wait.
+	SeverityIgnorable
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityFatal:
+		return "fatal"
+	case SeverityDegraded:
+		return "degraded"
+	case SeverityIgnorable:
+		return "ignorable"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// severityError marks an error with a severity other than SeverityFatal.
+type severityError struct {
+	err      error
+	severity Severity
+}
+
+// Error returns the message of the underlying error.
+func (e *severityError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *severityError) Unwrap() error {
+	return e.err
+}
+
+// Degraded marks err as SeverityDegraded. Components return it from a start
+// or stop function when they are not critical for the group. It returns nil
+// for a nil err.
+func Degraded(err error) error {
+	return withSeverity(err, SeverityDegraded)
+}
+
+// Ignorable marks err as SeverityIgnorable. It returns nil for a nil err.
+func Ignorable(err error) error {
+	return withSeverity(err, SeverityIgnorable)
+}
+
+// withSeverity marks err with severity, leaving fatal and nil errors as they
+// are.
+func withSeverity(err error, severity Severity) error {
+	if err == nil || severity == SeverityFatal || SeverityOf(err) == severity {
+		return err
+	}
+	return &severityError{err: err, severity: severity}
+}
+
+// SeverityOf returns the severity err was marked with by Degraded, Ignorable
+// or the classifier set with WithClassifier, and SeverityFatal for any other
+// error.
+func SeverityOf(err error) Severity {
+	var se *severityError
+	if errors.As(err, &se) {
+		return se.severity
+	}
+	return SeverityFatal
+}
+
+// classify marks err with the severity assigned by the configured
+// classifier, if any. Fatal errors of non-critical components are degraded,
+// except for an exceeded restart limit, and so are those of quorum members
+// while their quorum holds.
+func (g *Group) classify(c *component, phase Phase, err error) error {
+	if err == nil {
+		return nil
+	}
+	if g.opts.classify != nil {
+		err = withSeverity(err, g.opts.classify(c.name, phase, err))
+	}
+	if c.optional && SeverityOf(err) == SeverityFatal && !errors.Is(err, ErrRestartLimit) {
+		err = withSeverity(err, SeverityDegraded)
+	}
+	return g.classifyQuorum(c, phase, err)
+}
+
+// Degraded reports whether a component failed with SeverityDegraded while
+// the rest of the group kept running.
+func (g *Group) Degraded() bool {
+	return g.degraded.Load()
+}
+
+// degrade marks the group degraded.
+func (g *Group) degrade() {
+	if !g.degraded.Swap(true) {
+		g.logGroup("group degraded")
+	}
+}
+
+// failedDependency returns a dependency of c that is not running, so c cannot
+// start either. The caller must hold g.mu.
+func (g *Group) failedDependency(c *component) *component {
+	if g.running == nil {
+		return nil
+	}
+	for _, p := range g.running.deps[c] {
+		if p.loadState() != StateRunning {
+			return p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package run
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Run runs g until one of signals arrives, or SIGINT or SIGTERM without
+// any, or ctx is canceled: it is the one-liner most main functions need.
+//
+//	if err := run.Run(context.Background(), g); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Run returns nil when the group stopped cleanly, including on a signal,
+// and an error only for real failures: a component failed to start, ended
+// the group or failed to stop. The signal is logged through the group's
+// logger. After it, default signal handling is restored, so a second
+// signal kills a process stuck in shutdown.
+func Run(ctx context.Context, g *Group, signals ...os.Signal) error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	defer signal.Stop(ch)
+	go func() {
+		select {
+		case sig := <-ch:
+			signal.Stop(ch)
+			g.logGroup("signal received, shutting down", slog.String("signal", sig.String()))
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return g.Wait(ctx)
+}
@@ -0,0 +1,65 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrStarted is returned by Start when the group was already started by
+// Start or Wait, and by Wait when an earlier Wait already ran the group.
+var ErrStarted = errors.New("group already started")
+
+// Start starts the group in the background, for frameworks that own the
+// main loop, and returns once every component has started, warmed up and
+// the group is ready. If the start phase fails, Start returns the error
+// Wait would have returned, after the components that started were
+// stopped.
+//
+// ctx only bounds the start: its values are kept, but canceling it after
+// Start returned does not stop the group. If ctx is done before the group
+// is ready, the group is stopped and Start returns ctx.Err().
+//
+// Afterwards, Wait blocks until the run ends and Stop shuts it down. Start
+// after Wait returns ErrStarted without starting anything.
+func (g *Group) Start(ctx context.Context) error {
+	g.mu.Lock()
+	if g.startCalled || g.waitCalled {
+		g.mu.Unlock()
+		return ErrStarted
+	}
+	g.startCalled = true
+	g.mu.Unlock()
+
+	ready := make(chan struct{})
+	var once sync.Once
+	g.OnReadyChange(func(r bool) {
+		if r {
+			once.Do(func() { close(ready) })
+		}
+	})
+	go func() {
+		_ = g.serve(context.WithoutCancel(ctx))
+	}()
+
+	select {
+	case <-ready:
+		return nil
+	case <-g.stopped:
+		return g.Err()
+	case <-ctx.Done():
+		_ = g.Stop(context.WithoutCancel(ctx))
+		return ctx.Err()
+	}
+}
+
+// join waits for the run begun by Start to end, stopping it when ctx is
+// done, and returns its error.
+func (g *Group) join(ctx context.Context) error {
+	select {
+	case <-g.stopped:
+	case <-ctx.Done():
+		_ = g.Stop(context.WithoutCancel(ctx))
+	}
+	return g.Err()
+}
@@ -0,0 +1,181 @@
+package run
+
+import "time"
+
+// State is a point in a component's lifecycle.
+//
+// A component moves from StateRegistered through StateStarting to
+// StateRunning, and from there through StateStopping to StateStopped.
+// StateFailed and StateTimedOut record that a start or stop call returned an
+// error or did not return within its deadline; a component whose start failed
+// remains failed after it has been stopped. A supervised run-style component
+// moves between StateRunning and StateRestarting, and any running component
+// can be paused and resumed.
+type State int32
+
+const (
+	// StateRegistered is the state of a component that has not been started.
+	StateRegistered State = iota
+
+	// StateStarting is the state of a component whose start function is running.
+	StateStarting
+
+	// StateRunning is the state of a component that started successfully.
+	StateRunning
+
+	// StateStopping is the state of a component whose stop function is running.
+	StateStopping
+
+	// StateStopped is the state of a component whose stop function returned nil.
+	StateStopped
+
+	// StateFailed is the state of a component whose start or stop function returned an error.
+	StateFailed
+
+	// StateTimedOut is the state of a component whose start or stop function
+	// was still running when its deadline expired.
+	StateTimedOut
+
+	// StateRestarting is the state of a run-style component whose run
+	// function returned and that waits to be restarted by its RestartPolicy.
+	StateRestarting
+
+	// StatePaused is the state of a component paused with Group.Pause.
+	StatePaused
+)
+
+// String returns the lower-case name of the state.
+func (s State) String() string {
+	switch s {
+	case StateRegistered:
+		return "registered"
+	case StateStarting:
+		return "starting"
+	case StateRunning:
+		return "running"
+	case StateStopping:
+		return "stopping"
+	case StateStopped:
+		return "stopped"
+	case StateFailed:
+		return "failed"
+	case StateTimedOut:
+		return "timed out"
+	case StateRestarting:
+		return "restarting"
+	case StatePaused:
+		return "paused"
+	default:
+		return "unknown"
+	}
+}
+
+// States returns the current state of every registered component, keyed by
+// component name. It is safe to call at any time, including while Wait is
+// starting or stopping the group.
+func (g *Group) States() map[string]State {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	components := g.registry.list()
+	states := make(map[string]State, len(components))
+	for _, c := range components {
+		states[c.name] = c.loadState()
+	}
+	return states
+}
+
+// loadState returns the component's current state.
+func (c *component) loadState() State {
+	return State(c.state.Load())
+}
+
+// transition moves the component from one state to another. It reports false
+// and leaves the state unchanged when the component is not in from, for
+// example because a start call returned after its deadline had expired.
+func (c *component) transition(from, to State) bool {
+	if !c.state.CompareAndSwap(int32(from), int32(to)) {
+		return false
+	}
+	c.recordState(from, to)
+	return true
+}
+
+// swap moves the component to state to, whatever its current state, and
+// returns the previous state.
+func (c *component) swap(to State) State {
+	from := State(c.state.Swap(int32(to)))
+	c.recordState(from, to)
+	return from
+}
+
+// recordState notes when the component entered its state and adds the
+// state change to the flight recorder and the state log.
+func (c *component) recordState(from, to State) {
+	now := time.Now()
+	prev := c.since.Swap(now.UnixNano())
+	c.recorder.add(Event{Component: c.name, Message: from.String() + " -> " + to.String()})
+	if c.stateLog != nil {
+		var elapsed time.Duration
+		if prev != 0 {
+			elapsed = now.Sub(time.Unix(0, prev))
+		}
+		c.stateLog.log(c, from, to, elapsed)
+	}
+}
+
+// finish moves the component out of an in-flight state according to the
+// result of its start or stop call.
+func (c *component) finish(inFlight, done State, err error) {
+	if err != nil {
+		c.transition(inFlight, StateFailed)
+		return
+	}
+	c.transition(inFlight, done)
+}
+
+// timeOut marks every component of waves still in the inFlight state as
+// timed out. A start that timed out no longer holds up stopping the
+// component.
+func timeOut(waves [][]*component, inFlight State) {
+	for _, wave := range waves {
+		for _, c := range wave {
+			if c.transition(inFlight, StateTimedOut) && inFlight == StateStarting {
+				c.endStart()
+			}
+		}
+	}
+}
+
+// ComponentStatus is a snapshot of a single component.
+type ComponentStatus struct {
+	Name     string            // component name
+	Labels   map[string]string // component labels, must not be modified
+	State    State             // current lifecycle state
+	Since    time.Time         // time the component entered State, zero while registered
+	Restarts int               // restarts by its RestartPolicy, Group.RollingRestart or the health poller
+	Err      error             // start or stop error, as returned by Handle.Err
+}
+
+// Status returns a snapshot of every registered component in registration
+// order, for status pages and tooling.
+func (g *Group) Status() []ComponentStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	components := g.registry.list()
+	status := make([]ComponentStatus, len(components))
+	for i, c := range components {
+		status[i] = ComponentStatus{
+			Name:     c.name,
+			Labels:   c.labels,
+			State:    c.loadState(),
+			Restarts: int(c.restarts.Load()),
+			Err:      c.err,
+		}
+		if since := c.since.Load(); since != 0 {
+			status[i].Since = time.Unix(0, since)
+		}
+	}
+	return status
+}
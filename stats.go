@@ -0,0 +1,264 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Stats are lifecycle statistics of a group kept across process restarts by
+// a StatsStore.
+type Stats struct {
+	Runs       int                       `json:"runs"`       // completed runs of the group
+	History    *History                  `json:"history"`    // durations of start and stop calls
+	Components map[string]ComponentStats `json:"components"` // by component name
+}
+
+// ComponentStats are the lifecycle statistics of a single component.
+type ComponentStats struct {
+	Restarts      int `json:"restarts"`       // restarts by its RestartPolicy, over all runs
+	StartFailures int `json:"start_failures"` // consecutive runs in which its start failed
+	StopTimeouts  int `json:"stop_timeouts"`  // consecutive runs in which its stop timed out
+
+	Start     time.Duration `json:"start"`      // duration of its start call in the latest run
+	Stop      time.Duration `json:"stop"`       // duration of its stop call in the latest run
+	PrevStart time.Duration `json:"prev_start"` // duration of its start call in the run before
+	PrevStop  time.Duration `json:"prev_stop"`  // duration of its stop call in the run before
+}
+
+// StatsStore persists Stats across process restarts.
+type StatsStore interface {
+	// LoadStats returns the stored stats, or nil if there are none yet.
+	LoadStats(ctx context.Context) (*Stats, error)
+
+	// SaveStats stores stats, replacing the stored ones.
+	SaveStats(ctx context.Context, stats *Stats) error
+}
+
+// fileStats is the StatsStore returned by FileStats.
+type fileStats struct {
+	path string
+}
+
+// FileStats returns a StatsStore keeping the stats as JSON in the file at
+// path, which is replaced atomically on every save.
+func FileStats(path string) StatsStore {
+	return fileStats{path: path}
+}
+
+// LoadStats reads the stats file.
+func (f fileStats) LoadStats(context.Context) (*Stats, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stats Stats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// SaveStats writes the stats file.
+func (f fileStats) SaveStats(_ context.Context, stats *Stats) error {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	return writeFile(f.path, data)
+}
+
+// writeFile replaces the file at path with data atomically, so readers see
+// either the old or the new content.
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// WithStats returns an Option that keeps lifecycle statistics in store
+// across process restarts: they are loaded when Wait begins and saved when
+// it returns. The recorded call durations feed WithAdaptiveTimeouts, and
+// the statistics are available from Group.Stats, such as to compare a run
+// with the one before. Errors loading or saving the statistics are logged
+// and do not affect the group.
+//
+// Default is to keep no statistics.
+func WithStats(store StatsStore) Option {
+	return optionFunc(func(o *options) {
+		o.stats = store
+	})
+}
+
+// Stats returns a snapshot of the group's lifecycle statistics, including
+// those of the current run. It returns the zero Stats without WithStats.
+func (g *Group) Stats() Stats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	stats := g.stats
+	if stats.Components != nil {
+		stats.Components = make(map[string]ComponentStats, len(g.stats.Components))
+		for name, cs := range g.stats.Components {
+			stats.Components[name] = cs
+		}
+	}
+	return stats
+}
+
+// loadStats loads the statistics of earlier runs.
+func (g *Group) loadStats(ctx context.Context) {
+	store := g.opts.stats
+	if store == nil {
+		return
+	}
+	stats, err := store.LoadStats(ctx)
+	if err != nil {
+		g.logStats("stats not loaded", err)
+	}
+	if stats == nil {
+		stats = &Stats{}
+	}
+	if stats.Components == nil {
+		stats.Components = make(map[string]ComponentStats)
+	}
+	for name, cs := range stats.Components {
+		cs.PrevStart, cs.PrevStop = cs.Start, cs.Stop
+		cs.Start, cs.Stop = 0, 0
+		stats.Components[name] = cs
+	}
+
+	// The adaptive timeouts learn from the loaded durations.
+	h := NewHistory(0)
+	if a := g.opts.adaptive; a != nil {
+		h = a.h
+	}
+	if stats.History != nil {
+		h.replace(stats.History)
+	}
+	stats.History = h
+
+	g.mu.Lock()
+	g.stats = *stats
+	g.mu.Unlock()
+}
+
+// saveStats completes the statistics of the run and saves them.
+func (g *Group) saveStats() {
+	store := g.opts.stats
+	if store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), g.opts.stopTimeout)
+	defer cancel()
+
+	g.mu.Lock()
+	g.stats.Runs++
+	for _, c := range g.registry.list() {
+		if !c.attempted {
+			continue
+		}
+		cs := g.stats.Components[c.name]
+		started := isClosed(c.started)
+		if started {
+			cs.StartFailures = 0
+		} else {
+			cs.StartFailures++
+		}
+		if started && c.loadState() == StateTimedOut {
+			cs.StopTimeouts++
+		} else {
+			cs.StopTimeouts = 0
+		}
+		g.stats.Components[c.name] = cs
+	}
+	stats := g.stats
+	alerts := g.alerts(stats)
+	g.mu.Unlock()
+
+	for _, alert := range alerts {
+		g.opts.alert(alert)
+	}
+
+	if err := store.SaveStats(ctx, &stats); err != nil {
+		g.logStats("stats not saved", err)
+	}
+}
+
+// observeStats records that c's call in phase took d.
+func (g *Group) observeStats(c *component, phase Phase, d time.Duration) {
+	if g.opts.stats == nil || (phase != PhaseStart && phase != PhaseStop) {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.stats.Components == nil {
+		return
+	}
+	if g.opts.adaptive == nil {
+		// Otherwise the adaptive timeouts record into the same history.
+		g.stats.History.add(c.name, phase, d)
+	}
+	cs := g.stats.Components[c.name]
+	if phase == PhaseStart {
+		cs.Start = d
+	} else {
+		cs.Stop = d
+	}
+	g.stats.Components[c.name] = cs
+}
+
+// countRestart records that c was restarted by its RestartPolicy.
+func (g *Group) countRestart(c *component) {
+	if g.opts.stats == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.stats.Components == nil {
+		return
+	}
+	cs := g.stats.Components[c.name]
+	cs.Restarts++
+	g.stats.Components[c.name] = cs
+}
+
+// isClosed reports whether ch is closed.
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// logStats logs a failure to load or save the statistics.
+func (g *Group) logStats(msg string, err error) {
+	g.recorder.add(Event{Message: msg, Err: err})
+	if l := g.opts.logger; l != nil {
+		l.LogAttrs(context.Background(), slog.LevelError, msg, slog.Any("error", err))
+	}
+}
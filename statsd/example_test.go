@@ -0,0 +1,47 @@
+package statsd_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/statsd"
+)
+
+func ExampleNew() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var buf bytes.Buffer
+	sink := statsd.New(&buf, statsd.WithPrefix("checkout."), statsd.WithTags("env:prod"))
+
+	g := run.NewGroup(run.WithMetrics(sink))
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			cancel()
+		}
+	})
+	g.Add(func() error {
+		return nil
+	}, func(ctx context.Context) error {
+		return nil
+	}, run.Named("db"))
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("error:", err)
+	}
+
+	// Durations vary from run to run; print everything but the histograms.
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if !strings.Contains(line, "|h") {
+			fmt.Println(line)
+		}
+	}
+	// Output:
+	// checkout.run_component_calls_total:1|c|#env:prod,component:db,outcome:ok,phase:start
+	// checkout.run_group_ready:1|g|#env:prod
+	// checkout.run_group_ready:0|g|#env:prod
+	// checkout.run_component_calls_total:1|c|#env:prod,component:db,outcome:ok,phase:stop
+}
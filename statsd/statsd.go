@@ -0,0 +1,122 @@
+// Package statsd reports run.Group lifecycle metrics as StatsD packets with
+// DogStatsD-style tags, for Datadog agents and other StatsD servers.
+package statsd
+
+import (
+	"io"
+	"maps"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/not-for-prod/run"
+)
+
+// Sink is a run.Metrics implementation that writes one StatsD line per
+// measurement. Counters are sent as "c", gauges as "g" and histograms as "h";
+// labels become tags such as "#component:db,outcome:ok,phase:start".
+type Sink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	prefix string
+	tags   []string
+	buf    []byte
+}
+
+var _ run.Metrics = (*Sink)(nil)
+
+// Option configures a Sink.
+type Option func(*Sink)
+
+// WithPrefix returns an Option that prepends prefix to every metric name,
+// for example "checkout." to namespace the metrics of one service.
+func WithPrefix(prefix string) Option {
+	return func(s *Sink) {
+		s.prefix = prefix
+	}
+}
+
+// WithTags returns an Option that adds constant tags in "key:value" form to
+// every metric, for example "env:prod".
+func WithTags(tags ...string) Option {
+	return func(s *Sink) {
+		s.tags = append(s.tags, tags...)
+	}
+}
+
+// Dial returns a Sink sending UDP packets to the StatsD server at addr, for
+// example "127.0.0.1:8125".
+func Dial(addr string, opts ...Option) (*Sink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return New(conn, opts...), nil
+}
+
+// New returns a Sink writing each line to w in a single Write call, which
+// maps to one datagram for packet-oriented writers.
+func New(w io.Writer, opts ...Option) *Sink {
+	s := &Sink{w: w}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Close closes the underlying writer if it implements io.Closer.
+func (s *Sink) Close() error {
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Count implements run.Metrics.
+func (s *Sink) Count(name string, delta float64, labels map[string]string) {
+	s.send(name, delta, "c", labels)
+}
+
+// Gauge implements run.Metrics.
+func (s *Sink) Gauge(name string, value float64, labels map[string]string) {
+	s.send(name, value, "g", labels)
+}
+
+// Observe implements run.Metrics.
+func (s *Sink) Observe(name string, value float64, labels map[string]string) {
+	s.send(name, value, "h", labels)
+}
+
+// send formats and writes a single line. Write errors are ignored: metrics
+// are best effort and must never affect the lifecycle.
+func (s *Sink) send(name string, value float64, typ string, labels map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.buf[:0]
+	b = append(b, s.prefix...)
+	b = append(b, name...)
+	b = append(b, ':')
+	b = strconv.AppendFloat(b, value, 'f', -1, 64)
+	b = append(b, '|')
+	b = append(b, typ...)
+
+	if len(s.tags) > 0 || len(labels) > 0 {
+		b = append(b, "|#"...)
+		b = append(b, strings.Join(s.tags, ",")...)
+		for i, k := range slices.Sorted(maps.Keys(labels)) {
+			if i > 0 || len(s.tags) > 0 {
+				b = append(b, ',')
+			}
+			b = append(b, k...)
+			b = append(b, ':')
+			b = append(b, labels[k]...)
+		}
+	}
+	b = append(b, '\n')
+
+	_, _ = s.w.Write(b)
+	s.buf = b
+}
@@ -0,0 +1,52 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrStopCheck is returned, wrapped with the check's name and error, when a
+// check registered with StopCheck fails.
+var ErrStopCheck = errors.New("stop check failed")
+
+// stopCheck is a named check registered with StopCheck.
+type stopCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// StopCheck registers a check that runs once the stop phase has completed,
+// for example that ports were released, temporary files removed or no jobs
+// are in flight anymore. Checks run in registration order within the stop
+// timeout, and each failure is appended to the error returned by Wait and
+// Stop as an error wrapping ErrStopCheck, so shutdown bugs fail integration
+// tests loudly instead of leaking state silently.
+func (g *Group) StopCheck(name string, check func(ctx context.Context) error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.stopChecks = append(g.stopChecks, stopCheck{name: name, check: check})
+}
+
+// checkStopped runs the checks registered with StopCheck and returns their
+// joined errors.
+func (g *Group) checkStopped() error {
+	g.mu.Lock()
+	checks := g.stopChecks
+	g.mu.Unlock()
+	if len(checks) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.opts.stopTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, c := range checks {
+		if err := c.check(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %w", ErrStopCheck, c.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
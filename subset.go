@@ -0,0 +1,60 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// StopSubset gracefully stops the components matched by sel while the rest
+// of the group keeps running, such as all consumers during a backfill. The
+// matched components stop in stop order among themselves, each wave
+// concurrently, like in the group's own stop phase; drainers and force stop
+// functions are not called. Running dependents that sel does not match are
+// left running, so select them too if they cannot serve without their
+// dependencies.
+//
+// Stopped components are not restarted, and stopping them does not stop the
+// group. Stop errors are returned joined, each as a ComponentError, and the
+// components' stop errors are reported like those of Handle.Stop; stop calls
+// still running when ctx is done are reported in an error wrapping
+// ErrStopContextDeadlineExceeded.
+func (g *Group) StopSubset(ctx context.Context, sel Selector) error {
+	g.mu.Lock()
+	var waves [][]*component
+	if s := g.running; s != nil {
+		waves = s.stopWaves(func(c *component) bool {
+			return !c.stopping && sel(c.info())
+		})
+	} else {
+		// Before Wait, matched components are marked stopped and will not
+		// start.
+		for _, c := range slices.Backward(g.registry.list()) {
+			if !c.stopping && sel(c.info()) {
+				waves = append(waves, []*component{c})
+			}
+		}
+	}
+	g.mu.Unlock()
+	if len(waves) == 0 {
+		return nil
+	}
+
+	g.logGroup("group stopping subset")
+
+	stopped := g.runWaves(ctx, waves, phaseConfig{
+		deadlineErr: ErrStopContextDeadlineExceeded,
+		inFlight:    StateStopping,
+	}, func(ctx context.Context, c *component) error {
+		_, err := g.stopComponent(ctx, c)
+		return err
+	})
+
+	select {
+	case <-stopped.done:
+	case <-ctx.Done():
+		timeOut(waves, StateStopping)
+		return errors.Join(append(g.collate(stopped.errors()), ErrStopContextDeadlineExceeded)...)
+	}
+	return errors.Join(g.collate(stopped.errors())...)
+}
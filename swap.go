@@ -0,0 +1,68 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Swap replaces the running component with the given name by a new
+// instance without a gap, such as a plugin backend: start is called first
+// and the new instance is verified with the component's check registered
+// with Verify, and only then is the old instance stopped. From then on the
+// component is stopped with stop, and its name, options and dependents stay
+// the same.
+//
+// If the new instance fails to start or verify, stop is called to release
+// what it acquired, the old instance keeps running, and the error is
+// returned as a ComponentError in PhaseSwap, untransformed if the
+// transformer set with WithErrorTransformer drops it. An error stopping the
+// old instance is returned as a ComponentError in PhaseStop; the swap is
+// complete nonetheless. Swapping a component that is not running returns an
+// error wrapping ErrNotRunning.
+func (g *Group) Swap(ctx context.Context, name string, start Start, stop Stop) error {
+	c, err := g.pausable(name)
+	if err != nil {
+		return err
+	}
+
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+
+	if c.loadState() != StateRunning {
+		return fmt.Errorf("%q: %w", name, ErrNotRunning)
+	}
+
+	// The new instance runs alongside the old one until it is verified, so
+	// it gets a component context of its own.
+	next := &lifetime{}
+	next.begin(withComponent(g.life.context(), c))
+	nextCtx := withLifetime(withComponent(ctx, c), next)
+	startNext := func(context.Context) error { return start() }
+
+	began := time.Now()
+	if raw := g.callStart(nextCtx, c, startNext); raw != nil {
+		next.end()
+		raw = errors.Join(raw, stop(nextCtx))
+		err := g.transform(c, raw)
+		if err == nil {
+			// The swap did not happen, whatever the transformer made of
+			// the failure.
+			err = raw
+		}
+		g.record(c, PhaseSwap, began, err)
+		return c.wrap(PhaseSwap, err)
+	}
+	g.record(c, PhaseSwap, began, nil)
+
+	c.life.replace(next)
+	began = time.Now()
+	err = g.transform(c, g.callStop(withComponent(ctx, c), c, c.stop))
+	g.record(c, PhaseStop, began, err)
+
+	g.mu.Lock()
+	c.start, c.stop = startNext, stop
+	g.mu.Unlock()
+	return c.wrap(PhaseStop, err)
+}
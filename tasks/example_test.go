@@ -0,0 +1,56 @@
+package tasks_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/tasks"
+)
+
+func ExampleScheduler_Register() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := tasks.New(tasks.WithWorkers(2))
+	g := run.NewGroup()
+	s.Register(g)
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		_ = s.Submit(func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			fmt.Println("welcome email sent")
+			return nil
+		})
+		// Shut down while the task is still running; it is drained.
+		cancel()
+	})
+
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("wait error:", err)
+	}
+	fmt.Println(errors.Is(s.Submit(func(ctx context.Context) error { return nil }), tasks.ErrStopped))
+	// Output:
+	// welcome email sent
+	// true
+}
+
+func ExampleScheduler_Stop() {
+	s := tasks.New(tasks.WithWorkers(1))
+	for range 3 {
+		_ = s.Submit(func(ctx context.Context) error {
+			// Never finishes on its own.
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	fmt.Println(s.Stop(ctx))
+	// Output:
+	// 3 tasks abandoned
+}
@@ -0,0 +1,170 @@
+// Package tasks runs ad-hoc background tasks submitted at runtime, such as
+// sending a notification after a request, as a run.Group component: tasks
+// run on a bounded pool, and stopping the component rejects new tasks and
+// drains the ones already submitted within the stop timeout.
+package tasks
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/not-for-prod/run"
+)
+
+// DefaultWorkers is the default number of tasks running at the same time.
+const DefaultWorkers = 8
+
+// ErrStopped is returned by Submit once the scheduler is stopping.
+var ErrStopped = errors.New("scheduler stopped")
+
+// AbandonedError is returned by Stop when tasks were still queued or running
+// when its context was done.
+type AbandonedError struct {
+	Tasks int // number of tasks that did not finish
+}
+
+// Error reports the number of abandoned tasks.
+func (e *AbandonedError) Error() string {
+	return strconv.Itoa(e.Tasks) + " tasks abandoned"
+}
+
+// Option configures a Scheduler.
+type Option func(*Scheduler)
+
+// WithWorkers returns an Option that sets how many tasks run at the same
+// time. Values less than one are treated as one.
+//
+// Default is DefaultWorkers.
+func WithWorkers(n int) Option {
+	return func(s *Scheduler) {
+		s.workers = make(chan struct{}, max(n, 1))
+	}
+}
+
+// WithErrorHandler returns an Option that passes errors returned by tasks to
+// fn. By default they are dropped.
+func WithErrorHandler(fn func(error)) Option {
+	return func(s *Scheduler) {
+		s.onError = fn
+	}
+}
+
+// Scheduler runs submitted tasks on a bounded pool.
+type Scheduler struct {
+	workers chan struct{} // holds a token for every running task
+	onError func(error)
+
+	ctx    context.Context // canceled once stopping tasks are abandoned
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	closed  bool
+	pending int           // tasks submitted and not finished
+	idle    chan struct{} // closed when pending drops to zero after close
+}
+
+// New returns a Scheduler configured by opts.
+func New(opts ...Option) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		workers: make(chan struct{}, DefaultWorkers),
+		ctx:     ctx,
+		cancel:  cancel,
+		idle:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Submit queues fn to run as soon as a worker is free and returns
+// immediately. fn's context is canceled when Stop gives up waiting for it.
+// Submit returns ErrStopped once the scheduler is stopping.
+func (s *Scheduler) Submit(fn func(ctx context.Context) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStopped
+	}
+	s.pending++
+	go s.run(fn)
+	return nil
+}
+
+// run waits for a worker and calls fn.
+func (s *Scheduler) run(fn func(ctx context.Context) error) {
+	defer s.finish()
+
+	select {
+	case s.workers <- struct{}{}:
+	case <-s.ctx.Done():
+		return
+	}
+	defer func() { <-s.workers }()
+
+	if err := fn(s.ctx); err != nil && s.onError != nil {
+		s.onError(err)
+	}
+}
+
+// finish accounts for a task that returned or was skipped.
+func (s *Scheduler) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending--
+	if s.closed && s.pending == 0 {
+		close(s.idle)
+	}
+}
+
+// Close rejects further submissions without waiting for submitted tasks.
+func (s *Scheduler) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		if s.pending == 0 {
+			close(s.idle)
+		}
+	}
+}
+
+// Stop rejects further submissions and waits for every submitted task to
+// finish, including queued ones. If ctx is done first, the remaining tasks
+// are abandoned: queued ones are skipped, running ones have their context
+// canceled, and Stop returns an AbandonedError counting them.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.Close()
+
+	select {
+	case <-s.idle:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	n := s.pending
+	s.mu.Unlock()
+	s.cancel()
+	if n == 0 {
+		return nil
+	}
+	return &AbandonedError{Tasks: n}
+}
+
+// Register adds a component to g that rejects new tasks once the group
+// drains and drains s when it stops, and returns its handle. Further
+// component options, such as Named, may be passed in opts.
+func (s *Scheduler) Register(g *run.Group, opts ...run.ComponentOption) *run.Handle {
+	opts = append([]run.ComponentOption{run.Named("tasks"), run.Drains(run.DrainerFunc(func(context.Context) error {
+		s.Close()
+		return nil
+	}))}, opts...)
+	return g.Add(func() error { return nil }, s.Stop, opts...)
+}
@@ -0,0 +1,105 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TimeoutPolicy decides the start and stop timeouts of individual
+// components, such as from their labels, the environment or the durations
+// of earlier runs. A zero duration leaves the component bounded by the
+// group's start or stop timeout only.
+type TimeoutPolicy interface {
+	// StartTimeoutFor returns the timeout of the component's start call.
+	StartTimeoutFor(c ComponentInfo) time.Duration
+
+	// StopTimeoutFor returns the timeout of the component's stop call.
+	StopTimeoutFor(c ComponentInfo) time.Duration
+}
+
+// WithTimeoutPolicy returns an Option that bounds every start and stop call
+// by the timeout p returns for the component, within the group's start and
+// stop timeouts, which remain the budgets of the phases as a whole. A call
+// that exceeds its timeout leaves the component timed out and fails with an
+// error wrapping ErrStartContextDeadlineExceeded or
+// ErrStopContextDeadlineExceeded; the start phase then fails like on any
+// fatal start error, while the stop phase moves on.
+//
+// Default is no per-component timeouts.
+func WithTimeoutPolicy(p TimeoutPolicy) Option {
+	return optionFunc(func(o *options) {
+		o.timeoutPolicy = p
+	})
+}
+
+// StartTimeout returns a ComponentOption that bounds the component's start
+// call by d, like a TimeoutPolicy would, taking precedence over the one set
+// with WithTimeoutPolicy. The group's start timeout still bounds the start
+// phase as a whole.
+func StartTimeout(d time.Duration) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.startTimeout = d
+	})
+}
+
+// StopTimeout returns a ComponentOption that bounds the component's stop
+// call by d, like a TimeoutPolicy would, taking precedence over the one set
+// with WithTimeoutPolicy. The group's stop timeout still bounds the stop
+// phase as a whole.
+func StopTimeout(d time.Duration) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.stopTimeout = d
+	})
+}
+
+// startTimeout returns c's start timeout, zero for none.
+func (g *Group) startTimeout(c *component) time.Duration {
+	if c.startTimeout > 0 {
+		return c.startTimeout
+	}
+	if p := g.opts.timeoutPolicy; p != nil {
+		return p.StartTimeoutFor(c.info())
+	}
+	return 0
+}
+
+// stopTimeout returns c's stop timeout, zero for none.
+func (g *Group) stopTimeout(c *component) time.Duration {
+	if c.stopTimeout > 0 {
+		return c.stopTimeout
+	}
+	if p := g.opts.timeoutPolicy; p != nil {
+		return p.StopTimeoutFor(c.info())
+	}
+	return 0
+}
+
+// within calls fn with ctx bounded by d. If fn has not returned by then, c
+// is marked timed out and an error wrapping deadlineErr is returned while fn
+// keeps running in the background. Zero d calls fn with ctx as it is.
+func (c *component) within(ctx context.Context, d time.Duration, inFlight State, deadlineErr error, fn func(ctx context.Context) error) error {
+	if d <= 0 {
+		return fn(ctx)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(callCtx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-callCtx.Done():
+		if err := ctx.Err(); err != nil {
+			// The phase ended first; it reports its own deadline.
+			return err
+		}
+		c.transition(inFlight, StateTimedOut)
+		return fmt.Errorf("%w: component timeout %s exceeded", deadlineErr, d)
+	}
+}
@@ -0,0 +1,110 @@
+package tlsreload_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/tlsreload"
+)
+
+// writeCert writes a self-signed certificate for name and its key to dir.
+func writeCert(dir, name string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(filepath.Join(dir, "tls.crt"), certPEM, 0o600); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "tls.key"), keyPEM, 0o600)
+}
+
+// commonName returns the subject common name of c's current certificate.
+func commonName(c *tlsreload.Certificate) string {
+	leaf, err := x509.ParseCertificate(c.Certificate().Certificate[0])
+	if err != nil {
+		return err.Error()
+	}
+	return leaf.Subject.CommonName
+}
+
+func ExampleCertificate_Reload() {
+	dir, err := os.MkdirTemp("", "tlsreload")
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	if err := writeCert(dir, "v1.example.com"); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	cert, err := tlsreload.New(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"))
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println("serving:", commonName(cert))
+
+	if err := writeCert(dir, "v2.example.com"); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	if err := cert.Reload(context.Background()); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println("serving:", commonName(cert))
+	// Output:
+	// serving: v1.example.com
+	// serving: v2.example.com
+}
+
+func ExampleCertificate_Watch() {
+	cert, err := tlsreload.New("/etc/tls/tls.crt", "/etc/tls/tls.key")
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+
+	srv := &http.Server{Addr: ":8443", TLSConfig: cert.TLSConfig()}
+
+	g := run.NewGroup()
+	cert.Watch(g)
+	g.Add(func() error {
+		go srv.ListenAndServeTLS("", "")
+		return nil
+	}, srv.Shutdown, run.Named("https"))
+
+	_ = g.Wait(context.Background())
+}
@@ -0,0 +1,80 @@
+// Package tlsreload serves TLS certificates that are reloaded from disk when
+// they are rotated, so servers and clients pick up renewed certificates
+// without a restart.
+package tlsreload
+
+import (
+	"context"
+	"crypto/tls"
+	"sync/atomic"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/configwatch"
+)
+
+// Certificate is a key pair loaded from a certificate and a key file. Its
+// GetCertificate and GetClientCertificate methods plug into tls.Config and
+// always return the most recently loaded pair. It implements run.Reloader,
+// so it can also be reloaded through run.Group.Reload.
+type Certificate struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+var _ run.Reloader = (*Certificate)(nil)
+
+// New loads the key pair from certFile and keyFile, which contain PEM
+// encoded data as accepted by tls.LoadX509KeyPair.
+func New(certFile, keyFile string) (*Certificate, error) {
+	c := &Certificate{certFile: certFile, keyFile: keyFile}
+	if err := c.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload loads the key pair from disk again and swaps it in atomically. If
+// loading fails, the previous pair stays in use and the error is returned.
+func (c *Certificate) Reload(context.Context) error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+	c.cert.Store(&cert)
+	return nil
+}
+
+// Watch adds a configwatch component to g that reloads the key pair whenever
+// the certificate or key file changes, and returns its handle. Errors while
+// reloading go to the handler set with configwatch.WithErrorHandler.
+func (c *Certificate) Watch(g *run.Group, opts ...configwatch.Option) *run.Handle {
+	opts = append([]configwatch.Option{configwatch.WithName("tlsreload")}, opts...)
+	return configwatch.Watch(g, c.Reload, []string{c.certFile, c.keyFile}, opts...)
+}
+
+// Certificate returns the current key pair.
+func (c *Certificate) Certificate() *tls.Certificate {
+	return c.cert.Load()
+}
+
+// GetCertificate returns the current key pair. It can be used as
+// tls.Config.GetCertificate by servers.
+func (c *Certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// GetClientCertificate returns the current key pair. It can be used as
+// tls.Config.GetClientCertificate by clients authenticating with mutual TLS.
+func (c *Certificate) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// TLSConfig returns a tls.Config for servers presenting the current key
+// pair.
+func (c *Certificate) TLSConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: c.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+}
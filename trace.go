@@ -0,0 +1,86 @@
+package run
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// span is a completed call of a component.
+type span struct {
+	phase Phase
+	began time.Time
+	ended time.Time
+	err   error
+}
+
+// traceEvent is an event in the Chrome trace event format.
+type traceEvent struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat,omitempty"`
+	Phase string         `json:"ph"`
+	TS    int64          `json:"ts"`
+	Dur   int64          `json:"dur,omitempty"`
+	PID   int            `json:"pid"`
+	TID   int            `json:"tid"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+// WriteTrace writes the start and stop timeline of the group to w as a
+// Chrome trace event JSON file, with one track per component in
+// registration order. Open it in chrome://tracing or ui.perfetto.dev to see
+// which components serialize boot. Reload and rotate calls are included.
+//
+// Timestamps are relative to the first recorded call. Calls still running
+// are not included.
+func (g *Group) WriteTrace(w io.Writer) error {
+	g.mu.Lock()
+	components := g.registry.list()
+	var epoch time.Time
+	for _, c := range components {
+		for _, s := range c.spans {
+			if epoch.IsZero() || s.began.Before(epoch) {
+				epoch = s.began
+			}
+		}
+	}
+
+	events := make([]traceEvent, 0, 2*len(components))
+	for i, c := range components {
+		events = append(events, traceEvent{
+			Name:  "thread_name",
+			Phase: "M",
+			PID:   1,
+			TID:   i + 1,
+			Args:  map[string]any{"name": c.name},
+		})
+		for _, s := range c.spans {
+			e := traceEvent{
+				Name:  string(s.phase),
+				Cat:   c.name,
+				Phase: "X",
+				TS:    s.began.Sub(epoch).Microseconds(),
+				Dur:   max(s.ended.Sub(s.began).Microseconds(), 1),
+				PID:   1,
+				TID:   i + 1,
+			}
+			if s.err != nil {
+				e.Args = map[string]any{"error": s.err.Error()}
+			}
+			events = append(events, e)
+		}
+	}
+	g.mu.Unlock()
+
+	return json.NewEncoder(w).Encode(struct {
+		TraceEvents     []traceEvent `json:"traceEvents"`
+		DisplayTimeUnit string       `json:"displayTimeUnit"`
+	}{events, "ms"})
+}
+
+// trace records a completed call of c that began at began.
+func (g *Group) trace(c *component, phase Phase, began time.Time, err error) {
+	g.mu.Lock()
+	c.spans = append(c.spans, span{phase: phase, began: began, ended: time.Now(), err: err})
+	g.mu.Unlock()
+}
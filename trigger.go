@@ -0,0 +1,36 @@
+package run
+
+import "context"
+
+// triggerContext carries the values of the context that triggered
+// shutdown, such as the trace of an admin request, on top of the values of
+// the context passed to Wait.
+type triggerContext struct {
+	context.Context
+	trigger context.Context
+}
+
+// Value returns the trigger's value for key, or else the Wait context's.
+func (c triggerContext) Value(key any) any {
+	if v := c.trigger.Value(key); v != nil {
+		return v
+	}
+	return c.Context.Value(key)
+}
+
+// stopContext returns the parent of the contexts passed to stop and force
+// stop calls: it is never canceled, and it carries the values of the
+// context passed to Wait and, taking precedence, of the context passed to
+// the Stop call that began shutdown, so stop calls can correlate their logs
+// and spans with what triggered them.
+func (g *Group) stopContext() context.Context {
+	ctx := context.WithoutCancel(g.life.context())
+
+	g.mu.Lock()
+	trigger := g.stopTrigger
+	g.mu.Unlock()
+	if trigger == nil {
+		return ctx
+	}
+	return triggerContext{Context: ctx, trigger: trigger}
+}
@@ -0,0 +1,10 @@
+// Package upgrade replaces a running binary without dropping connections, for
+// services on hosts without an orchestrator.
+//
+// On Upgrade the current process starts the new binary, hands it its
+// listening sockets and waits until the new process reports that its group
+// is ready; only then does the current process stop its own group. The new
+// process adopts the inherited listeners through Listen and takes over the
+// pidfile. Upgrades rely on inheriting file descriptors and are supported on
+// Unix systems only.
+package upgrade
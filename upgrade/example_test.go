@@ -0,0 +1,172 @@
+//go:build unix
+
+package upgrade_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/not-for-prod/run"
+	"github.com/not-for-prod/run/upgrade"
+)
+
+// envChild makes the test binary act as the new process of
+// ExampleUpgrader_Upgrade, serving on the listener it inherits.
+const envChild = "UPGRADE_EXAMPLE_CHILD"
+
+func TestMain(m *testing.M) {
+	if pidFile := os.Getenv(envChild); pidFile != "" {
+		os.Unsetenv(envChild)
+		if err := serveNew(pidFile); err != nil {
+			fmt.Fprintln(os.Stderr, "new process:", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// serveNew adopts the listener of the old process, reports readiness and
+// answers a single request.
+func serveNew(pidFile string) error {
+	u, err := upgrade.New(upgrade.WithPIDFile(pidFile))
+	if err != nil {
+		return err
+	}
+	l, err := u.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "new")
+		cancel()
+	})}
+
+	g := run.NewGroup()
+	g.Add(func() error {
+		go srv.Serve(l)
+		return nil
+	}, srv.Shutdown, run.Named("http"))
+	return g.Wait(upgrade.Bind(ctx, g, u))
+}
+
+func ExampleUpgrader_Upgrade() {
+	dir, _ := os.MkdirTemp("", "upgrade")
+	defer os.RemoveAll(dir)
+	pidFile := filepath.Join(dir, "api.pid")
+
+	// The new binary is this one, acting as the new process.
+	os.Setenv(envChild, pidFile)
+	defer os.Unsetenv(envChild)
+
+	u, err := upgrade.New(upgrade.WithPIDFile(pidFile))
+	if err != nil {
+		fmt.Println("new:", err)
+		return
+	}
+	l, err := u.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Println("listen:", err)
+		return
+	}
+	addr := l.Addr().String()
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "old")
+	})}
+
+	g := run.NewGroup()
+	g.Add(func() error {
+		go srv.Serve(l)
+		return nil
+	}, srv.Shutdown, run.Named("http"))
+
+	ctx := upgrade.Bind(context.Background(), g, u)
+	g.OnReadyChange(func(ready bool) {
+		if !ready {
+			return
+		}
+		fmt.Println("before:", get(addr))
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := u.Upgrade(ctx); err != nil {
+				fmt.Println("upgrade:", err)
+			}
+		}()
+	})
+
+	// Wait returns once the new process is ready.
+	if err := g.Wait(ctx); err != nil {
+		fmt.Println("wait:", err)
+	}
+
+	// The old process closed its listener, but the socket lives on in the
+	// new process, which took over the pidfile.
+	fmt.Println("after:", get(addr))
+	b, _ := os.ReadFile(pidFile)
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(b)))
+	fmt.Println("pidfile taken over:", pid != os.Getpid())
+	// Output:
+	// before: old
+	// after: new
+	// pidfile taken over: true
+}
+
+// get returns the body served at addr.
+func get(addr string) string {
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err := client.Get("http://" + addr)
+	if err != nil {
+		return err.Error()
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return string(b)
+}
+
+func ExampleBind() {
+	u, err := upgrade.New(upgrade.WithPIDFile("/run/api.pid"))
+	if err != nil {
+		panic(err)
+	}
+
+	// Adopts the listener of the previous process after an upgrade.
+	l, err := u.Listen("tcp", ":8080")
+	if err != nil {
+		panic(err)
+	}
+	srv := &http.Server{}
+
+	g := run.NewGroup()
+	g.Add(func() error {
+		go srv.Serve(l)
+		return nil
+	}, srv.Shutdown, run.Named("http"))
+
+	// Upgrade on SIGUSR2 after replacing the binary on disk.
+	usr2 := make(chan os.Signal, 1)
+	signal.Notify(usr2, syscall.SIGUSR2)
+	go func() {
+		for range usr2 {
+			_ = u.Upgrade(context.Background())
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	_ = g.Wait(upgrade.Bind(ctx, g, u))
+}
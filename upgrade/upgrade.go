@@ -0,0 +1,339 @@
+//go:build unix
+
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/not-for-prod/run"
+)
+
+// Environment variables passed to the new process.
+const (
+	envListeners = "RUN_UPGRADE_LISTENERS" // comma-separated listener keys, in descriptor order from 3
+	envReady     = "RUN_UPGRADE_READY"     // descriptor of the readiness pipe
+)
+
+var (
+	// ErrInProgress is returned by Upgrade while another upgrade is running.
+	ErrInProgress = errors.New("upgrade in progress")
+
+	// ErrUpgraded is returned by Upgrade after a successful upgrade.
+	ErrUpgraded = errors.New("already upgraded")
+
+	// ErrNotReady is returned by Upgrade when the new process exits or closes
+	// its readiness pipe without reporting readiness.
+	ErrNotReady = errors.New("new process exited before becoming ready")
+)
+
+// Option configures an Upgrader.
+type Option func(*Upgrader)
+
+// WithBinary returns an Option that sets the path of the binary started by
+// Upgrade, for example the location a deploy script downloads releases to.
+//
+// Default is the current executable, which picks up a binary replaced in
+// place.
+func WithBinary(path string) Option {
+	return func(u *Upgrader) {
+		u.binary = path
+	}
+}
+
+// WithPIDFile returns an Option that writes the process ID to path once the
+// process is ready, so that the new process takes the pidfile over from the
+// old one.
+func WithPIDFile(path string) Option {
+	return func(u *Upgrader) {
+		u.pidFile = path
+	}
+}
+
+// Upgrader hands listeners over from the current process to a new one.
+type Upgrader struct {
+	binary  string
+	pidFile string
+
+	mu        sync.Mutex
+	inherited map[string]*os.File     // listeners received from the old process, by key
+	listeners map[string]net.Listener // listeners to pass on, by key
+	keys      []string                // keys of listeners, in creation order
+	readyPipe *os.File                // readiness pipe to the old process, nil when none
+	upgrading bool
+	exit      chan struct{} // closed after a successful upgrade
+}
+
+// New returns an Upgrader, adopting the listeners and readiness pipe passed
+// down by the old process when the current process was started by Upgrade.
+func New(opts ...Option) (*Upgrader, error) {
+	u := &Upgrader{
+		inherited: make(map[string]*os.File),
+		listeners: make(map[string]net.Listener),
+		exit:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	if u.binary == "" {
+		binary, err := os.Executable()
+		if err != nil {
+			return nil, err
+		}
+		u.binary = binary
+	}
+
+	if keys := os.Getenv(envListeners); keys != "" {
+		for i, key := range strings.Split(keys, ",") {
+			u.inherited[key] = os.NewFile(uintptr(3+i), key)
+		}
+	}
+	if fd := os.Getenv(envReady); fd != "" {
+		n, err := strconv.Atoi(fd)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", envReady, err)
+		}
+		u.readyPipe = os.NewFile(uintptr(n), "ready")
+	}
+	os.Unsetenv(envListeners)
+	os.Unsetenv(envReady)
+	return u, nil
+}
+
+// Listen returns a listener for network and address, adopting the one
+// inherited from the old process if it listened on the same network and
+// address, and registers it for the next upgrade. Only TCP and Unix
+// listeners can be handed over.
+func (u *Upgrader) Listen(network, address string) (net.Listener, error) {
+	key := network + ":" + address
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, ok := u.listeners[key]; ok {
+		return nil, fmt.Errorf("listener %s already created", key)
+	}
+
+	var l net.Listener
+	if f, ok := u.inherited[key]; ok {
+		delete(u.inherited, key)
+		var err error
+		l, err = net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("adopt listener %s: %w", key, err)
+		}
+	} else {
+		var err error
+		l, err = net.Listen(network, address)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// A Unix socket file is owned by the last process; do not remove it
+	// from underneath the new one.
+	if ul, ok := l.(*net.UnixListener); ok {
+		ul.SetUnlinkOnClose(false)
+	}
+
+	u.listeners[key] = l
+	u.keys = append(u.keys, key)
+	return l, nil
+}
+
+// Ready reports readiness: it writes the pidfile, closes inherited listeners
+// that were not adopted and, in a process started by Upgrade, tells the old
+// process to stop. It is safe to call more than once.
+func (u *Upgrader) Ready() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for key, f := range u.inherited {
+		f.Close()
+		delete(u.inherited, key)
+	}
+
+	if u.pidFile != "" {
+		if err := writePIDFile(u.pidFile); err != nil {
+			return err
+		}
+	}
+
+	if u.readyPipe == nil {
+		return nil
+	}
+	_, err := u.readyPipe.Write([]byte{1})
+	u.readyPipe.Close()
+	u.readyPipe = nil
+	return err
+}
+
+// Upgrade starts the new binary with the current arguments and environment,
+// passing it every listener created with Listen, and waits until it reports
+// readiness or ctx is done. A new process that does not become ready in time
+// is killed and the current process keeps running.
+//
+// After a successful upgrade Exit is closed; the current process should then
+// stop its group, which keeps serving in-flight requests while the new
+// process accepts new connections on the shared listeners.
+func (u *Upgrader) Upgrade(ctx context.Context) error {
+	u.mu.Lock()
+	switch {
+	case u.upgrading:
+		u.mu.Unlock()
+		return ErrInProgress
+	case u.upgraded():
+		u.mu.Unlock()
+		return ErrUpgraded
+	}
+	u.upgrading = true
+	keys := slices.Clone(u.keys)
+	files, err := u.files()
+	u.mu.Unlock()
+
+	defer func() {
+		u.mu.Lock()
+		u.upgrading = false
+		u.mu.Unlock()
+	}()
+	defer closeFiles(files)
+	if err != nil {
+		return err
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	cmd := exec.Command(u.binary, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = append(files, w)
+	cmd.Env = append(os.Environ(),
+		envListeners+"="+strings.Join(keys, ","),
+		envReady+"="+strconv.Itoa(3+len(files)),
+	)
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		return fmt.Errorf("start %s: %w", u.binary, err)
+	}
+
+	ready := make(chan error, 1)
+	go func() {
+		var b [1]byte
+		if n, _ := r.Read(b[:]); n == 1 {
+			ready <- nil
+			return
+		}
+		ready <- ErrNotReady
+	}()
+
+	select {
+	case err = <-ready:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+
+	// The new process outlives this one.
+	_ = cmd.Process.Release()
+	close(u.exit)
+	return nil
+}
+
+// Exit returns a channel that is closed after a successful upgrade.
+func (u *Upgrader) Exit() <-chan struct{} {
+	return u.exit
+}
+
+// upgraded reports whether an upgrade succeeded.
+func (u *Upgrader) upgraded() bool {
+	select {
+	case <-u.exit:
+		return true
+	default:
+		return false
+	}
+}
+
+// files duplicates the descriptors of the listeners in creation order. The
+// caller must hold u.mu.
+func (u *Upgrader) files() ([]*os.File, error) {
+	files := make([]*os.File, 0, len(u.keys))
+	for _, key := range u.keys {
+		l, ok := u.listeners[key].(interface{ File() (*os.File, error) })
+		if !ok {
+			return files, fmt.Errorf("listener %s cannot be handed over", key)
+		}
+		f, err := l.File()
+		if err != nil {
+			return files, fmt.Errorf("listener %s: %w", key, err)
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// closeFiles closes every file in files.
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+// writePIDFile atomically replaces path with the current process ID.
+func writePIDFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(strconv.Itoa(os.Getpid()) + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Bind reports readiness through u once g is ready and ends g's run after a
+// successful upgrade: the returned context is canceled when ctx is or when
+// Exit is closed, and should be passed to g.Wait.
+func Bind(ctx context.Context, g *run.Group, u *Upgrader) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-u.exit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	g.OnReadyChange(func(ready bool) {
+		if ready {
+			_ = u.Ready()
+		}
+	})
+	return ctx
+}
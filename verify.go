@@ -0,0 +1,42 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultVerifyTimeout is the default duration of a single verify call. It
+// can be customized using the WithVerifyTimeout option.
+const DefaultVerifyTimeout = 5 * time.Second
+
+// ErrVerifyFailed is returned, wrapping the check's error, when a component
+// fails its post-start check registered with Verify.
+var ErrVerifyFailed = errors.New("verify failed")
+
+// Verify returns a ComponentOption that registers a smoke test run right
+// after the component's start function returned nil, such as a query
+// against a database pool or a request to a server's own health endpoint,
+// catching components that started without error but do not actually work.
+// Each check gets its own verify timeout within the start timeout. A failed
+// check fails the component's start with an error wrapping ErrVerifyFailed.
+func Verify(check func(ctx context.Context) error) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.verify = check
+	})
+}
+
+// callStart calls start for c followed by c's check registered with Verify.
+func (g *Group) callStart(ctx context.Context, c *component, start func(ctx context.Context) error) error {
+	if err := start(ctx); err != nil || c.verify == nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, g.opts.verifyTimeout)
+	defer cancel()
+	if err := c.verify(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrVerifyFailed, err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+// ErrWarmupContextDeadlineExceeded is returned when warming up exceeds the configured timeout.
+var ErrWarmupContextDeadlineExceeded = errors.New("warmup context deadline exceeded")
+
+// Warmer is implemented by components that need to prepare after starting
+// before the instance should receive traffic, such as priming caches or
+// precomputing lookup tables. Register it with the Warms component option.
+type Warmer interface {
+	// Warmup prepares the started component.
+	Warmup(ctx context.Context) error
+}
+
+// WarmerFunc adapts an ordinary function to the Warmer interface.
+type WarmerFunc func(ctx context.Context) error
+
+// Warmup calls f(ctx).
+func (f WarmerFunc) Warmup(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Warms returns a ComponentOption that registers w to be called in the
+// warmup phase, after every component has started and before the group
+// reports ready. Warmup errors are classified like start errors: a fatal
+// error aborts the group, while an error marked with Degraded only marks it
+// degraded.
+func Warms(w Warmer) ComponentOption {
+	return componentOptionFunc(func(c *component) {
+		c.warmer = w
+	})
+}
+
+// warmup runs the warmup phase: it calls the warmers of all running
+// components concurrently on the worker pool within the warmup timeout,
+// reporting progress as they finish, and returns their joined fatal errors.
+func (g *Group) warmup(ctx context.Context, s *schedule) error {
+	var warmers []*component
+	for _, wave := range s.waves {
+		for _, c := range wave {
+			if c.warmer != nil && c.loadState() == StateRunning {
+				warmers = append(warmers, c)
+			}
+		}
+	}
+	if len(warmers) == 0 {
+		return nil
+	}
+
+	g.logGroup("group warming up")
+	g.warming.Store(true)
+	defer g.warming.Store(false)
+
+	var mu sync.Mutex
+	waiting := slices.Clone(warmers)
+	report := func() {
+		if g.opts.progress == nil {
+			return
+		}
+		g.progressMu.Lock()
+		defer g.progressMu.Unlock()
+
+		mu.Lock()
+		p := Progress{Phase: PhaseWarmup, Started: len(warmers) - len(waiting), Total: len(warmers)}
+		for _, c := range waiting {
+			p.Waiting = append(p.Waiting, c.name)
+		}
+		mu.Unlock()
+		g.opts.progress(p)
+	}
+
+	err := g.callAll(ctx, warmers, g.opts.warmupTimeout, PhaseWarmup, ErrWarmupContextDeadlineExceeded,
+		func(ctx context.Context, c *component) error {
+			return c.warmer.Warmup(ctx)
+		},
+		func(c *component, err error) error {
+			// Classified after the transformer, as on start.
+			err = g.classify(c, PhaseWarmup, err)
+			if SeverityOf(err) == SeverityDegraded {
+				g.degrade()
+			}
+			mu.Lock()
+			waiting = slices.DeleteFunc(waiting, func(w *component) bool { return w == c })
+			mu.Unlock()
+			report()
+			return err
+		})
+
+	// Like start errors, only fatal warmup errors abort the group.
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return err
+	}
+	var errs []error
+	for _, err := range joined.Unwrap() {
+		if SeverityOf(err) == SeverityFatal {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}